@@ -0,0 +1,44 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/pprof"
+	"time"
+
+	"github.com/cert-manager/cert-manager/pkg/acme/webhook"
+	"github.com/rikotsev/cert-manager-webhook-designate/internal/resolver"
+	"k8s.io/klog/v2"
+)
+
+// newDebugServer returns a server exposing the pprof endpoints under /debug/pprof on addr, separate
+// from the webhook's own port, and the redacted cache state of the solver under /debug/caches. It
+// returns nil when addr is empty, which disables profiling.
+func newDebugServer(addr string, solver webhook.Solver) *http.Server {
+	if addr == "" {
+		return nil
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	if dumper, ok := solver.(resolver.CacheDumper); ok {
+		mux.HandleFunc("/debug/caches", func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			encoder := json.NewEncoder(w)
+			encoder.SetIndent("", "  ")
+			if err := encoder.Encode(dumper.DumpCaches()); err != nil {
+				klog.ErrorS(err, "failed to write the cache dump")
+			}
+		})
+	}
+
+	return &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+}
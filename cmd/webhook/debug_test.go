@@ -0,0 +1,29 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rikotsev/cert-manager-webhook-designate/internal/resolver"
+)
+
+func TestNewDebugServer(t *testing.T) {
+	if server := newDebugServer("", resolver.New()); server != nil {
+		t.Errorf("expected no debug server when disabled, got one on %s", server.Addr)
+	}
+
+	server := newDebugServer("localhost:6060", resolver.New())
+	if server == nil {
+		t.Fatal("expected a debug server when enabled, got none")
+	}
+
+	for _, path := range []string{"/debug/pprof/", "/debug/pprof/cmdline", "/debug/pprof/symbol", "/debug/pprof/heap", "/debug/caches"} {
+		recorder := httptest.NewRecorder()
+		server.Handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+
+		if recorder.Code != http.StatusOK {
+			t.Errorf("expected status %d for %s, got %d", http.StatusOK, path, recorder.Code)
+		}
+	}
+}
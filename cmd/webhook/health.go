@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/cert-manager/cert-manager/pkg/acme/webhook"
+	"github.com/rikotsev/cert-manager-webhook-designate/internal/resolver"
+)
+
+// newHealthServer returns a server on port answering liveness probes under /healthz and readiness
+// probes under /readyz, separate from the webhook's own port, and serving the build information
+// under /version. /readyz fails until the solver is initialized and while it reports itself not
+// ready. It returns nil when port is empty, which disables the probes.
+func newHealthServer(port string, solver webhook.Solver) *http.Server {
+	if port == "" {
+		return nil
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprintln(w, "ok")
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, _ *http.Request) {
+		if checker, ok := solver.(resolver.ReadinessChecker); ok {
+			if err := checker.Ready(); err != nil {
+				http.Error(w, err.Error(), http.StatusServiceUnavailable)
+				return
+			}
+		}
+		fmt.Fprintln(w, "ok")
+	})
+	mux.HandleFunc("/version", versionHandler)
+
+	return &http.Server{
+		Addr:              net.JoinHostPort("", port),
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rikotsev/cert-manager-webhook-designate/internal/resolver"
+	"k8s.io/client-go/rest"
+)
+
+func TestNewHealthServer(t *testing.T) {
+	if server := newHealthServer("", resolver.New()); server != nil {
+		t.Errorf("expected no health server when disabled, got one on %s", server.Addr)
+	}
+
+	solver := resolver.New()
+	server := newHealthServer("8081", solver)
+	if server == nil {
+		t.Fatal("expected a health server when enabled, got none")
+	}
+	if server.Addr != ":8081" {
+		t.Errorf("expected the health server on :8081, got %s", server.Addr)
+	}
+
+	running := httptest.NewServer(server.Handler)
+	t.Cleanup(running.Close)
+
+	assertStatus := func(path string, expected int) {
+		t.Helper()
+
+		resp, err := http.Get(running.URL + path)
+		if err != nil {
+			t.Fatalf("expected no error requesting %s, got %v", path, err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != expected {
+			t.Errorf("expected status %d for %s, got %d", expected, path, resp.StatusCode)
+		}
+	}
+
+	assertStatus("/healthz", http.StatusOK)
+	assertStatus("/readyz", http.StatusServiceUnavailable)
+
+	stopCh := make(chan struct{})
+	t.Cleanup(func() { close(stopCh) })
+	if err := solver.Initialize(&rest.Config{Host: "https://localhost:6443"}, stopCh); err != nil {
+		t.Fatalf("expected no error initializing, got %v", err)
+	}
+
+	assertStatus("/healthz", http.StatusOK)
+	assertStatus("/readyz", http.StatusOK)
+}
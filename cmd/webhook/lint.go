@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/rikotsev/cert-manager-webhook-designate/internal/resolver"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/yaml"
+)
+
+// lintCommand is the first argument selecting the lint subcommand instead of the webhook server.
+const lintCommand = "lint"
+
+// runLint lints an issuer solver config and its credentials secret, read from a manifest file or from
+// the cluster of the current kubeconfig, and prints every problem found. It returns the exit code: 0
+// without problems, 1 with problems and 2 for usage errors.
+func runLint(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet(lintCommand, flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	configFile := flags.String("config", "", "file holding the solver config of the issuer, as JSON or YAML")
+	secretFile := flags.String("secret-file", "", "file holding the credentials secret manifest")
+	secretRef := flags.String("secret", "", "credentials secret in the cluster, as namespace/name")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if *configFile == "" || (*secretFile != "" && *secretRef != "") {
+		fmt.Fprintf(stderr, "usage: webhook %s -config FILE [-secret-file FILE | -secret NAMESPACE/NAME]\n", lintCommand)
+		return 2
+	}
+
+	config, err := readConfig(*configFile)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+
+	var secret *corev1.Secret
+	switch {
+	case *secretFile != "":
+		secret, err = readSecret(*secretFile)
+	case *secretRef != "":
+		secret, err = fetchSecret(ctx, *secretRef)
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+
+	problems := resolver.Lint(ctx, config, secret)
+	for _, problem := range problems {
+		fmt.Fprintln(stdout, "-", problem)
+	}
+	if len(problems) > 0 {
+		return 1
+	}
+
+	fmt.Fprintln(stdout, "no problems found")
+	return 0
+}
+
+// readConfig reads the solver config, converting YAML to the JSON the webhook receives.
+func readConfig(path string) ([]byte, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := yaml.YAMLToJSON(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the config from %s: %w", path, err)
+	}
+
+	return config, nil
+}
+
+func readSecret(path string) (*corev1.Secret, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	secret := new(corev1.Secret)
+	if err := yaml.Unmarshal(content, secret); err != nil {
+		return nil, fmt.Errorf("failed to read the secret from %s: %w", path, err)
+	}
+
+	return secret, nil
+}
+
+func fetchSecret(ctx context.Context, ref string) (*corev1.Secret, error) {
+	namespace, name, ok := strings.Cut(ref, "/")
+	if !ok || namespace == "" || name == "" {
+		return nil, fmt.Errorf("invalid secret %q, expected namespace/name", ref)
+	}
+
+	restConfig, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(clientcmd.NewDefaultClientConfigLoadingRules(), nil).ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return client.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunLint(t *testing.T) {
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "config.yaml")
+	secretFile := filepath.Join(dir, "secret.yaml")
+
+	if err := os.WriteFile(configFile, []byte(`
+secretName: designate-credentials
+secretNamespace: cert-manager
+strategy:
+  kind: ZoneName
+`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(secretFile, []byte(`
+apiVersion: v1
+kind: Secret
+metadata:
+  name: designate-credentials
+  namespace: cert-manager
+stringData:
+  tenantName: testTenant
+  domainName: testDomainName
+  username: john-doe
+  region: RegionOne
+  identityEndpoint: http://127.0.0.1:1
+`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	code := runLint(context.Background(), []string{"-config", configFile, "-secret-file", secretFile}, &stdout, &stderr)
+	if code != 1 {
+		t.Fatalf("expected exit code 1, got %d, stderr: %s", code, stderr.String())
+	}
+
+	for _, expected := range []string{"strategy.zoneName is required", "missing auth value: password"} {
+		if !strings.Contains(stdout.String(), expected) {
+			t.Errorf("expected the output to contain %q, got %s", expected, stdout.String())
+		}
+	}
+
+	if code := runLint(context.Background(), []string{"-secret-file", secretFile}, &stdout, &stderr); code != 2 {
+		t.Errorf("expected exit code 2 without a config, got %d", code)
+	}
+}
@@ -1,18 +1,145 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/cert-manager/cert-manager/pkg/acme/webhook/cmd"
 	"github.com/rikotsev/cert-manager-webhook-designate/internal/resolver"
+	"k8s.io/klog/v2"
 )
 
 var GroupName = os.Getenv("GROUP_NAME")
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == lintCommand {
+		os.Exit(runLint(context.Background(), os.Args[2:], os.Stdout, os.Stderr))
+	}
+	if len(os.Args) > 1 && os.Args[1] == versionFlag {
+		printVersion(os.Stdout)
+		os.Exit(0)
+	}
+
 	if GroupName == "" {
 		panic("GROUP_NAME must be specified")
 	}
 
-	cmd.RunWebhookServer(GroupName, resolver.New())
+	resolver.SetLogger(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: envLogLevel("LOG_LEVEL")})))
+
+	opts := []resolver.Option{
+		resolver.WithIssuerNamespaceEnforcement(envBool("ENFORCE_ISSUER_NAMESPACE")),
+		resolver.WithSecretEnvFallback(envBool("SECRET_ENV_FALLBACK")),
+		resolver.WithMaxConcurrentChallenges(envInt("MAX_CONCURRENT_CHALLENGES")),
+		resolver.WithZoneCacheTTL(envDuration("ZONE_CACHE_TTL")),
+		resolver.WithZoneListPageSize(envInt("ZONE_LIST_PAGE_SIZE")),
+		resolver.WithPropagationCacheTTL(envDuration("PROPAGATION_CACHE_TTL")),
+		resolver.WithCleanUpCoalescing(envDuration("CLEANUP_COALESCING_WINDOW")),
+		resolver.WithUnknownSecretKeys(resolver.UnknownSecretKeys(strings.ToLower(os.Getenv("UNKNOWN_SECRET_KEYS")))),
+		resolver.WithClientCreationRetries(envInt("CLIENT_CREATION_RETRIES")),
+		resolver.WithListTimeout(envDuration("LIST_TIMEOUT")),
+		resolver.WithMutateTimeout(envDuration("MUTATE_TIMEOUT")),
+		resolver.WithTimingLogs(envBool("CHALLENGE_TIMING_LOGS")),
+		resolver.WithChallengeEvents(envBool("CHALLENGE_EVENTS")),
+		resolver.WithCapabilityProbe(envBool("CAPABILITY_PROBE")),
+		resolver.WithStartupReconciliation(envDuration("STARTUP_RECONCILIATION_AGE")),
+		resolver.WithRegionValidation(resolver.RegionValidation(strings.ToLower(os.Getenv("REGION_VALIDATION")))),
+	}
+	// Unlike the other settings, the operation timeout and the token cache are on by default.
+	if _, ok := os.LookupEnv("OPERATION_TIMEOUT"); ok {
+		opts = append(opts, resolver.WithOperationTimeout(envDuration("OPERATION_TIMEOUT")))
+	}
+	if _, ok := os.LookupEnv("TOKEN_CACHE"); ok {
+		opts = append(opts, resolver.WithTokenCache(envBool("TOKEN_CACHE")))
+	}
+	if _, ok := os.LookupEnv("TOKEN_EXPIRY_SKEW"); ok {
+		opts = append(opts, resolver.WithTokenExpirySkew(envDuration("TOKEN_EXPIRY_SKEW")))
+	}
+
+	// A broken tracing setup only costs the traces, not the webhook.
+	tracerProvider, err := newTracerProvider(context.Background())
+	if err != nil {
+		klog.ErrorS(err, "failed to set up tracing, continuing without")
+	}
+	if tracerProvider != nil {
+		opts = append(opts, resolver.WithTracerProvider(tracerProvider))
+	}
+
+	solver := resolver.New(opts...)
+
+	var servers []*http.Server
+	if debugServer := newDebugServer(os.Getenv("PPROF_ADDR"), solver); debugServer != nil {
+		servers = append(servers, debugServer)
+	}
+	if metricsServer := newMetricsServer(os.Getenv("METRICS_ADDR")); metricsServer != nil {
+		servers = append(servers, metricsServer)
+	}
+	if healthServer := newHealthServer(os.Getenv("HEALTH_PORT"), solver); healthServer != nil {
+		servers = append(servers, healthServer)
+	}
+	for _, server := range servers {
+		go func() {
+			if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				klog.ErrorS(err, "server stopped", "addr", server.Addr)
+			}
+		}()
+	}
+
+	// The webhook server drains its requests on SIGTERM by itself and then closes the stop channel
+	// of the solver, which drains its challenges in turn.
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer stop()
+
+	klog.InfoS("starting webhook", "version", version, "commit", commit, "buildDate", buildDate)
+	cmd.RunWebhookServer(GroupName, solver)
+
+	shutdown(ctx, solver, servers, shutdownTimeout)
+	shutdownTracing(tracerProvider)
+}
+
+// envBool reads a boolean environment variable, treating unset or unparseable values as false.
+func envBool(name string) bool {
+	value, err := strconv.ParseBool(os.Getenv(name))
+	if err != nil {
+		return false
+	}
+
+	return value
+}
+
+// envInt reads an integer environment variable, treating unset or unparseable values as zero.
+func envInt(name string) int {
+	value, err := strconv.Atoi(os.Getenv(name))
+	if err != nil {
+		return 0
+	}
+
+	return value
+}
+
+// envDuration reads a duration environment variable such as "500ms", treating unset or unparseable values as zero.
+func envDuration(name string) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(name))
+	if err != nil {
+		return 0
+	}
+
+	return value
+}
+
+// envLogLevel reads a log level environment variable such as "debug" or "warn", treating unset or unparseable values as info.
+func envLogLevel(name string) slog.Level {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(os.Getenv(name))); err != nil {
+		return slog.LevelInfo
+	}
+
+	return level
 }
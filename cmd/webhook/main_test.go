@@ -5,6 +5,7 @@ package main
 import (
 	"math/rand"
 	"os"
+	"strings"
 	"testing"
 
 	acmetest "github.com/cert-manager/cert-manager/test/acme"
@@ -22,7 +23,7 @@ func TestRunsSuite(t *testing.T) {
 	//from https://github.com/cert-manager/webhook-example/blob/master/main_test.go
 	fqdn = GetRandomString(20) + "." + zone
 
-	solver := resolver.New()
+	solver := resolver.New(resolver.WithDecisionRecording(true))
 	fixture := acmetest.NewFixture(solver,
 		acmetest.SetResolvedZone(zone),
 		acmetest.SetResolvedFQDN(fqdn),
@@ -31,6 +32,19 @@ func TestRunsSuite(t *testing.T) {
 	)
 
 	fixture.RunConformance(t)
+
+	decision, ok := solver.(resolver.DecisionRecorder).LastDecision()
+	if !ok {
+		t.Fatal("expected the resolver to record its decisions")
+	}
+
+	if decision.Operation != resolver.DecisionOperationCleanUp {
+		t.Errorf("expected the last decision to be a cleanup, got %s", decision.Operation)
+	}
+
+	if strings.TrimSuffix(decision.ZoneName, ".") != strings.TrimSuffix(zone, ".") {
+		t.Errorf("expected zone %s to be chosen, got %s (%s)", zone, decision.ZoneName, decision.ZoneID)
+	}
 }
 
 func GetRandomString(n int) string {
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/rikotsev/cert-manager-webhook-designate/internal/metrics"
+)
+
+// newMetricsServer returns a server exposing the default registry and the webhook's cache metrics
+// under /metrics on addr, separate from the webhook's own port. It returns nil when addr is empty, which disables metrics.
+func newMetricsServer(addr string) *http.Server {
+	if addr == "" {
+		return nil
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(prometheus.Gatherers{prometheus.DefaultGatherer, metrics.Registry}, promhttp.HandlerOpts{}))
+
+	return &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+}
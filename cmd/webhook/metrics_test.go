@@ -0,0 +1,39 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rikotsev/cert-manager-webhook-designate/internal/metrics"
+)
+
+func TestNewMetricsServer(t *testing.T) {
+	if server := newMetricsServer(""); server != nil {
+		t.Errorf("expected no metrics server when disabled, got one on %s", server.Addr)
+	}
+
+	server := newMetricsServer("localhost:9090")
+	if server == nil {
+		t.Fatal("expected a metrics server when enabled, got none")
+	}
+
+	metrics.PresentTotal.WithLabelValues(metrics.ResultSuccess).Add(0)
+	metrics.CacheRequests.WithLabelValues(metrics.CacheOperationZoneList, metrics.CacheResultHit).Add(0)
+	recorder := httptest.NewRecorder()
+	server.Handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, recorder.Code)
+	}
+	body := recorder.Body.String()
+	for _, expected := range []string{
+		`designate_webhook_present_total{result="success"}`,
+		`cert_manager_webhook_designate_cache_requests_total{operation="zone-list",result="hit"}`,
+	} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("expected the metrics to contain %s, got %q", expected, body)
+		}
+	}
+}
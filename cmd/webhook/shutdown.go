@@ -0,0 +1,37 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/cert-manager/cert-manager/pkg/acme/webhook"
+	"github.com/rikotsev/cert-manager-webhook-designate/internal/resolver"
+	"k8s.io/klog/v2"
+)
+
+// shutdownTimeout bounds waiting for the solver to drain and the servers to close, well within the
+// default termination grace period of 30s.
+const shutdownTimeout = 25 * time.Second
+
+// shutdown closes the servers once the webhook server has returned. When signaled is done, i.e. the
+// webhook server returned because of a shutdown signal, it first waits for the solver to drain its
+// challenges in flight, which the servers keep reporting on meanwhile. It gives up after timeout.
+func shutdown(signaled context.Context, solver webhook.Solver, servers []*http.Server, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if waiter, ok := solver.(resolver.ShutdownWaiter); ok && signaled.Err() != nil && waiter.Stopped() != nil {
+		select {
+		case <-waiter.Stopped():
+		case <-ctx.Done():
+			klog.InfoS("gave up waiting for challenges in flight", "timeout", timeout)
+		}
+	}
+
+	for _, server := range servers {
+		if err := server.Shutdown(ctx); err != nil {
+			klog.ErrorS(err, "failed to shut down server", "addr", server.Addr)
+		}
+	}
+}
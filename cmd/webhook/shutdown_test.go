@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/rikotsev/cert-manager-webhook-designate/internal/resolver"
+	"k8s.io/client-go/rest"
+)
+
+func TestShutdown(t *testing.T) {
+	solver := resolver.New()
+	stopCh := make(chan struct{})
+	if err := solver.Initialize(&rest.Config{Host: "https://localhost:6443"}, stopCh); err != nil {
+		t.Fatalf("expected no error initializing, got %v", err)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("expected no error listening, got %v", err)
+	}
+	server := newHealthServer("0", solver)
+	served := make(chan error, 1)
+	go func() { served <- server.Serve(listener) }()
+
+	signaled, cancel := context.WithCancel(context.Background())
+	cancel()
+	close(stopCh)
+
+	done := make(chan struct{})
+	go func() {
+		shutdown(signaled, solver, []*http.Server{server}, 5*time.Second)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the shutdown to complete")
+	}
+	select {
+	case <-solver.(resolver.ShutdownWaiter).Stopped():
+	default:
+		t.Error("expected the solver to be stopped after the shutdown")
+	}
+	if err := <-served; !errors.Is(err, http.ErrServerClosed) {
+		t.Errorf("expected error %v from the closed server, got %v", http.ErrServerClosed, err)
+	}
+}
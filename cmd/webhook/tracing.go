@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"os"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"k8s.io/klog/v2"
+)
+
+// serviceName names the webhook in traces unless OTEL_SERVICE_NAME says otherwise.
+const serviceName = "cert-manager-webhook-designate"
+
+// tracingShutdownTimeout bounds flushing the spans not exported yet on exit.
+const tracingShutdownTimeout = 5 * time.Second
+
+// tracingConfigured reports whether an OTLP endpoint is configured through the standard OTEL_*
+// environment variables and tracing is not disabled.
+func tracingConfigured() bool {
+	if strings.EqualFold(os.Getenv("OTEL_SDK_DISABLED"), "true") || strings.EqualFold(os.Getenv("OTEL_TRACES_EXPORTER"), "none") {
+		return false
+	}
+
+	return os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" || os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") != ""
+}
+
+// newTracerProvider returns a tracer provider exporting spans over OTLP/gRPC as configured by the
+// standard OTEL_* environment variables, or nil if tracing is not configured.
+func newTracerProvider(ctx context.Context) (*sdktrace.TracerProvider, error) {
+	if !tracingConfigured() {
+		return nil, nil
+	}
+
+	exporter, err := otlptracegrpc.New(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithAttributes(attribute.String("service.name", serviceName), attribute.String("service.version", version)),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter), sdktrace.WithResource(res)), nil
+}
+
+// shutdownTracing exports the spans not exported yet. A nil provider is ignored.
+func shutdownTracing(provider *sdktrace.TracerProvider) {
+	if provider == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), tracingShutdownTimeout)
+	defer cancel()
+
+	if err := provider.Shutdown(ctx); err != nil {
+		klog.ErrorS(err, "failed to shut down tracing")
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"testing"
+)
+
+func TestNewTracerProvider(t *testing.T) {
+	tcs := []struct {
+		name     string
+		env      map[string]string
+		expected bool
+	}{
+		{
+			name:     "not configured",
+			expected: false,
+		},
+		{
+			name:     "endpoint",
+			env:      map[string]string{"OTEL_EXPORTER_OTLP_ENDPOINT": "http://collector:4317"},
+			expected: true,
+		},
+		{
+			name:     "traces endpoint",
+			env:      map[string]string{"OTEL_EXPORTER_OTLP_TRACES_ENDPOINT": "http://collector:4317"},
+			expected: true,
+		},
+		{
+			name:     "disabled",
+			env:      map[string]string{"OTEL_EXPORTER_OTLP_ENDPOINT": "http://collector:4317", "OTEL_SDK_DISABLED": "true"},
+			expected: false,
+		},
+		{
+			name:     "no traces exporter",
+			env:      map[string]string{"OTEL_EXPORTER_OTLP_ENDPOINT": "http://collector:4317", "OTEL_TRACES_EXPORTER": "none"},
+			expected: false,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			for _, name := range []string{"OTEL_EXPORTER_OTLP_ENDPOINT", "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "OTEL_SDK_DISABLED", "OTEL_TRACES_EXPORTER"} {
+				t.Setenv(name, tc.env[name])
+			}
+
+			provider, err := newTracerProvider(context.Background())
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			defer shutdownTracing(provider)
+
+			if (provider != nil) != tc.expected {
+				t.Errorf("expected a tracer provider: %v, got %v", tc.expected, provider)
+			}
+		})
+	}
+}
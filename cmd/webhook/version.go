@@ -0,0 +1,41 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// versionFlag is the argument printing the build information instead of starting the webhook server.
+const versionFlag = "--version"
+
+// The build information, injected at build time with
+// -ldflags "-X main.version=... -X main.commit=... -X main.buildDate=...".
+var (
+	version   = "dev"
+	commit    = "unknown"
+	buildDate = "unknown"
+)
+
+// buildInfo is the build information served under /version.
+type buildInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildDate string `json:"buildDate"`
+}
+
+func currentBuildInfo() buildInfo {
+	return buildInfo{Version: version, Commit: commit, BuildDate: buildDate}
+}
+
+// printVersion writes the build information as a single line.
+func printVersion(w io.Writer) {
+	fmt.Fprintf(w, "webhook %s (commit %s, built %s)\n", version, commit, buildDate)
+}
+
+// versionHandler serves the build information as JSON.
+func versionHandler(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(currentBuildInfo())
+}
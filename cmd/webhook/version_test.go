@@ -0,0 +1,50 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rikotsev/cert-manager-webhook-designate/internal/resolver"
+)
+
+func TestVersionHandler(t *testing.T) {
+	for name, value := range map[*string]string{&version: "v1.2.3", &commit: "abc123", &buildDate: "2025-01-02T03:04:05Z"} {
+		previous := *name
+		*name = value
+		t.Cleanup(func() { *name = previous })
+	}
+
+	running := httptest.NewServer(newHealthServer("8081", resolver.New()).Handler)
+	t.Cleanup(running.Close)
+
+	resp, err := http.Get(running.URL + "/version")
+	if err != nil {
+		t.Fatalf("expected no error requesting /version, got %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, resp.StatusCode)
+	}
+	if contentType := resp.Header.Get("Content-Type"); contentType != "application/json" {
+		t.Errorf("expected content type application/json, got %s", contentType)
+	}
+
+	var info buildInfo
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		t.Fatalf("expected a JSON body, got %v", err)
+	}
+	expected := buildInfo{Version: "v1.2.3", Commit: "abc123", BuildDate: "2025-01-02T03:04:05Z"}
+	if info != expected {
+		t.Errorf("expected %+v, got %+v", expected, info)
+	}
+
+	var out strings.Builder
+	printVersion(&out)
+	if !strings.Contains(out.String(), "v1.2.3") || !strings.Contains(out.String(), "abc123") || !strings.Contains(out.String(), "2025-01-02T03:04:05Z") {
+		t.Errorf("expected the printed version to hold the build information, got %q", out.String())
+	}
+}
@@ -0,0 +1,78 @@
+// Package metrics holds the Prometheus metrics of the webhook. The challenge metrics are registered
+// with the default registry, the cache metrics with Registry.
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const namespace = "cert_manager_webhook_designate"
+
+// challengeNamespace prefixes the metrics of challenge operations.
+const challengeNamespace = "designate_webhook"
+
+// Cache operations, used as the operation label of the cache metrics. Keep this list short, every
+// operation adds a time series per result.
+const (
+	CacheOperationZoneList    = "zone-list"
+	CacheOperationPropagation = "propagation"
+	CacheOperationClient      = "client"
+)
+
+// Cache results, used as the result label of the cache metrics.
+const (
+	CacheResultHit  = "hit"
+	CacheResultMiss = "miss"
+)
+
+// Challenge operations, used as the operation label of OperationDuration.
+const (
+	OperationPresent = "present"
+	OperationCleanUp = "cleanup"
+)
+
+// Challenge results, used as the result label of the challenge counters.
+const (
+	ResultSuccess = "success"
+	ResultError   = "error"
+)
+
+// Registry holds the cache metrics, kept separate from the default registry.
+var Registry = prometheus.NewRegistry()
+
+// PresentTotal counts Present calls by result.
+var PresentTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: challengeNamespace,
+	Name:      "present_total",
+	Help:      "Number of challenge presents, partitioned by whether they succeeded.",
+}, []string{"result"})
+
+// CleanUpTotal counts CleanUp calls by result.
+var CleanUpTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: challengeNamespace,
+	Name:      "cleanup_total",
+	Help:      "Number of challenge cleanups, partitioned by whether they succeeded.",
+}, []string{"result"})
+
+// OperationDuration observes the duration of Present and CleanUp calls, including the time spent
+// waiting for a challenge slot and for propagation. The buckets reach beyond the default operation
+// timeout.
+var OperationDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: challengeNamespace,
+	Name:      "operation_duration_seconds",
+	Help:      "Duration of challenge presents and cleanups in seconds.",
+	Buckets:   prometheus.ExponentialBuckets(0.05, 2, 12),
+}, []string{"operation"})
+
+// CacheRequests counts cache lookups by operation and result.
+var CacheRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Subsystem: "cache",
+	Name:      "requests_total",
+	Help:      "Number of cache lookups, partitioned by operation and whether they were a hit or a miss.",
+}, []string{"operation", "result"})
+
+func init() {
+	Registry.MustRegister(CacheRequests)
+	prometheus.MustRegister(PresentTotal, CleanUpTotal, OperationDuration)
+}
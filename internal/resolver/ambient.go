@@ -0,0 +1,74 @@
+package resolver
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/gophercloud/gophercloud/v2/openstack"
+)
+
+var ErrInvalidEndpointInterface = errors.New("invalid endpoint interface")
+var ErrAmbientCredentials = errors.New("cannot read ambient credentials from the environment")
+
+// ambientAuthConfig reads credentials from the standard OS_* environment variables, for challenges
+// that reference no secret and allow ambient credentials.
+func ambientAuthConfig() (*AuthConfig, error) {
+	authOpts, err := openstack.AuthOptionsFromEnv()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrAmbientCredentials, err)
+	}
+	// A TOTP passcode is only valid for a moment, so re-authenticating with it would fail anyway.
+	authOpts.AllowReauth = authOpts.Passcode == ""
+
+	endpointOpts, err := ambientEndpointOpts()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrAmbientCredentials, err)
+	}
+
+	version, err := parseIdentityAPIVersion(os.Getenv("OS_IDENTITY_API_VERSION"))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrAmbientCredentials, err)
+	}
+
+	return &AuthConfig{authOpts: authOpts, endpointOpts: endpointOpts, identityAPIVersion: version}, nil
+}
+
+// ambientEndpointOpts reads the endpoint options for credentials that do not come from a secret
+// from the environment, following the OpenStack client conventions: OS_REGION_NAME selects the
+// region and OS_INTERFACE (or the older OS_ENDPOINT_TYPE) one of the public, internal or admin
+// endpoints. The interface defaults to public.
+func ambientEndpointOpts() (gophercloud.EndpointOpts, error) {
+	opts := gophercloud.EndpointOpts{
+		Region: os.Getenv("OS_REGION_NAME"),
+	}
+
+	endpointInterface := os.Getenv("OS_INTERFACE")
+	if endpointInterface == "" {
+		endpointInterface = os.Getenv("OS_ENDPOINT_TYPE")
+	}
+
+	availability, err := parseAvailability(endpointInterface)
+	if err != nil {
+		return gophercloud.EndpointOpts{}, err
+	}
+	opts.Availability = availability
+
+	return opts, nil
+}
+
+// parseAvailability accepts both the interface names and the legacy publicURL style endpoint types.
+func parseAvailability(endpointInterface string) (gophercloud.Availability, error) {
+	switch strings.TrimSuffix(strings.ToLower(endpointInterface), "url") {
+	case "", "public":
+		return gophercloud.AvailabilityPublic, nil
+	case "internal":
+		return gophercloud.AvailabilityInternal, nil
+	case "admin":
+		return gophercloud.AvailabilityAdmin, nil
+	}
+
+	return "", fmt.Errorf("%w: %q, expected one of public, internal or admin", ErrInvalidEndpointInterface, endpointInterface)
+}
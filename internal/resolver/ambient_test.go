@@ -0,0 +1,174 @@
+package resolver
+
+import (
+	"errors"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gophercloud/gophercloud/v2"
+	mockresolver "github.com/rikotsev/cert-manager-webhook-designate/internal/resolver/mock"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestAmbientAuthConfig_EndpointOpts(t *testing.T) {
+	tcs := []struct {
+		name          string
+		env           map[string]string
+		expected      gophercloud.EndpointOpts
+		expectedError error
+	}{
+		{
+			name:     "defaults",
+			expected: gophercloud.EndpointOpts{Availability: gophercloud.AvailabilityPublic},
+		},
+		{
+			name: "region and interface",
+			env: map[string]string{
+				"OS_REGION_NAME": "RegionOne",
+				"OS_INTERFACE":   "internal",
+			},
+			expected: gophercloud.EndpointOpts{Region: "RegionOne", Availability: gophercloud.AvailabilityInternal},
+		},
+		{
+			name: "legacy endpoint type",
+			env: map[string]string{
+				"OS_ENDPOINT_TYPE": "adminURL",
+			},
+			expected: gophercloud.EndpointOpts{Availability: gophercloud.AvailabilityAdmin},
+		},
+		{
+			name: "interface takes precedence over endpoint type",
+			env: map[string]string{
+				"OS_INTERFACE":     "Public",
+				"OS_ENDPOINT_TYPE": "internalURL",
+			},
+			expected: gophercloud.EndpointOpts{Availability: gophercloud.AvailabilityPublic},
+		},
+		{
+			name: "invalid interface",
+			env: map[string]string{
+				"OS_INTERFACE": "private",
+			},
+			expectedError: ErrInvalidEndpointInterface,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("OS_AUTH_URL", "https://example.com")
+			t.Setenv("OS_USERNAME", "john-doe")
+			t.Setenv("OS_PASSWORD", "secretpass")
+			for _, name := range []string{"OS_REGION_NAME", "OS_INTERFACE", "OS_ENDPOINT_TYPE"} {
+				t.Setenv(name, tc.env[name])
+			}
+
+			cfg, err := ambientAuthConfig()
+			if !errors.Is(err, tc.expectedError) {
+				t.Fatalf("expected error %v, got %v", tc.expectedError, err)
+			}
+			if tc.expectedError != nil {
+				if !errors.Is(err, ErrAmbientCredentials) {
+					t.Errorf("expected error %v, got %v", ErrAmbientCredentials, err)
+				}
+				return
+			}
+
+			if !reflect.DeepEqual(cfg.endpointOpts, tc.expected) {
+				t.Errorf("expected %+v, got %+v", tc.expected, cfg.endpointOpts)
+			}
+		})
+	}
+}
+
+func TestDesignateDnsResolver_Present_AmbientCredentials(t *testing.T) {
+	tcs := []struct {
+		name              string
+		allowAmbient      bool
+		setEnv            bool
+		endpointInterface string
+		expectedError     []error
+	}{
+		{
+			name:         "ambient allowed with the environment set",
+			allowAmbient: true,
+			setEnv:       true,
+		},
+		{
+			name:              "ambient allowed with an invalid interface",
+			allowAmbient:      true,
+			setEnv:            true,
+			endpointInterface: "private",
+			expectedError:     []error{ErrFailedDesignateClientInitialization, ErrAmbientCredentials, ErrInvalidEndpointInterface},
+		},
+		{
+			name:          "ambient allowed without the environment",
+			allowAmbient:  true,
+			expectedError: []error{ErrFailedDesignateClientInitialization, ErrAmbientCredentials},
+		},
+		{
+			name:          "ambient not allowed still requires the secret",
+			setEnv:        true,
+			expectedError: []error{ErrFailedDesignateClientInitialization, ErrMissingRequiredField},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			mockApi := mockresolver.CreateMockOpenstackApi(t)
+			mockApi.Zones = []mockresolver.MockZone{
+				{
+					ID:   "12345",
+					Name: "example.com.",
+				},
+			}
+			server := httptest.NewServer(mockApi)
+			t.Cleanup(server.Close)
+
+			env := map[string]string{
+				"OS_AUTH_URL":    server.URL,
+				"OS_USERNAME":    "john-doe",
+				"OS_PASSWORD":    "secretpass",
+				"OS_TENANT_ID":   "testTenantId",
+				"OS_DOMAIN_ID":   "testDomainId",
+				"OS_REGION_NAME": "RegionOne",
+			}
+			for name, value := range env {
+				if !tc.setEnv {
+					value = ""
+				}
+				t.Setenv(name, value)
+			}
+			t.Setenv("OS_INTERFACE", tc.endpointInterface)
+
+			resolver := new(designateDnsResolver)
+			resolver.configProvider = &authConfigProvider{client: fake.NewClientset()}
+			WithDecisionRecording(true)(resolver)
+
+			ch := newChallengeRequest("challenge", "cool.example.com", "example.com", `{
+				"strategy": {
+					"kind": "SOA"
+				}
+			}`)
+			ch.AllowAmbientCredentials = tc.allowAmbient
+
+			err := resolver.Present(ch)
+			for _, expected := range tc.expectedError {
+				if !errors.Is(err, expected) {
+					t.Errorf("expected error %v, got %v", expected, err)
+				}
+			}
+			if tc.expectedError == nil {
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				if len(mockApi.Updates) != 1 {
+					t.Errorf("expected the recordset to be created with ambient credentials, got %d creates", len(mockApi.Updates))
+				}
+				if decision, _ := resolver.LastDecision(); decision.CredentialSource != CredentialSourceAmbient {
+					t.Errorf("expected credential source %s, got %q", CredentialSourceAmbient, decision.CredentialSource)
+				}
+			}
+		})
+	}
+}
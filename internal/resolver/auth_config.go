@@ -4,88 +4,298 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gophercloud/gophercloud/v2"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
 )
 
 type authConfigProvider struct {
-	client kubernetes.Interface
+	client      kubernetes.Interface
+	unknownKeys UnknownSecretKeys
+	// lookupEnv, if set, reads the OS_* environment variables standing in for keys missing from the
+	// secret, see WithSecretEnvFallback and fromSecret.
+	lookupEnv func(key string) (string, bool)
 }
 
+// UnknownSecretKeys controls what happens when a credentials secret contains keys the webhook does not read.
+type UnknownSecretKeys string
+
+const (
+	// UnknownSecretKeysIgnore silently ignores unknown keys.
+	UnknownSecretKeysIgnore UnknownSecretKeys = ""
+	// UnknownSecretKeysWarn logs unknown keys, suggesting the closest known key.
+	UnknownSecretKeysWarn UnknownSecretKeys = "warn"
+	// UnknownSecretKeysError rejects secrets with unknown keys with ErrUnknownAuthValue.
+	UnknownSecretKeysError UnknownSecretKeys = "error"
+)
+
 type AuthConfig struct {
 	authOpts     gophercloud.AuthOptions
 	endpointOpts gophercloud.EndpointOpts
+	// caCert holds PEM encoded certificates trusted in addition to the system pool.
+	caCert []byte
+	// designateEndpoints maps regions to designate URLs overriding the service catalog, see designateEndpoint.
+	designateEndpoints string
+	// insecureAllowHTTP allows a plain http identity endpoint, see canonicalIdentityEndpoint.
+	insecureAllowHTTP bool
+	// httpTimeout bounds every HTTP request of the clients, see ChallengeConfig.HTTPTimeout.
+	httpTimeout time.Duration
+	// identityAPIVersion forces the keystone API version instead of discovering it.
+	identityAPIVersion identityAPIVersion
+	// scope selects a project or domain scoped token for password credentials, see applyAuthScope.
+	scope string
 }
 
 var ErrMissingAuthValue = errors.New("missing auth value")
 var ErrEitherDomainIdOrNameRequired = errors.New("one of either domain id or domain name is required")
+var ErrEitherTenantIdOrNameRequired = errors.New("one of either tenant id or tenant name is required")
+var ErrUnknownAuthValue = errors.New("unknown auth value")
+var ErrConflictingAuthValues = errors.New("either a password or an application credential may be given, not both")
+var ErrPasscodeReauth = errors.New("a passcode expires too soon to re-authenticate with, allowReauth must not be true")
+
+// authMethod groups auth values by the way of authenticating they belong to.
+type authMethod int
+
+const (
+	// authMethodAny values are needed regardless of the way of authenticating.
+	authMethodAny authMethod = iota
+	authMethodPassword
+	authMethodApplicationCredential
+)
+
+// authValues lists the keys read from the credentials secret, together with the OS_* environment
+// variables falling in for them, if any. Required values of the password method are only required
+// when the secret holds no application credential. Only keys marked cloudsYAML may be given next to
+// a clouds.yaml file, the others describe the cloud and credentials the file already holds.
 var authValues = []struct {
-	keyName  string
-	required bool
-	setter   func(*AuthConfig, string)
+	keyName    string
+	envNames   []string
+	required   bool
+	method     authMethod
+	cloudsYAML bool
+	setter     func(*AuthConfig, string)
 }{
 	{
 		keyName:  "tenantName",
-		required: true,
+		envNames: []string{"OS_PROJECT_NAME", "OS_TENANT_NAME"},
+		required: false,
+		method:   authMethodPassword,
 		setter:   func(cfg *AuthConfig, value string) { cfg.authOpts.TenantName = value },
 	},
 	{
 		keyName:  "tenantId",
-		required: true,
+		envNames: []string{"OS_PROJECT_ID", "OS_TENANT_ID"},
+		required: false,
+		method:   authMethodPassword,
 		setter:   func(cfg *AuthConfig, value string) { cfg.authOpts.TenantID = value },
 	},
 	{
 		keyName:  "domainName",
+		envNames: []string{"OS_DOMAIN_NAME"},
 		required: false,
 		setter:   func(cfg *AuthConfig, value string) { cfg.authOpts.DomainName = value },
 	},
 	{
 		keyName:  "domainId",
+		envNames: []string{"OS_DOMAIN_ID"},
 		required: false,
 		setter:   func(cfg *AuthConfig, value string) { cfg.authOpts.DomainID = value },
 	},
 	{
 		keyName:  "username",
+		envNames: []string{"OS_USERNAME"},
 		required: true,
+		method:   authMethodPassword,
 		setter:   func(cfg *AuthConfig, value string) { cfg.authOpts.Username = value },
 	},
 	{
 		keyName:  "password",
+		envNames: []string{"OS_PASSWORD"},
 		required: true,
+		method:   authMethodPassword,
 		setter:   func(cfg *AuthConfig, value string) { cfg.authOpts.Password = value },
 	},
+	{
+		keyName:  "passcode",
+		envNames: []string{"OS_PASSCODE"},
+		required: false,
+		method:   authMethodPassword,
+		setter:   func(cfg *AuthConfig, value string) { cfg.authOpts.Passcode = value },
+	},
+	{
+		keyName:  "applicationCredentialId",
+		envNames: []string{"OS_APPLICATION_CREDENTIAL_ID"},
+		required: false,
+		method:   authMethodApplicationCredential,
+		setter:   func(cfg *AuthConfig, value string) { cfg.authOpts.ApplicationCredentialID = value },
+	},
+	{
+		keyName:  "applicationCredentialName",
+		envNames: []string{"OS_APPLICATION_CREDENTIAL_NAME"},
+		required: false,
+		method:   authMethodApplicationCredential,
+		setter:   func(cfg *AuthConfig, value string) { cfg.authOpts.ApplicationCredentialName = value },
+	},
+	{
+		keyName:  "applicationCredentialSecret",
+		envNames: []string{"OS_APPLICATION_CREDENTIAL_SECRET"},
+		required: false,
+		method:   authMethodApplicationCredential,
+		setter:   func(cfg *AuthConfig, value string) { cfg.authOpts.ApplicationCredentialSecret = value },
+	},
 	{
 		keyName:  "identityEndpoint",
+		envNames: []string{"OS_AUTH_URL"},
 		required: true,
 		setter:   func(cfg *AuthConfig, value string) { cfg.authOpts.IdentityEndpoint = value },
 	},
 	{
-		keyName:  "region",
-		required: true,
-		setter:   func(cfg *AuthConfig, value string) { cfg.endpointOpts.Region = value },
+		keyName:    "region",
+		envNames:   []string{"OS_REGION_NAME"},
+		required:   false,
+		cloudsYAML: true,
+		setter:     func(cfg *AuthConfig, value string) { cfg.endpointOpts.Region = value },
+	},
+	{
+		keyName:    "designateEndpoints",
+		required:   false,
+		cloudsYAML: true,
+		setter:     func(cfg *AuthConfig, value string) { cfg.designateEndpoints = value },
+	},
+	{
+		keyName:    "insecureAllowHTTP",
+		required:   false,
+		cloudsYAML: true,
+		setter:     func(cfg *AuthConfig, value string) { cfg.insecureAllowHTTP, _ = strconv.ParseBool(value) },
+	},
+	{
+		keyName:    "allowReauth",
+		required:   false,
+		cloudsYAML: true,
+		setter:     func(cfg *AuthConfig, value string) { cfg.authOpts.AllowReauth = parseAllowReauth(value) },
+	},
+	{
+		keyName:    "caCert",
+		required:   false,
+		cloudsYAML: true,
+		setter:     func(cfg *AuthConfig, value string) { cfg.caCert = []byte(value) },
+	},
+	{
+		keyName:    "endpointType",
+		envNames:   []string{"OS_INTERFACE", "OS_ENDPOINT_TYPE"},
+		required:   false,
+		cloudsYAML: true,
+		setter:     func(cfg *AuthConfig, value string) { cfg.endpointOpts.Availability = gophercloud.Availability(value) },
+	},
+	{
+		keyName:    "identityApiVersion",
+		envNames:   []string{"OS_IDENTITY_API_VERSION"},
+		required:   false,
+		cloudsYAML: true,
+		setter:     func(cfg *AuthConfig, value string) { cfg.identityAPIVersion = identityAPIVersion(value) },
+	},
+	{
+		keyName:    "scope",
+		required:   false,
+		cloudsYAML: true,
+		setter:     func(cfg *AuthConfig, value string) { cfg.scope = strings.ToLower(strings.TrimSpace(value)) },
 	},
 }
 
-func (a *authConfigProvider) Get(ctx context.Context, namespace, secretName string) (*AuthConfig, error) {
+// Get builds the auth config from a credentials secret. allowEnv tells whether the issuer allows
+// ambient credentials, without which keys missing from the secret are never read from the environment.
+func (a *authConfigProvider) Get(ctx context.Context, namespace, secretName string, allowEnv bool) (*AuthConfig, error) {
 	secret, err := a.client.CoreV1().Secrets(namespace).Get(ctx, secretName, metav1.GetOptions{})
 	if err != nil {
 		return nil, err
 	}
 
+	return a.fromSecret(namespace, secretName, secret.Data, allowEnv)
+}
+
+// fromSecret builds the auth config from the data of a credentials secret. All missing keys are
+// reported at once.
+func (a *authConfigProvider) fromSecret(namespace, secretName string, data map[string][]byte, allowEnv bool) (*AuthConfig, error) {
+	if err := a.checkUnknownKeys(namespace, secretName, data); err != nil {
+		return nil, err
+	}
+
+	if content, ok := data[cloudsYAMLKey]; ok {
+		return fromCloudsYAML(content, data)
+	}
+
 	cfg := new(AuthConfig)
 	cfg.authOpts = gophercloud.AuthOptions{}
+	lookupEnv := a.envLookup(data, allowEnv)
 
+	usesApplicationCredential, decided := false, false
 	for _, val := range authValues {
-		binaryContent, ok := secret.Data[val.keyName]
-		if !ok && val.required {
-			return nil, fmt.Errorf("%w: %s", ErrMissingAuthValue, val.keyName)
+		if _, ok := data[val.keyName]; ok && val.method != authMethodAny {
+			usesApplicationCredential = usesApplicationCredential || val.method == authMethodApplicationCredential
+			decided = true
+		}
+	}
+	// The environment only picks the way of authenticating when the secret does not.
+	if !decided {
+		for _, val := range authValues {
+			if _, ok := envValue(lookupEnv, val.envNames); ok && val.method == authMethodApplicationCredential {
+				usesApplicationCredential = true
+			}
 		}
-		val.setter(cfg, string(binaryContent))
 	}
 
-	if cfg.authOpts.DomainID == "" && cfg.authOpts.DomainName == "" {
+	var missing []error
+	for _, val := range authValues {
+		value, ok := data[val.keyName]
+		content := string(value)
+		// Values of the other way of authenticating are not taken from the environment, so that
+		// ambient credentials do not mix with the secret's.
+		otherMethod := (val.method == authMethodPassword && usesApplicationCredential) || (val.method == authMethodApplicationCredential && !usesApplicationCredential)
+		if !ok && !otherMethod {
+			content, ok = envValue(lookupEnv, val.envNames)
+		}
+		required := val.required && (val.method != authMethodPassword || !usesApplicationCredential)
+		if !ok && required {
+			missing = append(missing, fmt.Errorf("%w: %s", ErrMissingAuthValue, val.keyName))
+		}
+		val.setter(cfg, content)
+	}
+	if len(missing) > 0 {
+		return nil, errors.Join(missing...)
+	}
+
+	var err error
+	cfg.endpointOpts.Availability, err = parseAvailability(string(cfg.endpointOpts.Availability))
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.identityAPIVersion, err = parseIdentityAPIVersion(string(cfg.identityAPIVersion))
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.authOpts.IdentityEndpoint, err = canonicalIdentityEndpoint(cfg.authOpts.IdentityEndpoint, cfg.insecureAllowHTTP)
+	if err != nil {
+		return nil, err
+	}
+
+	if usesApplicationCredential {
+		if err := validateApplicationCredential(cfg.authOpts); err != nil {
+			return nil, err
+		}
+	} else if err := applyAuthScope(&cfg.authOpts, cfg.scope); err != nil {
+		return nil, err
+	} else if cfg.authOpts.TenantID == "" && cfg.authOpts.TenantName == "" && cfg.scope != authScopeDomain {
+		return nil, ErrEitherTenantIdOrNameRequired
+	} else if cfg.authOpts.DomainID == "" && cfg.authOpts.DomainName == "" {
 		return nil, ErrEitherDomainIdOrNameRequired
 	}
 
@@ -94,7 +304,159 @@ func (a *authConfigProvider) Get(ctx context.Context, namespace, secretName stri
 		cfg.authOpts.DomainName = ""
 	}
 
-	cfg.authOpts.AllowReauth = true
+	// A TOTP passcode is only valid for a moment, so re-authenticating with it would fail anyway.
+	if cfg.authOpts.Passcode != "" {
+		if value, ok := data["allowReauth"]; ok && parseAllowReauth(string(value)) {
+			return nil, ErrPasscodeReauth
+		}
+		cfg.authOpts.AllowReauth = false
+	}
+
+	return cfg, nil
+}
+
+// fromCloudsYAML builds the auth config from a clouds.yaml file and the keys next to it that do not
+// describe the cloud. Keys describing the cloud are rejected rather than silently losing to the file.
+func fromCloudsYAML(content []byte, data map[string][]byte) (*AuthConfig, error) {
+	var conflicting []error
+	for _, val := range authValues {
+		if _, ok := data[val.keyName]; ok && !val.cloudsYAML {
+			conflicting = append(conflicting, fmt.Errorf("%w: %s", ErrConflictingCloudsYAML, val.keyName))
+		}
+	}
+	if len(conflicting) > 0 {
+		return nil, errors.Join(conflicting...)
+	}
+
+	cfg, err := authConfigFromCloudsYAML(content, string(data[cloudNameKey]), string(data["region"]))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, val := range authValues {
+		if value, ok := data[val.keyName]; ok {
+			val.setter(cfg, string(value))
+		}
+	}
+
+	cfg.endpointOpts.Availability, err = parseAvailability(string(cfg.endpointOpts.Availability))
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.identityAPIVersion, err = parseIdentityAPIVersion(string(cfg.identityAPIVersion))
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.authOpts.IdentityEndpoint, err = canonicalIdentityEndpoint(cfg.authOpts.IdentityEndpoint, cfg.insecureAllowHTTP)
+	if err != nil {
+		return nil, err
+	}
+
+	// The project scope is already set from the file, with the project's own domain.
+	if cfg.authOpts.ApplicationCredentialSecret == "" && (cfg.authOpts.Scope == nil || cfg.scope == authScopeDomain) {
+		if err := applyAuthScope(&cfg.authOpts, cfg.scope); err != nil {
+			return nil, err
+		}
+	}
 
 	return cfg, nil
 }
+
+// parseAllowReauth reads the allowReauth key. Re-authenticating once the token expires stays on
+// unless the key explicitly turns it off.
+func parseAllowReauth(value string) bool {
+	allowed, err := strconv.ParseBool(value)
+	return err != nil || allowed
+}
+
+// envLookup returns the lookup of the environment variables standing in for keys missing from the
+// secret, or nil if the environment must not be read. A secret choosing its own identity endpoint or
+// region never gets the webhook's credentials, as they would be sent to a cloud of the secret's choice.
+func (a *authConfigProvider) envLookup(data map[string][]byte, allowEnv bool) func(string) (string, bool) {
+	if !allowEnv {
+		return nil
+	}
+
+	for _, key := range []string{"identityEndpoint", "region"} {
+		if _, ok := data[key]; ok {
+			return nil
+		}
+	}
+
+	return a.lookupEnv
+}
+
+// envValue returns the first of the environment variables that is set, if there is a lookup at all.
+func envValue(lookupEnv func(string) (string, bool), names []string) (string, bool) {
+	if lookupEnv == nil {
+		return "", false
+	}
+
+	for _, name := range names {
+		if value, ok := lookupEnv(name); ok {
+			return value, true
+		}
+	}
+
+	return "", false
+}
+
+// validateApplicationCredential makes sure the application credential is complete. It is identified
+// either by its id, or by its name together with the user owning it.
+func validateApplicationCredential(opts gophercloud.AuthOptions) error {
+	if opts.Password != "" {
+		return ErrConflictingAuthValues
+	}
+
+	if opts.ApplicationCredentialSecret == "" {
+		return fmt.Errorf("%w: %s", ErrMissingAuthValue, "applicationCredentialSecret")
+	}
+
+	if opts.ApplicationCredentialID != "" {
+		return nil
+	}
+
+	if opts.ApplicationCredentialName == "" {
+		return fmt.Errorf("%w: %s", ErrMissingAuthValue, "applicationCredentialId")
+	}
+
+	if opts.Username == "" {
+		return fmt.Errorf("%w: %s, required with applicationCredentialName", ErrMissingAuthValue, "username")
+	}
+
+	if opts.DomainID == "" && opts.DomainName == "" {
+		return ErrEitherDomainIdOrNameRequired
+	}
+
+	return nil
+}
+
+// checkUnknownKeys reports secret keys that are not in authValues, which usually are typos of a known key.
+func (a *authConfigProvider) checkUnknownKeys(namespace, secretName string, data map[string][]byte) error {
+	if a.unknownKeys == UnknownSecretKeysIgnore {
+		return nil
+	}
+
+	knownKeys := []string{cloudsYAMLKey, cloudNameKey}
+	for _, val := range authValues {
+		knownKeys = append(knownKeys, val.keyName)
+	}
+
+	var errs []error
+	for _, key := range slices.Sorted(maps.Keys(data)) {
+		if slices.Contains(knownKeys, key) {
+			continue
+		}
+		errs = append(errs, fmt.Errorf("%w: %s, did you mean %s?", ErrUnknownAuthValue, key, closestString(key, knownKeys)))
+	}
+
+	err := errors.Join(errs...)
+	if err != nil && a.unknownKeys == UnknownSecretKeysWarn {
+		klog.Warningf("credentials secret %s/%s contains unknown keys: %v", namespace, secretName, err)
+		return nil
+	}
+
+	return err
+}
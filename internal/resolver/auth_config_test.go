@@ -3,6 +3,10 @@ package resolver
 import (
 	"context"
 	"errors"
+	"maps"
+	"os"
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/gophercloud/gophercloud/v2"
@@ -100,18 +104,67 @@ func TestAuthConfigProvider_Get(t *testing.T) {
 			expectedError:    nil,
 		},
 		{
-			name:             "missing tenant name",
-			secret:           dummySecret(secretName, namespace, stripKey(allKeys, "tenantName")),
-			expectedAuthOpts: nil,
+			name:   "happy path - with only tenantId and domainId",
+			secret: dummySecret(secretName, namespace, stripKey(stripKey(allKeys, "tenantName"), "domainName")),
+			expectedAuthOpts: &gophercloud.AuthOptions{
+				TenantID:         "testTenantId",
+				DomainID:         "testDomainId",
+				Username:         "john-doe",
+				Password:         "secretpass",
+				IdentityEndpoint: "https://example.com",
+				AllowReauth:      true,
+			},
 			expectedNotFound: false,
-			expectedError:    ErrMissingAuthValue,
+			expectedError:    nil,
+		},
+		{
+			name:   "happy path - with only tenantId and domainName",
+			secret: dummySecret(secretName, namespace, stripKey(stripKey(allKeys, "tenantName"), "domainId")),
+			expectedAuthOpts: &gophercloud.AuthOptions{
+				TenantID:         "testTenantId",
+				DomainName:       "testDomainName",
+				Username:         "john-doe",
+				Password:         "secretpass",
+				IdentityEndpoint: "https://example.com",
+				AllowReauth:      true,
+			},
+			expectedNotFound: false,
+			expectedError:    nil,
 		},
 		{
-			name:             "missing tenant id",
-			secret:           dummySecret(secretName, namespace, stripKey(allKeys, "tenantId")),
+			name:   "happy path - with only tenantName and domainId",
+			secret: dummySecret(secretName, namespace, stripKey(stripKey(allKeys, "tenantId"), "domainName")),
+			expectedAuthOpts: &gophercloud.AuthOptions{
+				TenantName:       "testTenant",
+				DomainID:         "testDomainId",
+				Username:         "john-doe",
+				Password:         "secretpass",
+				IdentityEndpoint: "https://example.com",
+				AllowReauth:      true,
+			},
+			expectedNotFound: false,
+			expectedError:    nil,
+		},
+		{
+			name:   "happy path - with only tenantName and domainName",
+			secret: dummySecret(secretName, namespace, stripKey(stripKey(allKeys, "tenantId"), "domainId")),
+			expectedAuthOpts: &gophercloud.AuthOptions{
+				TenantName:       "testTenant",
+				DomainName:       "testDomainName",
+				Username:         "john-doe",
+				Password:         "secretpass",
+				IdentityEndpoint: "https://example.com",
+				AllowReauth:      true,
+			},
+			expectedNotFound: false,
+			expectedError:    nil,
+		},
+		{
+			name:             "missing tenant name or tenant id",
+			secret:           dummySecret(secretName, namespace, stripKey(stripKey(allKeys, "tenantName"), "tenantId")),
 			expectedAuthOpts: nil,
 			expectedNotFound: false,
-			expectedError:    ErrMissingAuthValue,
+			expectedError:    ErrEitherTenantIdOrNameRequired,
 		},
 		{
 			name:             "missing domain name or domain id",
@@ -157,7 +210,7 @@ func TestAuthConfigProvider_Get(t *testing.T) {
 				client: client,
 			}
 
-			cfg, err := confProvider.Get(context.Background(), namespace, secretName)
+			cfg, err := confProvider.Get(context.Background(), namespace, secretName, false)
 
 			if tc.expectedNotFound {
 				if err == nil {
@@ -227,6 +280,1122 @@ func TestAuthConfigProvider_Get(t *testing.T) {
 	}
 }
 
+func TestAuthConfigProvider_Get_UnknownKeys(t *testing.T) {
+	allKeys := map[string]string{
+		"tenantName":       "testTenant",
+		"tenantId":         "testTenantId",
+		"domainId":         "testDomainId",
+		"username":         "john-doe",
+		"password":         "secretpass",
+		"identityEndpoint": "https://example.com",
+		"region":           "RegionOne",
+	}
+	withTypo := maps.Clone(allKeys)
+	withTypo["usrname"] = "jane-doe"
+
+	tcs := []struct {
+		name            string
+		mode            UnknownSecretKeys
+		data            map[string]string
+		expectedError   error
+		expectedMessage string
+		expectWarning   bool
+	}{
+		{
+			name:          "ignored by default",
+			mode:          UnknownSecretKeysIgnore,
+			data:          withTypo,
+			expectedError: nil,
+		},
+		{
+			name:          "only reported in warn mode",
+			mode:          UnknownSecretKeysWarn,
+			data:          withTypo,
+			expectedError: nil,
+			expectWarning: true,
+		},
+		{
+			name:            "rejected in error mode",
+			mode:            UnknownSecretKeysError,
+			data:            withTypo,
+			expectedError:   ErrUnknownAuthValue,
+			expectedMessage: "usrname, did you mean username?",
+		},
+		{
+			name:          "known keys only in error mode",
+			mode:          UnknownSecretKeysError,
+			data:          allKeys,
+			expectedError: nil,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			confProvider := authConfigProvider{
+				client:      fake.NewClientset(dummySecret("creds", "bar", tc.data)),
+				unknownKeys: tc.mode,
+			}
+			warnings := captureWarnings(t)
+
+			cfg, err := confProvider.Get(context.Background(), "bar", "creds", false)
+			if !errors.Is(err, tc.expectedError) {
+				t.Fatalf("expected err: %v, got %v", tc.expectedError, err)
+			}
+
+			warned := strings.Contains(strings.Join(warnings(), "\n"), "usrname, did you mean username?")
+			if warned != tc.expectWarning {
+				t.Errorf("expected a warning about the unknown key: %t, got %q", tc.expectWarning, warnings())
+			}
+
+			if tc.expectedError != nil {
+				if !strings.Contains(err.Error(), tc.expectedMessage) {
+					t.Errorf("expected err to contain %q, got %v", tc.expectedMessage, err)
+				}
+				return
+			}
+
+			if cfg.authOpts.Username != "john-doe" {
+				t.Errorf("got Username: %s, want john-doe", cfg.authOpts.Username)
+			}
+		})
+	}
+}
+
+func TestAuthConfigProvider_Get_ApplicationCredentials(t *testing.T) {
+	tcs := []struct {
+		name             string
+		data             map[string]string
+		expectedAuthOpts *gophercloud.AuthOptions
+		expectedError    error
+	}{
+		{
+			name: "by id",
+			data: map[string]string{
+				"applicationCredentialId":     "app-cred-id",
+				"applicationCredentialSecret": "app-cred-secret",
+				"identityEndpoint":            "https://example.com",
+				"region":                      "RegionOne",
+			},
+			expectedAuthOpts: &gophercloud.AuthOptions{
+				ApplicationCredentialID:     "app-cred-id",
+				ApplicationCredentialSecret: "app-cred-secret",
+				IdentityEndpoint:            "https://example.com",
+				AllowReauth:                 true,
+			},
+		},
+		{
+			name: "by name",
+			data: map[string]string{
+				"applicationCredentialName":   "app-cred",
+				"applicationCredentialSecret": "app-cred-secret",
+				"username":                    "john-doe",
+				"domainId":                    "testDomainId",
+				"identityEndpoint":            "https://example.com",
+				"region":                      "RegionOne",
+			},
+			expectedAuthOpts: &gophercloud.AuthOptions{
+				ApplicationCredentialName:   "app-cred",
+				ApplicationCredentialSecret: "app-cred-secret",
+				Username:                    "john-doe",
+				DomainID:                    "testDomainId",
+				IdentityEndpoint:            "https://example.com",
+				AllowReauth:                 true,
+			},
+		},
+		{
+			name: "mixed with a password",
+			data: map[string]string{
+				"applicationCredentialId":     "app-cred-id",
+				"applicationCredentialSecret": "app-cred-secret",
+				"username":                    "john-doe",
+				"password":                    "secretpass",
+				"identityEndpoint":            "https://example.com",
+				"region":                      "RegionOne",
+			},
+			expectedError: ErrConflictingAuthValues,
+		},
+		{
+			name: "missing secret",
+			data: map[string]string{
+				"applicationCredentialId": "app-cred-id",
+				"identityEndpoint":        "https://example.com",
+				"region":                  "RegionOne",
+			},
+			expectedError: ErrMissingAuthValue,
+		},
+		{
+			name: "only a secret",
+			data: map[string]string{
+				"applicationCredentialSecret": "app-cred-secret",
+				"identityEndpoint":            "https://example.com",
+				"region":                      "RegionOne",
+			},
+			expectedError: ErrMissingAuthValue,
+		},
+		{
+			name: "by name without username",
+			data: map[string]string{
+				"applicationCredentialName":   "app-cred",
+				"applicationCredentialSecret": "app-cred-secret",
+				"domainId":                    "testDomainId",
+				"identityEndpoint":            "https://example.com",
+				"region":                      "RegionOne",
+			},
+			expectedError: ErrMissingAuthValue,
+		},
+		{
+			name: "by name without domain",
+			data: map[string]string{
+				"applicationCredentialName":   "app-cred",
+				"applicationCredentialSecret": "app-cred-secret",
+				"username":                    "john-doe",
+				"identityEndpoint":            "https://example.com",
+				"region":                      "RegionOne",
+			},
+			expectedError: ErrEitherDomainIdOrNameRequired,
+		},
+		{
+			name: "still requires the identity endpoint",
+			data: map[string]string{
+				"applicationCredentialId":     "app-cred-id",
+				"applicationCredentialSecret": "app-cred-secret",
+				"region":                      "RegionOne",
+			},
+			expectedError: ErrMissingAuthValue,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			confProvider := authConfigProvider{
+				client: fake.NewClientset(dummySecret("creds", "bar", tc.data)),
+			}
+
+			cfg, err := confProvider.Get(context.Background(), "bar", "creds", false)
+			if !errors.Is(err, tc.expectedError) {
+				t.Fatalf("expected err: %v, got %v", tc.expectedError, err)
+			}
+			if tc.expectedError != nil {
+				return
+			}
+
+			if cfg.authOpts != *tc.expectedAuthOpts {
+				t.Errorf("got auth options %+v, want %+v", cfg.authOpts, *tc.expectedAuthOpts)
+			}
+		})
+	}
+}
+
+func TestAuthConfigProvider_Get_EnvironmentFallback(t *testing.T) {
+	env := map[string]string{
+		"OS_PROJECT_NAME": "envTenant",
+		"OS_DOMAIN_ID":    "envDomainId",
+		"OS_USERNAME":     "env-user",
+		"OS_AUTH_URL":     "https://env.example.com",
+		"OS_REGION_NAME":  "EnvRegion",
+	}
+
+	tcs := []struct {
+		name             string
+		data             map[string]string
+		env              map[string]string
+		withoutEnv       bool
+		notAmbient       bool
+		expectedAuthOpts *gophercloud.AuthOptions
+		expectedRegion   string
+		expectedError    error
+	}{
+		{
+			name: "secret only carries the password",
+			data: map[string]string{"password": "secretpass"},
+			env:  env,
+			expectedAuthOpts: &gophercloud.AuthOptions{
+				TenantName:       "envTenant",
+				DomainID:         "envDomainId",
+				Username:         "env-user",
+				Password:         "secretpass",
+				IdentityEndpoint: "https://env.example.com",
+				AllowReauth:      true,
+			},
+			expectedRegion: "EnvRegion",
+		},
+		{
+			name: "secret wins over the environment",
+			data: map[string]string{
+				"username": "john-doe",
+				"password": "secretpass",
+			},
+			env: env,
+			expectedAuthOpts: &gophercloud.AuthOptions{
+				TenantName:       "envTenant",
+				DomainID:         "envDomainId",
+				Username:         "john-doe",
+				Password:         "secretpass",
+				IdentityEndpoint: "https://env.example.com",
+				AllowReauth:      true,
+			},
+			expectedRegion: "EnvRegion",
+		},
+		{
+			name:          "secret with only an endpoint does not pick up the environment's credentials",
+			data:          map[string]string{"identityEndpoint": "https://attacker.example.com"},
+			env:           mergeEnv(env, map[string]string{"OS_PASSWORD": "envpass"}),
+			expectedError: ErrMissingAuthValue,
+		},
+		{
+			name:          "secret with a region does not pick up the environment's credentials",
+			data:          map[string]string{"password": "secretpass", "region": "RegionOne"},
+			env:           env,
+			expectedError: ErrMissingAuthValue,
+		},
+		{
+			name: "application credential of the environment does not mix with a password",
+			data: map[string]string{"password": "secretpass"},
+			env: mergeEnv(env, map[string]string{
+				"OS_APPLICATION_CREDENTIAL_ID":     "env-app-cred-id",
+				"OS_APPLICATION_CREDENTIAL_SECRET": "env-app-cred-secret",
+			}),
+			expectedAuthOpts: &gophercloud.AuthOptions{
+				TenantName:       "envTenant",
+				DomainID:         "envDomainId",
+				Username:         "env-user",
+				Password:         "secretpass",
+				IdentityEndpoint: "https://env.example.com",
+				AllowReauth:      true,
+			},
+			expectedRegion: "EnvRegion",
+		},
+		{
+			name: "application credential split between secret and environment",
+			data: map[string]string{"applicationCredentialSecret": "app-cred-secret"},
+			env: mergeEnv(env, map[string]string{
+				"OS_APPLICATION_CREDENTIAL_ID": "env-app-cred-id",
+				"OS_PASSWORD":                  "envpass",
+			}),
+			expectedAuthOpts: &gophercloud.AuthOptions{
+				DomainID:                    "envDomainId",
+				ApplicationCredentialID:     "env-app-cred-id",
+				ApplicationCredentialSecret: "app-cred-secret",
+				IdentityEndpoint:            "https://env.example.com",
+				AllowReauth:                 true,
+			},
+			expectedRegion: "EnvRegion",
+		},
+		{
+			name:          "missing from both",
+			data:          map[string]string{"password": "secretpass"},
+			env:           stripKey(env, "OS_AUTH_URL"),
+			expectedError: ErrMissingAuthValue,
+		},
+		{
+			name:          "environment is not read without a lookup",
+			data:          map[string]string{"password": "secretpass"},
+			env:           env,
+			withoutEnv:    true,
+			expectedError: ErrMissingAuthValue,
+		},
+		{
+			name:          "environment is not read for issuers without ambient credentials",
+			data:          map[string]string{"password": "secretpass"},
+			env:           env,
+			notAmbient:    true,
+			expectedError: ErrMissingAuthValue,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			for key, value := range tc.env {
+				t.Setenv(key, value)
+			}
+
+			confProvider := authConfigProvider{
+				client: fake.NewClientset(dummySecret("creds", "bar", tc.data)),
+			}
+			if !tc.withoutEnv {
+				confProvider.lookupEnv = os.LookupEnv
+			}
+
+			cfg, err := confProvider.Get(context.Background(), "bar", "creds", !tc.notAmbient)
+			if !errors.Is(err, tc.expectedError) {
+				t.Fatalf("expected err: %v, got %v", tc.expectedError, err)
+			}
+			if tc.expectedError != nil {
+				return
+			}
+
+			if cfg.authOpts != *tc.expectedAuthOpts {
+				t.Errorf("got auth options %+v, want %+v", cfg.authOpts, *tc.expectedAuthOpts)
+			}
+			if cfg.endpointOpts.Region != tc.expectedRegion {
+				t.Errorf("got region %q, want %q", cfg.endpointOpts.Region, tc.expectedRegion)
+			}
+		})
+	}
+}
+
+func mergeEnv(env, extra map[string]string) map[string]string {
+	result := maps.Clone(env)
+	maps.Copy(result, extra)
+
+	return result
+}
+
+func TestAuthConfigProvider_Get_EndpointType(t *testing.T) {
+	tcs := []struct {
+		endpointType         string
+		expectedAvailability gophercloud.Availability
+		expectedError        error
+	}{
+		{endpointType: "", expectedAvailability: gophercloud.AvailabilityPublic},
+		{endpointType: "public", expectedAvailability: gophercloud.AvailabilityPublic},
+		{endpointType: "internal", expectedAvailability: gophercloud.AvailabilityInternal},
+		{endpointType: "admin", expectedAvailability: gophercloud.AvailabilityAdmin},
+		{endpointType: "internalURL", expectedAvailability: gophercloud.AvailabilityInternal},
+		{endpointType: "private", expectedError: ErrInvalidEndpointInterface},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.endpointType, func(t *testing.T) {
+			data := map[string]string{
+				"tenantName":       "testTenant",
+				"domainId":         "testDomainId",
+				"username":         "john-doe",
+				"password":         "secretpass",
+				"identityEndpoint": "https://example.com",
+				"region":           "RegionOne",
+			}
+			if tc.endpointType != "" {
+				data["endpointType"] = tc.endpointType
+			}
+
+			confProvider := authConfigProvider{
+				client: fake.NewClientset(dummySecret("creds", "bar", data)),
+			}
+
+			cfg, err := confProvider.Get(context.Background(), "bar", "creds", false)
+			if !errors.Is(err, tc.expectedError) {
+				t.Fatalf("expected err: %v, got %v", tc.expectedError, err)
+			}
+			if tc.expectedError != nil {
+				return
+			}
+
+			if cfg.endpointOpts.Availability != tc.expectedAvailability {
+				t.Errorf("got availability %s, want %s", cfg.endpointOpts.Availability, tc.expectedAvailability)
+			}
+		})
+	}
+}
+
+func TestAuthConfigProvider_Get_Scope(t *testing.T) {
+	tcs := []struct {
+		name          string
+		data          map[string]string
+		expectedScope *gophercloud.AuthScope
+		expectedError error
+	}{
+		{
+			name:          "project scope by tenant id",
+			data:          map[string]string{"scope": "project", "tenantId": "testTenantId", "domainId": "testDomainId"},
+			expectedScope: &gophercloud.AuthScope{ProjectID: "testTenantId"},
+		},
+		{
+			name:          "project scope by tenant and domain name",
+			data:          map[string]string{"scope": "Project", "tenantName": "testTenant", "domainName": "testDomainName"},
+			expectedScope: &gophercloud.AuthScope{ProjectName: "testTenant", DomainName: "testDomainName"},
+		},
+		{
+			name:          "domain scope needs no tenant",
+			data:          map[string]string{"scope": "domain", "domainId": "testDomainId"},
+			expectedScope: &gophercloud.AuthScope{DomainID: "testDomainId"},
+		},
+		{
+			name: "no scope leaves it to gophercloud",
+			data: map[string]string{"tenantName": "testTenant", "domainId": "testDomainId"},
+		},
+		{
+			name:          "project scope without tenant",
+			data:          map[string]string{"scope": "project", "domainId": "testDomainId"},
+			expectedError: ErrMissingAuthValue,
+		},
+		{
+			name:          "domain scope without domain",
+			data:          map[string]string{"scope": "domain", "tenantId": "testTenantId"},
+			expectedError: ErrMissingAuthValue,
+		},
+		{
+			name:          "unknown scope",
+			data:          map[string]string{"scope": "system", "tenantId": "testTenantId", "domainId": "testDomainId"},
+			expectedError: ErrInvalidAuthScope,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			data := map[string]string{
+				"username":         "john-doe",
+				"password":         "secretpass",
+				"identityEndpoint": "https://example.com",
+				"region":           "RegionOne",
+			}
+			maps.Copy(data, tc.data)
+
+			confProvider := authConfigProvider{
+				client: fake.NewClientset(dummySecret("creds", "bar", data)),
+			}
+
+			cfg, err := confProvider.Get(context.Background(), "bar", "creds", false)
+			if !errors.Is(err, tc.expectedError) {
+				t.Fatalf("expected err: %v, got %v", tc.expectedError, err)
+			}
+			if tc.expectedError != nil {
+				return
+			}
+
+			if !reflect.DeepEqual(cfg.authOpts.Scope, tc.expectedScope) {
+				t.Errorf("got scope %+v, want %+v", cfg.authOpts.Scope, tc.expectedScope)
+			}
+		})
+	}
+}
+
+func TestAuthConfigProvider_Get_AllowReauth(t *testing.T) {
+	cloudsYAML := `
+clouds:
+  production:
+    auth:
+      auth_url: https://example.com
+      application_credential_id: app-cred-id
+      application_credential_secret: app-cred-secret
+    region_name: RegionOne
+`
+
+	tcs := []struct {
+		name     string
+		data     map[string]string
+		expected bool
+	}{
+		{
+			name:     "default",
+			expected: true,
+		},
+		{
+			name:     "allowed",
+			data:     map[string]string{"allowReauth": "true"},
+			expected: true,
+		},
+		{
+			name:     "disallowed",
+			data:     map[string]string{"allowReauth": "false"},
+			expected: false,
+		},
+		{
+			name:     "disallowed with clouds.yaml",
+			data:     map[string]string{"clouds.yaml": cloudsYAML, "allowReauth": "false"},
+			expected: false,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			data := map[string]string{
+				"tenantName":       "testTenant",
+				"domainId":         "testDomainId",
+				"username":         "john-doe",
+				"password":         "secretpass",
+				"identityEndpoint": "https://example.com",
+				"region":           "RegionOne",
+			}
+			if _, ok := tc.data["clouds.yaml"]; ok {
+				data = map[string]string{}
+			}
+			maps.Copy(data, tc.data)
+
+			confProvider := authConfigProvider{
+				client: fake.NewClientset(dummySecret("creds", "bar", data)),
+			}
+
+			cfg, err := confProvider.Get(context.Background(), "bar", "creds", false)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if cfg.authOpts.AllowReauth != tc.expected {
+				t.Errorf("got AllowReauth %t, want %t", cfg.authOpts.AllowReauth, tc.expected)
+			}
+		})
+	}
+}
+
+func TestAuthConfigProvider_Get_Passcode(t *testing.T) {
+	tcs := []struct {
+		name          string
+		data          map[string]string
+		expectedError error
+	}{
+		{
+			name: "passcode turns reauth off",
+			data: map[string]string{"passcode": "123456"},
+		},
+		{
+			name: "passcode with reauth explicitly off",
+			data: map[string]string{"passcode": "123456", "allowReauth": "false"},
+		},
+		{
+			name:          "passcode with reauth explicitly on",
+			data:          map[string]string{"passcode": "123456", "allowReauth": "true"},
+			expectedError: ErrPasscodeReauth,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			data := map[string]string{
+				"tenantName":       "testTenant",
+				"domainId":         "testDomainId",
+				"username":         "john-doe",
+				"password":         "secretpass",
+				"identityEndpoint": "https://example.com",
+				"region":           "RegionOne",
+			}
+			maps.Copy(data, tc.data)
+
+			confProvider := authConfigProvider{
+				client: fake.NewClientset(dummySecret("creds", "bar", data)),
+			}
+
+			cfg, err := confProvider.Get(context.Background(), "bar", "creds", false)
+			if !errors.Is(err, tc.expectedError) {
+				t.Fatalf("expected err: %v, got %v", tc.expectedError, err)
+			}
+			if tc.expectedError != nil {
+				return
+			}
+
+			if cfg.authOpts.Passcode != "123456" {
+				t.Errorf("got passcode %q, want %q", cfg.authOpts.Passcode, "123456")
+			}
+			if cfg.authOpts.AllowReauth {
+				t.Error("expected AllowReauth to be off with a passcode")
+			}
+		})
+	}
+}
+
+func TestAuthConfigProvider_Get_IdentityEndpointScheme(t *testing.T) {
+	tcs := []struct {
+		name             string
+		identityEndpoint string
+		allowHTTP        string
+		expectedEndpoint string
+		expectedError    error
+	}{
+		{
+			name:             "http rejected by default",
+			identityEndpoint: "http://example.com/v3",
+			expectedError:    ErrInsecureIdentityEndpoint,
+		},
+		{
+			name:             "http allowed with insecureAllowHTTP",
+			identityEndpoint: "http://example.com/v3",
+			allowHTTP:        "true",
+			expectedEndpoint: "http://example.com/v3",
+		},
+		{
+			name:             "https accepted",
+			identityEndpoint: "https://example.com/v3",
+			expectedEndpoint: "https://example.com/v3",
+		},
+		{
+			name:             "canonicalized",
+			identityEndpoint: " HTTPS://example.com/v3\n",
+			expectedEndpoint: "https://example.com/v3",
+		},
+		{
+			name:             "unsupported scheme",
+			identityEndpoint: "ftp://example.com/v3",
+			expectedError:    ErrInvalidIdentityEndpoint,
+		},
+		{
+			name:             "missing scheme",
+			identityEndpoint: "example.com/v3",
+			expectedError:    ErrInvalidIdentityEndpoint,
+		},
+		{
+			name:             "missing scheme with port",
+			identityEndpoint: "keystone.example.com:5000/v3",
+			expectedError:    ErrInvalidIdentityEndpoint,
+		},
+		{
+			name:             "no host",
+			identityEndpoint: "https:///v3",
+			expectedError:    ErrInvalidIdentityEndpoint,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			data := map[string]string{
+				"tenantName":       "testTenant",
+				"domainId":         "testDomainId",
+				"username":         "john-doe",
+				"password":         "secretpass",
+				"identityEndpoint": tc.identityEndpoint,
+				"region":           "RegionOne",
+			}
+			if tc.allowHTTP != "" {
+				data["insecureAllowHTTP"] = tc.allowHTTP
+			}
+
+			confProvider := authConfigProvider{
+				client: fake.NewClientset(dummySecret("creds", "bar", data)),
+			}
+
+			cfg, err := confProvider.Get(context.Background(), "bar", "creds", false)
+			if !errors.Is(err, tc.expectedError) {
+				t.Fatalf("expected err: %v, got %v", tc.expectedError, err)
+			}
+			if tc.expectedError != nil {
+				return
+			}
+
+			if cfg.authOpts.IdentityEndpoint != tc.expectedEndpoint {
+				t.Errorf("got IdentityEndpoint: %s, want %s", cfg.authOpts.IdentityEndpoint, tc.expectedEndpoint)
+			}
+		})
+	}
+
+	t.Run("clouds.yaml", func(t *testing.T) {
+		confProvider := authConfigProvider{
+			client: fake.NewClientset(dummySecret("creds", "bar", map[string]string{
+				"clouds.yaml": `
+clouds:
+  production:
+    auth:
+      auth_url: http://example.com/v3
+      username: john-doe
+      password: secretpass
+    region_name: RegionOne
+`,
+			})),
+		}
+
+		_, err := confProvider.Get(context.Background(), "bar", "creds", false)
+		if !errors.Is(err, ErrInsecureIdentityEndpoint) {
+			t.Errorf("expected err: %v, got %v", ErrInsecureIdentityEndpoint, err)
+		}
+	})
+}
+
+func TestAuthConfigProvider_Get_CloudsYAML(t *testing.T) {
+	cloudsYAML := `
+clouds:
+  production:
+    auth:
+      auth_url: https://example.com
+      username: john-doe
+      password: secretpass
+      project_name: testTenant
+      user_domain_name: testDomainName
+    region_name: RegionOne
+    interface: internal
+  staging:
+    auth:
+      auth_url: https://staging.example.com
+      application_credential_id: app-cred-id
+      application_credential_secret: app-cred-secret
+    region_name: RegionTwo
+`
+
+	tcs := []struct {
+		name                 string
+		data                 map[string]string
+		expectedAuthOpts     *gophercloud.AuthOptions
+		expectedEndpointOpts gophercloud.EndpointOpts
+		expectedError        error
+	}{
+		{
+			name: "named cloud",
+			data: map[string]string{
+				"clouds.yaml": cloudsYAML,
+				"cloud":       "production",
+			},
+			expectedAuthOpts: &gophercloud.AuthOptions{
+				IdentityEndpoint: "https://example.com",
+				Username:         "john-doe",
+				Password:         "secretpass",
+				TenantName:       "testTenant",
+				DomainName:       "testDomainName",
+				AllowReauth:      true,
+			},
+			expectedEndpointOpts: gophercloud.EndpointOpts{
+				Region:       "RegionOne",
+				Availability: gophercloud.AvailabilityInternal,
+			},
+		},
+		{
+			name: "application credential",
+			data: map[string]string{
+				"clouds.yaml": cloudsYAML,
+				"cloud":       "staging",
+			},
+			expectedAuthOpts: &gophercloud.AuthOptions{
+				IdentityEndpoint:            "https://staging.example.com",
+				ApplicationCredentialID:     "app-cred-id",
+				ApplicationCredentialSecret: "app-cred-secret",
+				AllowReauth:                 true,
+			},
+			expectedEndpointOpts: gophercloud.EndpointOpts{
+				Region:       "RegionTwo",
+				Availability: gophercloud.AvailabilityPublic,
+			},
+		},
+		{
+			name: "single cloud without a name",
+			data: map[string]string{
+				"clouds.yaml": `
+clouds:
+  only:
+    auth:
+      auth_url: https://example.com
+      user_id: john-doe-id
+      password: secretpass
+      project_id: testTenantId
+      user_domain_id: testDomainId
+    region_name: RegionOne
+`,
+			},
+			expectedAuthOpts: &gophercloud.AuthOptions{
+				IdentityEndpoint: "https://example.com",
+				UserID:           "john-doe-id",
+				Password:         "secretpass",
+				TenantID:         "testTenantId",
+				DomainID:         "testDomainId",
+				AllowReauth:      true,
+				Scope:            &gophercloud.AuthScope{ProjectID: "testTenantId"},
+			},
+			expectedEndpointOpts: gophercloud.EndpointOpts{
+				Region:       "RegionOne",
+				Availability: gophercloud.AvailabilityPublic,
+			},
+		},
+		{
+			name: "missing named cloud",
+			data: map[string]string{
+				"clouds.yaml": cloudsYAML,
+				"cloud":       "development",
+			},
+			expectedError: ErrUnknownCloud,
+		},
+		{
+			name: "several clouds without a name",
+			data: map[string]string{
+				"clouds.yaml": cloudsYAML,
+			},
+			expectedError: ErrUnknownCloud,
+		},
+		{
+			name: "malformed yaml",
+			data: map[string]string{
+				"clouds.yaml": "clouds: [production",
+			},
+			expectedError: ErrInvalidCloudsYAML,
+		},
+		{
+			name: "separate user and project domains",
+			data: map[string]string{
+				"clouds.yaml": `
+clouds:
+  production:
+    auth:
+      auth_url: https://example.com
+      username: john-doe
+      password: secretpass
+      project_name: testTenant
+      user_domain_name: users
+      project_domain_id: projects-id
+    region_name: RegionOne
+`,
+			},
+			expectedAuthOpts: &gophercloud.AuthOptions{
+				IdentityEndpoint: "https://example.com",
+				Username:         "john-doe",
+				Password:         "secretpass",
+				TenantName:       "testTenant",
+				DomainName:       "users",
+				AllowReauth:      true,
+				Scope:            &gophercloud.AuthScope{ProjectName: "testTenant", DomainID: "projects-id"},
+			},
+			expectedEndpointOpts: gophercloud.EndpointOpts{
+				Region:       "RegionOne",
+				Availability: gophercloud.AvailabilityPublic,
+			},
+		},
+		{
+			name: "region chosen among the regions",
+			data: map[string]string{
+				"clouds.yaml": `
+clouds:
+  production:
+    auth:
+      auth_url: https://example.com
+      application_credential_id: app-cred-id
+      application_credential_secret: app-cred-secret
+    regions:
+    - RegionOne
+    - name: RegionTwo
+`,
+				"region": "RegionTwo",
+			},
+			expectedAuthOpts: &gophercloud.AuthOptions{
+				IdentityEndpoint:            "https://example.com",
+				ApplicationCredentialID:     "app-cred-id",
+				ApplicationCredentialSecret: "app-cred-secret",
+				AllowReauth:                 true,
+			},
+			expectedEndpointOpts: gophercloud.EndpointOpts{
+				Region:       "RegionTwo",
+				Availability: gophercloud.AvailabilityPublic,
+			},
+		},
+		{
+			name: "region not among the regions",
+			data: map[string]string{
+				"clouds.yaml": `
+clouds:
+  production:
+    auth:
+      auth_url: https://example.com
+      application_credential_id: app-cred-id
+      application_credential_secret: app-cred-secret
+    regions:
+    - RegionOne
+`,
+				"region": "RegionTwo",
+			},
+			expectedError: ErrInvalidCloudsYAML,
+		},
+		{
+			name: "unknown field",
+			data: map[string]string{
+				"clouds.yaml": `
+clouds:
+  production:
+    auth:
+      auth_url: https://example.com
+      username: john-doe
+      password: secretpass
+      default_domain: Default
+    region_name: RegionOne
+`,
+			},
+			expectedError: ErrInvalidCloudsYAML,
+		},
+		{
+			name: "unsupported auth type",
+			data: map[string]string{
+				"clouds.yaml": `
+clouds:
+  production:
+    auth_type: v3oidcpassword
+    auth:
+      auth_url: https://example.com
+      username: john-doe
+      password: secretpass
+    region_name: RegionOne
+`,
+			},
+			expectedError: ErrUnsupportedCloudsYAML,
+		},
+		{
+			name: "verification disabled",
+			data: map[string]string{
+				"clouds.yaml": `
+clouds:
+  production:
+    auth:
+      auth_url: https://example.com
+      username: john-doe
+      password: secretpass
+    region_name: RegionOne
+    verify: false
+`,
+			},
+			expectedError: ErrUnsupportedCloudsYAML,
+		},
+		{
+			name: "cacert path",
+			data: map[string]string{
+				"clouds.yaml": `
+clouds:
+  production:
+    auth:
+      auth_url: https://example.com
+      username: john-doe
+      password: secretpass
+    region_name: RegionOne
+    cacert: /etc/ssl/ca.pem
+`,
+			},
+			expectedError: ErrUnsupportedCloudsYAML,
+		},
+		{
+			name: "missing region",
+			data: map[string]string{
+				"clouds.yaml": `
+clouds:
+  production:
+    auth:
+      auth_url: https://example.com
+      username: john-doe
+      password: secretpass
+`,
+			},
+			expectedError: ErrMissingAuthValue,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			confProvider := authConfigProvider{
+				client:      fake.NewClientset(dummySecret("creds", "bar", tc.data)),
+				unknownKeys: UnknownSecretKeysError,
+			}
+
+			cfg, err := confProvider.Get(context.Background(), "bar", "creds", false)
+			if !errors.Is(err, tc.expectedError) {
+				t.Fatalf("expected err: %v, got %v", tc.expectedError, err)
+			}
+			if tc.expectedError != nil {
+				return
+			}
+
+			if !reflect.DeepEqual(cfg.authOpts, *tc.expectedAuthOpts) {
+				t.Errorf("got auth options %+v, want %+v", cfg.authOpts, *tc.expectedAuthOpts)
+			}
+
+			if !reflect.DeepEqual(cfg.endpointOpts, tc.expectedEndpointOpts) {
+				t.Errorf("got endpoint options %+v, want %+v", cfg.endpointOpts, tc.expectedEndpointOpts)
+			}
+		})
+	}
+}
+
+func TestAuthConfigProvider_Get_CloudsYAMLTLSAndIdentityVersion(t *testing.T) {
+	confProvider := authConfigProvider{
+		client: fake.NewClientset(dummySecret("creds", "bar", map[string]string{
+			"clouds.yaml": `
+clouds:
+  production:
+    auth:
+      auth_url: https://example.com
+      username: john-doe
+      password: secretpass
+      project_id: testTenantId
+      user_domain_id: testDomainId
+    region_name: RegionOne
+    identity_api_version: 3
+    verify: true
+    cacert: |
+      -----BEGIN CERTIFICATE-----
+      ca
+      -----END CERTIFICATE-----
+`,
+		})),
+	}
+
+	cfg, err := confProvider.Get(context.Background(), "bar", "creds", false)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if cfg.identityAPIVersion != identityAPIVersionV3 {
+		t.Errorf("expected identity API version %q, got %q", identityAPIVersionV3, cfg.identityAPIVersion)
+	}
+	if !strings.HasPrefix(string(cfg.caCert), "-----BEGIN CERTIFICATE-----") {
+		t.Errorf("expected the cacert to be applied, got %q", cfg.caCert)
+	}
+}
+
+func TestAuthConfigProvider_Get_CloudsYAMLWithOtherKeys(t *testing.T) {
+	cloudsYAML := `
+clouds:
+  production:
+    auth:
+      auth_url: https://example.com
+      username: john-doe
+      password: secretpass
+      project_name: testTenant
+      user_domain_name: testDomainName
+    region_name: RegionOne
+`
+
+	t.Run("settings are applied", func(t *testing.T) {
+		confProvider := authConfigProvider{
+			client: fake.NewClientset(dummySecret("creds", "bar", map[string]string{
+				"clouds.yaml":        cloudsYAML,
+				"caCert":             "ca",
+				"designateEndpoints": "RegionOne=https://dns.example.com",
+				"endpointType":       "admin",
+				"identityApiVersion": "3",
+				"scope":              "Project",
+				"allowReauth":        "false",
+			})),
+			unknownKeys: UnknownSecretKeysError,
+		}
+
+		cfg, err := confProvider.Get(context.Background(), "bar", "creds", false)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if string(cfg.caCert) != "ca" || cfg.designateEndpoints != "RegionOne=https://dns.example.com" {
+			t.Errorf("expected caCert and designateEndpoints to be applied, got %q and %q", cfg.caCert, cfg.designateEndpoints)
+		}
+		if cfg.endpointOpts.Availability != gophercloud.AvailabilityAdmin || cfg.identityAPIVersion != identityAPIVersionV3 {
+			t.Errorf("expected endpointType and identityApiVersion to be applied, got %q and %q", cfg.endpointOpts.Availability, cfg.identityAPIVersion)
+		}
+		if cfg.authOpts.Scope == nil || cfg.authOpts.Scope.ProjectName != "testTenant" || cfg.authOpts.AllowReauth {
+			t.Errorf("expected scope and allowReauth to be applied, got %+v", cfg.authOpts)
+		}
+	})
+
+	t.Run("invalid settings are rejected", func(t *testing.T) {
+		confProvider := authConfigProvider{
+			client: fake.NewClientset(dummySecret("creds", "bar", map[string]string{
+				"clouds.yaml":  cloudsYAML,
+				"endpointType": "private",
+			})),
+		}
+
+		_, err := confProvider.Get(context.Background(), "bar", "creds", false)
+		if !errors.Is(err, ErrInvalidEndpointInterface) {
+			t.Errorf("expected err: %v, got %v", ErrInvalidEndpointInterface, err)
+		}
+	})
+
+	t.Run("keys describing the cloud are rejected", func(t *testing.T) {
+		confProvider := authConfigProvider{
+			client: fake.NewClientset(dummySecret("creds", "bar", map[string]string{
+				"clouds.yaml": cloudsYAML,
+				"passcode":    "123456",
+				"username":    "jane-doe",
+			})),
+		}
+
+		_, err := confProvider.Get(context.Background(), "bar", "creds", false)
+		if !errors.Is(err, ErrConflictingCloudsYAML) {
+			t.Fatalf("expected err: %v, got %v", ErrConflictingCloudsYAML, err)
+		}
+		for _, key := range []string{"passcode", "username"} {
+			if !strings.Contains(err.Error(), key) {
+				t.Errorf("expected the error to name %s, got %v", key, err)
+			}
+		}
+	})
+}
+
 func dummySecret(name, namespace string, data map[string]string) *corev1.Secret {
 	result := &corev1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
@@ -0,0 +1,52 @@
+package resolver
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gophercloud/gophercloud/v2"
+)
+
+var ErrInvalidAuthScope = errors.New("invalid scope, expected project or domain")
+
+const (
+	// authScopeProject requests a token scoped to the project of tenantId or tenantName.
+	authScopeProject = "project"
+	// authScopeDomain requests a token scoped to the domain of domainId or domainName, which some
+	// designate policies require.
+	authScopeDomain = "domain"
+)
+
+// applyAuthScope sets the keystone v3 scope of password credentials, given in lower case. Without a
+// scope, gophercloud derives it from the tenant and domain values as before. It fails with
+// ErrMissingAuthValue when the values identifying the chosen scope are missing.
+func applyAuthScope(opts *gophercloud.AuthOptions, scope string) error {
+	switch scope {
+	case "":
+		return nil
+	case authScopeProject:
+		switch {
+		case opts.TenantID != "":
+			opts.Scope = &gophercloud.AuthScope{ProjectID: opts.TenantID}
+		case opts.TenantName != "" && opts.DomainID != "":
+			opts.Scope = &gophercloud.AuthScope{ProjectName: opts.TenantName, DomainID: opts.DomainID}
+		case opts.TenantName != "" && opts.DomainName != "":
+			opts.Scope = &gophercloud.AuthScope{ProjectName: opts.TenantName, DomainName: opts.DomainName}
+		default:
+			return fmt.Errorf("%w: tenantId, or tenantName and domainId or domainName, for the %s scope", ErrMissingAuthValue, authScopeProject)
+		}
+	case authScopeDomain:
+		switch {
+		case opts.DomainID != "":
+			opts.Scope = &gophercloud.AuthScope{DomainID: opts.DomainID}
+		case opts.DomainName != "":
+			opts.Scope = &gophercloud.AuthScope{DomainName: opts.DomainName}
+		default:
+			return fmt.Errorf("%w: domainId or domainName for the %s scope", ErrMissingAuthValue, authScopeDomain)
+		}
+	default:
+		return fmt.Errorf("%w: %q", ErrInvalidAuthScope, scope)
+	}
+
+	return nil
+}
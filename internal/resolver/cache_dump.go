@@ -0,0 +1,147 @@
+package resolver
+
+import (
+	"cmp"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// redacted replaces secrets in cache dumps.
+const redacted = "<redacted>"
+
+// CacheDump is a snapshot of the resolver's caches for debugging stale credentials or zones. Secrets
+// are redacted. Expired entries that were not evicted yet are included with a negative ExpiresIn.
+type CacheDump struct {
+	Clients     []ClientCacheEntry      `json:"clients"`
+	Zones       []ZoneCacheEntry        `json:"zones"`
+	Propagation []PropagationCacheEntry `json:"propagation"`
+}
+
+// ClientCacheEntry is an authenticated client of the token cache.
+type ClientCacheEntry struct {
+	// Key is a prefix of the cache key, which hashes the credentials.
+	Key              string `json:"key"`
+	Cloud            string `json:"cloud"`
+	IdentityEndpoint string `json:"identityEndpoint"`
+	User             string `json:"user"`
+	// Token is always redacted, it only tells whether the client holds one.
+	Token string `json:"token"`
+	// ExpiresIn is the time left until the token is no longer used, allowing for the expiry skew.
+	ExpiresIn string `json:"expiresIn"`
+}
+
+// ZoneCacheEntry is a zone listing of the zone cache.
+type ZoneCacheEntry struct {
+	Key       string   `json:"key"`
+	Zones     []string `json:"zones"`
+	ExpiresIn string   `json:"expiresIn"`
+}
+
+// PropagationCacheEntry is a zone and nameserver of the propagation cache.
+type PropagationCacheEntry struct {
+	Key        string    `json:"key"`
+	ObservedAt time.Time `json:"observedAt"`
+	ExpiresIn  string    `json:"expiresIn"`
+}
+
+// CacheDumper is implemented by solvers that can report their cache state, so that it can be served
+// by the debug server.
+type CacheDumper interface {
+	DumpCaches() CacheDump
+}
+
+var _ CacheDumper = (*designateDnsResolver)(nil)
+
+// DumpCaches returns the current entries of all caches, sorted by key.
+func (d *designateDnsResolver) DumpCaches() CacheDump {
+	dump := CacheDump{
+		Clients:     d.tokenCache.dump(),
+		Zones:       d.zoneCache.dump(),
+		Propagation: d.propagationCache.dump(),
+	}
+
+	slices.SortFunc(dump.Clients, func(a, b ClientCacheEntry) int { return cmp.Compare(a.Key, b.Key) })
+	slices.SortFunc(dump.Zones, func(a, b ZoneCacheEntry) int { return cmp.Compare(a.Key, b.Key) })
+	slices.SortFunc(dump.Propagation, func(a, b PropagationCacheEntry) int { return cmp.Compare(a.Key, b.Key) })
+
+	return dump
+}
+
+func (c *tokenCache) dump() []ClientCacheEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entries := make([]ClientCacheEntry, 0, len(c.entries))
+	for key, entry := range c.entries {
+		token := ""
+		if entry.client.Token() != "" {
+			token = redacted
+		}
+
+		entries = append(entries, ClientCacheEntry{
+			Key:              key[:12],
+			Cloud:            entry.cloud,
+			IdentityEndpoint: redactURL(entry.client.IdentityEndpoint),
+			User:             entry.user,
+			Token:            token,
+			ExpiresIn:        expiresIn(entry.expires.Add(-c.skew), c.clock()),
+		})
+	}
+
+	return entries
+}
+
+func (c *zoneCache) dump() []ZoneCacheEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entries := make([]ZoneCacheEntry, 0, len(c.entries))
+	for key, entry := range c.entries {
+		names := make([]string, 0, len(entry.zones))
+		for _, zone := range entry.zones {
+			names = append(names, zone.Name+" ("+zone.ID+")")
+		}
+
+		entries = append(entries, ZoneCacheEntry{
+			Key:       key,
+			Zones:     names,
+			ExpiresIn: expiresIn(entry.expires, c.clock()),
+		})
+	}
+
+	return entries
+}
+
+func (c *propagationCache) dump() []PropagationCacheEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entries := make([]PropagationCacheEntry, 0, len(c.settled))
+	for key, observedAt := range c.settled {
+		entries = append(entries, PropagationCacheEntry{
+			Key:        key,
+			ObservedAt: observedAt,
+			ExpiresIn:  expiresIn(observedAt.Add(c.ttl), c.clock()),
+		})
+	}
+
+	return entries
+}
+
+func expiresIn(expires, now time.Time) string {
+	return expires.Sub(now).Round(time.Second).String()
+}
+
+// redactURL masks the password of credentials embedded in the URL.
+func redactURL(raw string) string {
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		// The URL may not be printable safely, keep only what is before any userinfo.
+		scheme, _, _ := strings.Cut(raw, "://")
+		return scheme + "://" + redacted
+	}
+
+	return parsed.Redacted()
+}
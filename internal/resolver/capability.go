@@ -0,0 +1,169 @@
+package resolver
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/gophercloud/gophercloud/v2/openstack/dns/v2/recordsets"
+	"github.com/gophercloud/gophercloud/v2/openstack/dns/v2/zones"
+	"github.com/gophercloud/gophercloud/v2/pagination"
+	"k8s.io/klog/v2"
+)
+
+// capabilityProbeLabel is the parent label of the recordsets written to probe for write permissions.
+const capabilityProbeLabel = "_cert-manager-webhook-designate-probe"
+
+// newProbeLabel returns the random label naming a probe recordset below capabilityProbeLabel, so
+// that probes never collide with each other. Replaceable in tests.
+var newProbeLabel = func() string {
+	label := make([]byte, 8)
+	_, _ = rand.Read(label)
+	return hex.EncodeToString(label)
+}
+
+var ErrMissingCapability = errors.New("the credentials are not permitted to perform an operation the webhook needs")
+
+// WithCapabilityProbe checks, on first use of credentials, that they may perform the operations the
+// challenge needs: listing zones, unless the ZoneID strategy is used, and creating and deleting
+// recordsets in the matched zone. The latter writes a short-lived probe recordset. Policy gaps then
+// fail with ErrMissingCapability instead of a bare 403 halfway through the challenge.
+func WithCapabilityProbe(enabled bool) Option {
+	return func(d *designateDnsResolver) {
+		d.capabilityProbe = enabled
+	}
+}
+
+// probeOnce runs the probe of the capability with the given key unless it passed before. Concurrent
+// callers share a single probe, see doShared.
+func (d *designateDnsResolver) probeOnce(ctx context.Context, key string, timeout time.Duration, probe func(context.Context) error) error {
+	if _, ok := d.probedCapabilities.Load(key); ok {
+		return nil
+	}
+
+	_, err := d.doShared(ctx, &d.capabilityProbes, key, timeout, func(ctx context.Context) (any, error) {
+		if _, ok := d.probedCapabilities.Load(key); ok {
+			return nil, nil
+		}
+		if err := probe(ctx); err != nil {
+			return nil, err
+		}
+
+		d.probedCapabilities.Store(key, struct{}{})
+		return nil, nil
+	})
+	return err
+}
+
+// probeListCapability checks that zones can be listed. Successful probes are remembered per cloud.
+func (d *designateDnsResolver) probeListCapability(ctx context.Context, cfg *ChallengeConfig, cloud string, designateClient *gophercloud.ServiceClient) error {
+	if !d.capabilityProbe || cfg.Strategy.Kind == StrategyKindZoneID {
+		return nil
+	}
+
+	return d.probeOnce(ctx, cloud+"|list", d.listTimeout, func(ctx context.Context) error {
+		err := zones.List(designateClient, zones.ListOpts{Limit: 1}).EachPage(ctx, func(context.Context, pagination.Page) (bool, error) {
+			return false, nil
+		})
+		if gophercloud.ResponseCodeIs(err, http.StatusForbidden) {
+			return fmt.Errorf("%w: listing zones, needed by the %s strategy: %w", ErrMissingCapability, cfg.Strategy.Kind, err)
+		}
+		return err
+	})
+}
+
+// probeWriteCapability checks, if enabled, that recordsets can be created and deleted in the zone.
+func (d *designateDnsResolver) probeWriteCapability(ctx context.Context, cloud string, designateClient *gophercloud.ServiceClient, zone *zones.Zone) error {
+	if !d.capabilityProbe {
+		return nil
+	}
+
+	return d.probeWrite(ctx, cloud, designateClient, zone)
+}
+
+// probeWrite checks that recordsets can be created and deleted in the zone by writing and removing a
+// probe recordset. Successful probes are remembered per cloud and zone.
+func (d *designateDnsResolver) probeWrite(ctx context.Context, cloud string, designateClient *gophercloud.ServiceClient, zone *zones.Zone) error {
+	return d.probeOnce(ctx, cloud+"|write|"+zone.ID, d.mutateTimeout, func(ctx context.Context) error {
+		name := newProbeLabel() + "." + capabilityProbeLabel + "." + enforceTrailingDot(zone.Name)
+
+		probe, err := createProbe(ctx, designateClient, zone, name)
+		// The name is taken by a probe left behind, e.g. by a webhook that stopped mid-probe.
+		if gophercloud.ResponseCodeIs(err, http.StatusConflict) {
+			klog.V(2).InfoS("removing a capability probe left behind", "zone", zone.Name, "name", name)
+			if err := deleteProbes(ctx, designateClient, zone, name); err != nil {
+				return err
+			}
+			probe, err = createProbe(ctx, designateClient, zone, name)
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := deleteProbe(ctx, designateClient, zone, probe); err != nil {
+			return err
+		}
+
+		klog.V(2).InfoS("capability probe passed", "zone", zone.Name)
+		return nil
+	})
+}
+
+// createProbe writes the probe recordset of the given name.
+func createProbe(ctx context.Context, designateClient *gophercloud.ServiceClient, zone *zones.Zone, name string) (*recordsets.RecordSet, error) {
+	probe, err := recordsets.Create(ctx, designateClient, zone.ID, recordsets.CreateOpts{
+		Name:        name,
+		Type:        "TXT",
+		Records:     []string{`"capability probe"`},
+		Description: "written and removed by cert-manager-webhook-designate to check its permissions",
+	}).Extract()
+	if gophercloud.ResponseCodeIs(err, http.StatusForbidden) {
+		return nil, fmt.Errorf("%w: creating recordsets in %s: %w", ErrMissingCapability, enforceTrailingDot(zone.Name), err)
+	}
+	if gophercloud.ResponseCodeIs(err, http.StatusConflict) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, readOnlyAware(err)
+	}
+
+	return probe, nil
+}
+
+// deleteProbes removes the probe recordsets of the given name.
+func deleteProbes(ctx context.Context, designateClient *gophercloud.ServiceClient, zone *zones.Zone, name string) error {
+	pages, err := recordsets.ListByZone(designateClient, zone.ID, recordsets.ListOpts{Name: name, Type: "TXT"}).AllPages(ctx)
+	if err != nil {
+		return err
+	}
+	leftovers, err := recordsets.ExtractRecordSets(pages)
+	if err != nil {
+		return err
+	}
+
+	for i := range leftovers {
+		if err := deleteProbe(ctx, designateClient, zone, &leftovers[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// deleteProbe removes a probe recordset.
+func deleteProbe(ctx context.Context, designateClient *gophercloud.ServiceClient, zone *zones.Zone, probe *recordsets.RecordSet) error {
+	err := recordsets.Delete(ctx, designateClient, zone.ID, probe.ID).ExtractErr()
+	if gophercloud.ResponseCodeIs(err, http.StatusForbidden) {
+		return fmt.Errorf("%w: deleting recordsets in %s, the probe recordset %s has to be removed by hand: %w", ErrMissingCapability, enforceTrailingDot(zone.Name), probe.Name, err)
+	}
+	if err != nil {
+		return readOnlyAware(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,195 @@
+package resolver
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	mockresolver "github.com/rikotsev/cert-manager-webhook-designate/internal/resolver/mock"
+)
+
+func TestDesignateDnsResolver_CapabilityProbe(t *testing.T) {
+	tcs := []struct {
+		name              string
+		strategy          string
+		forbidZoneListing bool
+		forbidWrites      bool
+		expectedError     error
+		expectedMessage   string
+	}{
+		{
+			name:     "permitted",
+			strategy: `{"kind": "BestEffort"}`,
+		},
+		{
+			name:              "missing list permission",
+			strategy:          `{"kind": "BestEffort"}`,
+			forbidZoneListing: true,
+			expectedError:     ErrMissingCapability,
+			expectedMessage:   "listing zones, needed by the BestEffort strategy",
+		},
+		{
+			name:            "missing write permission",
+			strategy:        `{"kind": "BestEffort"}`,
+			forbidWrites:    true,
+			expectedError:   ErrMissingCapability,
+			expectedMessage: "creating recordsets in example.com.",
+		},
+		{
+			name:              "ZoneID strategy does not need to list zones",
+			strategy:          `{"kind": "ZoneID", "zoneId": "12345"}`,
+			forbidZoneListing: true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			mockApi := mockresolver.CreateMockOpenstackApi(t)
+			mockApi.Zones = []mockresolver.MockZone{
+				{
+					ID:   "12345",
+					Name: "example.com.",
+				},
+			}
+			mockApi.ForbiddenZoneListing = tc.forbidZoneListing
+			mockApi.ForbiddenWrites = tc.forbidWrites
+			resolver := newTestResolver(t, mockApi)
+			WithCapabilityProbe(true)(resolver)
+
+			ch := newChallengeRequest("challenge", "cool.example.com", "example.com", `{
+				"secretName": "foo",
+				"secretNamespace": "bar",
+				"strategy": `+tc.strategy+`
+			}`)
+
+			err := resolver.Present(ch)
+			if !errors.Is(err, tc.expectedError) {
+				t.Fatalf("expected error %v, got %v", tc.expectedError, err)
+			}
+			if tc.expectedError != nil {
+				if !strings.Contains(err.Error(), tc.expectedMessage) {
+					t.Errorf("expected error to contain %q, got %v", tc.expectedMessage, err)
+				}
+				if len(mockApi.Updates) != 0 {
+					t.Errorf("expected no recordset creates, got %v", mockApi.Updates)
+				}
+				return
+			}
+
+			// The probe recordset is created and removed before the challenge recordset.
+			if len(mockApi.Updates) != 2 || !strings.Contains(mockApi.Updates[0].Opts.Name, "."+capabilityProbeLabel+".") {
+				t.Fatalf("expected the probe and the challenge recordset to be created, got %v", mockApi.Updates)
+			}
+			if len(mockApi.RecordSetDeletes) != 1 || len(mockApi.RecordSets) != 1 {
+				t.Errorf("expected the probe recordset to be deleted, got %d deletes and %d recordsets", len(mockApi.RecordSetDeletes), len(mockApi.RecordSets))
+			}
+
+			// Passed probes are not repeated.
+			if err := resolver.Present(newChallengeRequest("other", "cool.example.com", "example.com", string(ch.Config.Raw))); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if len(mockApi.Updates) != 2 || len(mockApi.RecordSetDeletes) != 1 {
+				t.Errorf("expected no further probe, got %d creates and %d deletes", len(mockApi.Updates), len(mockApi.RecordSetDeletes))
+			}
+		})
+	}
+}
+
+func TestDesignateDnsResolver_CapabilityProbe_SharedBetweenConcurrentChallenges(t *testing.T) {
+	mockApi := mockresolver.CreateMockOpenstackApi(t)
+	mockApi.Zones = []mockresolver.MockZone{
+		{
+			ID:   "12345",
+			Name: "example.com.",
+		},
+	}
+	mockApi.WriteDelay = 200 * time.Millisecond
+	mockApi.RejectDuplicateRecordSets = true
+	resolver := newTestResolver(t, mockApi)
+	WithCapabilityProbe(true)(resolver)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 5)
+	for i := range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- resolver.Present(newChallengeRequest(fmt.Sprintf("challenge-%d", i), fmt.Sprintf("cool-%d.example.com", i), "example.com", `{
+				"secretName": "foo",
+				"secretNamespace": "bar",
+				"strategy": {
+					"kind": "BestEffort"
+				}
+			}`))
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+	}
+
+	probes := 0
+	for _, update := range mockApi.Updates {
+		if strings.Contains(update.Opts.Name, "."+capabilityProbeLabel+".") {
+			probes++
+		}
+	}
+	if probes != 1 {
+		t.Errorf("expected a single probe, got %d", probes)
+	}
+	if len(mockApi.RecordSets) != 5 {
+		t.Errorf("expected only the 5 challenge recordsets to remain, got %v", mockApi.RecordSets)
+	}
+}
+
+func TestDesignateDnsResolver_CapabilityProbe_RemovesLeftoverProbe(t *testing.T) {
+	newProbeLabelOrig := newProbeLabel
+	t.Cleanup(func() { newProbeLabel = newProbeLabelOrig })
+	newProbeLabel = func() string { return "leftover" }
+
+	mockApi := mockresolver.CreateMockOpenstackApi(t)
+	mockApi.Zones = []mockresolver.MockZone{
+		{
+			ID:   "12345",
+			Name: "example.com.",
+		},
+	}
+	mockApi.RecordSets = []mockresolver.MockRecordSet{
+		{
+			ID:      "probe",
+			ZoneID:  "12345",
+			Name:    "leftover." + capabilityProbeLabel + ".example.com.",
+			Type:    "TXT",
+			Records: []string{`"capability probe"`},
+		},
+	}
+	mockApi.RejectDuplicateRecordSets = true
+	resolver := newTestResolver(t, mockApi)
+	WithCapabilityProbe(true)(resolver)
+
+	err := resolver.Present(newChallengeRequest("challenge", "cool.example.com", "example.com", `{
+		"secretName": "foo",
+		"secretNamespace": "bar",
+		"strategy": {
+			"kind": "BestEffort"
+		}
+	}`))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	// The leftover and the new probe are deleted, and only the challenge recordset remains.
+	if len(mockApi.RecordSetDeletes) != 2 {
+		t.Errorf("expected the leftover and the new probe to be deleted, got %v", mockApi.RecordSetDeletes)
+	}
+	if len(mockApi.RecordSets) != 1 || mockApi.RecordSets[0].Name != "cool.example.com." {
+		t.Errorf("expected only the challenge recordset to remain, got %v", mockApi.RecordSets)
+	}
+}
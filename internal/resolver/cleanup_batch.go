@@ -0,0 +1,99 @@
+package resolver
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cert-manager/cert-manager/pkg/acme/webhook/apis/acme/v1alpha1"
+)
+
+// WithCleanUpCoalescing holds every CleanUp for the given window so that further CleanUps for the
+// same name and issuer config arriving in the meantime are removed in the same recordset update or
+// delete. The window counts against the operation timeout and is cut short by a shutdown. A window of
+// zero or less cleans up every challenge on its own.
+func WithCleanUpCoalescing(window time.Duration) Option {
+	return func(d *designateDnsResolver) {
+		d.cleanUpWindow = window
+	}
+}
+
+// cleanUpBatch collects the keys of challenges cleaned up together. The outcome is shared by all of them.
+type cleanUpBatch struct {
+	keys    []string
+	done    chan struct{}
+	summary *cleanupSummary
+	err     error
+}
+
+type cleanUpBatches struct {
+	mu      sync.Mutex
+	pending map[string]*cleanUpBatch
+}
+
+// coalescedCleanUp removes the challenge key, batching it with other CleanUps within the coalescing
+// window. Only the CleanUp performing the batch records its phases with the timer.
+func (d *designateDnsResolver) coalescedCleanUp(ch *v1alpha1.ChallengeRequest, timer *phaseTimer) (*cleanupSummary, error) {
+	if d.cleanUpWindow <= 0 {
+		defer d.acquireChallengeSlot()()
+		ctx, cancel := d.operationContext()
+		defer cancel()
+		return d.cleanUp(ctx, ch, []string{ch.Key}, timer)
+	}
+
+	batchKey := cleanUpBatchKey(ch)
+
+	d.cleanUpBatches.mu.Lock()
+	if batch, ok := d.cleanUpBatches.pending[batchKey]; ok {
+		batch.keys = append(batch.keys, ch.Key)
+		d.cleanUpBatches.mu.Unlock()
+
+		<-batch.done
+		return batch.summary, batch.err
+	}
+
+	batch := &cleanUpBatch{keys: []string{ch.Key}, done: make(chan struct{})}
+	if d.cleanUpBatches.pending == nil {
+		d.cleanUpBatches.pending = make(map[string]*cleanUpBatch)
+	}
+	d.cleanUpBatches.pending[batchKey] = batch
+	d.cleanUpBatches.mu.Unlock()
+
+	// The window counts against the operation timeout, and a shutdown cuts it short so that the
+	// batch is cleaned up while the challenges in flight are drained.
+	ctx, cancel := d.operationContext()
+	defer cancel()
+	select {
+	case <-ctx.Done():
+	case <-d.stopCh:
+	case <-time.After(d.cleanUpWindow):
+	}
+	timer.phase("coalesce")
+
+	d.cleanUpBatches.mu.Lock()
+	delete(d.cleanUpBatches.pending, batchKey)
+	keys := batch.keys
+	d.cleanUpBatches.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		batch.err = err
+	} else {
+		func() {
+			defer d.acquireChallengeSlot()()
+			batch.summary, batch.err = d.cleanUp(ctx, ch, keys, timer)
+		}()
+	}
+	close(batch.done)
+
+	return batch.summary, batch.err
+}
+
+// cleanUpBatchKey identifies challenges that resolve to the same recordset through the same config.
+func cleanUpBatchKey(ch *v1alpha1.ChallengeRequest) string {
+	var config string
+	if ch.Config != nil {
+		config = string(ch.Config.Raw)
+	}
+
+	return strings.Join([]string{ch.ResourceNamespace, enforceTrailingDot(ch.ResolvedFQDN), ch.ResolvedZone, config}, "|")
+}
@@ -0,0 +1,159 @@
+package resolver
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	mockresolver "github.com/rikotsev/cert-manager-webhook-designate/internal/resolver/mock"
+)
+
+func TestDesignateDnsResolver_CleanUp_Coalescing(t *testing.T) {
+	tcs := []struct {
+		name            string
+		records         []string
+		expectedDeletes int
+		expectedPuts    int
+		expectedRecords []string
+	}{
+		{
+			name:            "removing all keys deletes the recordset once",
+			records:         []string{"\"first\"", "\"second\""},
+			expectedDeletes: 1,
+		},
+		{
+			name:            "removing some keys updates the recordset once",
+			records:         []string{"\"first\"", "\"other\"", "\"second\""},
+			expectedPuts:    1,
+			expectedRecords: []string{"\"other\""},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			mockApi := mockresolver.CreateMockOpenstackApi(t)
+			mockApi.Zones = []mockresolver.MockZone{
+				{
+					ID:   "12345",
+					Name: "example.com.",
+				},
+			}
+			mockApi.RecordSets = []mockresolver.MockRecordSet{
+				{
+					ID:      "rs-1",
+					ZoneID:  "12345",
+					Name:    "cool.example.com.",
+					Type:    "TXT",
+					Records: tc.records,
+				},
+			}
+			resolver := newTestResolver(t, mockApi)
+			WithCleanUpCoalescing(200 * time.Millisecond)(resolver)
+
+			config := `{
+				"secretName": "foo",
+				"secretNamespace": "bar",
+				"strategy": {
+					"kind": "SOA"
+				}
+			}`
+
+			var wg sync.WaitGroup
+			for _, key := range []string{"first", "second"} {
+				wg.Go(func() {
+					if err := resolver.CleanUp(newChallengeRequest(key, "cool.example.com", "example.com", config)); err != nil {
+						t.Errorf("expected no error cleaning up %s, got %v", key, err)
+					}
+				})
+			}
+			wg.Wait()
+
+			if len(mockApi.RecordSetDeletes) != tc.expectedDeletes {
+				t.Errorf("expected %d deletes, got %d", tc.expectedDeletes, len(mockApi.RecordSetDeletes))
+			}
+			if len(mockApi.RecordSetPuts) != tc.expectedPuts {
+				t.Fatalf("expected %d updates, got %d", tc.expectedPuts, len(mockApi.RecordSetPuts))
+			}
+			if tc.expectedPuts > 0 && !slices.Equal(mockApi.RecordSetPuts[0].Opts.Records, tc.expectedRecords) {
+				t.Errorf("expected records %v, got %v", tc.expectedRecords, mockApi.RecordSetPuts[0].Opts.Records)
+			}
+		})
+	}
+}
+
+func TestDesignateDnsResolver_CleanUp_CoalescingWindowInterrupted(t *testing.T) {
+	tcs := []struct {
+		name             string
+		operationTimeout time.Duration
+		stop             bool
+		expectedError    error
+		expectedDeletes  int
+	}{
+		{
+			name:             "shutdown cleans up right away",
+			operationTimeout: time.Minute,
+			stop:             true,
+			expectedDeletes:  1,
+		},
+		{
+			name:             "operation timeout ends the window",
+			operationTimeout: 100 * time.Millisecond,
+			expectedError:    context.DeadlineExceeded,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			mockApi := mockresolver.CreateMockOpenstackApi(t)
+			mockApi.Zones = []mockresolver.MockZone{
+				{
+					ID:   "12345",
+					Name: "example.com.",
+				},
+			}
+			mockApi.RecordSets = []mockresolver.MockRecordSet{
+				{
+					ID:      "rs-1",
+					ZoneID:  "12345",
+					Name:    "cool.example.com.",
+					Type:    "TXT",
+					Records: []string{"\"challenge\""},
+				},
+			}
+			resolver := newTestResolver(t, mockApi)
+			WithCleanUpCoalescing(time.Hour)(resolver)
+			WithOperationTimeout(tc.operationTimeout)(resolver)
+			stopCh := make(chan struct{})
+			resolver.stopCh = stopCh
+			if tc.stop {
+				time.AfterFunc(100*time.Millisecond, func() { close(stopCh) })
+			}
+
+			errs := make(chan error, 1)
+			go func() {
+				errs <- resolver.CleanUp(newChallengeRequest("challenge", "cool.example.com", "example.com", `{
+					"secretName": "foo",
+					"secretNamespace": "bar",
+					"strategy": {
+						"kind": "SOA"
+					}
+				}`))
+			}()
+
+			select {
+			case err := <-errs:
+				if !errors.Is(err, tc.expectedError) {
+					t.Errorf("expected error %v, got %v", tc.expectedError, err)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("expected the coalescing window to be cut short")
+			}
+			if len(mockApi.RecordSetDeletes) != tc.expectedDeletes {
+				t.Errorf("expected %d deletes, got %d", tc.expectedDeletes, len(mockApi.RecordSetDeletes))
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package resolver
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"github.com/gophercloud/gophercloud/v2"
+)
+
+// cloudIdentity derives a stable identity from everything that determines which cloud, project,
+// user and region the credentials resolve to. Caches are keyed by it, so that issuers pointing at
+// different clouds never share entries, even if a secret is repointed under the same name.
+// Secrets are left out; they do not change what the credentials can see.
+func (c *AuthConfig) cloudIdentity() string {
+	return hashIdentity(
+		"secret",
+		c.authOpts.IdentityEndpoint,
+		c.authOpts.DomainID,
+		c.authOpts.DomainName,
+		c.authOpts.TenantID,
+		c.authOpts.TenantName,
+		c.authOpts.UserID,
+		c.authOpts.Username,
+		c.authOpts.ApplicationCredentialID,
+		c.authOpts.ApplicationCredentialName,
+		c.endpointOpts.Region,
+		string(c.endpointOpts.Availability),
+		c.designateEndpoints,
+	)
+}
+
+// providerCloudIdentity is the identity of the cloud an injected provider client talks to.
+func providerCloudIdentity(providerClient *gophercloud.ProviderClient, endpointOpts gophercloud.EndpointOpts) string {
+	return hashIdentity(
+		"provider",
+		providerClient.IdentityEndpoint,
+		endpointOpts.Region,
+		string(endpointOpts.Availability),
+	)
+}
+
+func hashIdentity(parts ...string) string {
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(sum[:16])
+}
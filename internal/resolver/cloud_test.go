@@ -0,0 +1,111 @@
+package resolver
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gophercloud/gophercloud/v2"
+	mockresolver "github.com/rikotsev/cert-manager-webhook-designate/internal/resolver/mock"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestAuthConfig_CloudIdentity(t *testing.T) {
+	base := AuthConfig{
+		authOpts: gophercloud.AuthOptions{
+			IdentityEndpoint: "https://keystone.one.example.com",
+			TenantID:         "tenant",
+			Username:         "john-doe",
+			Password:         "secretpass",
+			DomainID:         "domain",
+		},
+		endpointOpts: gophercloud.EndpointOpts{Region: "RegionOne"},
+	}
+
+	otherPassword := base
+	otherPassword.authOpts.Password = "rotated"
+	if base.cloudIdentity() != otherPassword.cloudIdentity() {
+		t.Errorf("expected a rotated password to keep the cloud identity")
+	}
+
+	otherCloud := base
+	otherCloud.authOpts.IdentityEndpoint = "https://keystone.two.example.com"
+	if base.cloudIdentity() == otherCloud.cloudIdentity() {
+		t.Errorf("expected different identity endpoints to yield different cloud identities")
+	}
+
+	otherRegion := base
+	otherRegion.endpointOpts.Region = "RegionTwo"
+	if base.cloudIdentity() == otherRegion.cloudIdentity() {
+		t.Errorf("expected different regions to yield different cloud identities")
+	}
+}
+
+func TestDesignateDnsResolver_CloudScopedCaches(t *testing.T) {
+	clouds := map[string]*mockresolver.OpenstackApiMock{}
+	secretData := map[string]map[string]string{}
+	for name, zoneId := range map[string]string{"cloud-a": "aaaaa", "cloud-b": "bbbbb"} {
+		mockApi := mockresolver.CreateMockOpenstackApi(t)
+		mockApi.Zones = []mockresolver.MockZone{
+			{
+				ID:   zoneId,
+				Name: "example.com.",
+			},
+		}
+		server := httptest.NewServer(mockApi)
+		t.Cleanup(server.Close)
+
+		clouds[name] = mockApi
+		secretData[name] = map[string]string{
+			"tenantName":        "testTenant",
+			"tenantId":          "testTenantId",
+			"domainId":          "testDomainId",
+			"username":          "john-doe",
+			"password":          "secretpass",
+			"region":            "RegionOne",
+			"identityEndpoint":  server.URL,
+			"insecureAllowHTTP": "true",
+		}
+	}
+
+	resolver := new(designateDnsResolver)
+	resolver.configProvider = &authConfigProvider{
+		client: fake.NewClientset(
+			dummySecret("cloud-a", "bar", secretData["cloud-a"]),
+			dummySecret("cloud-b", "bar", secretData["cloud-b"]),
+		),
+	}
+	WithZoneCacheTTL(time.Minute)(resolver)
+
+	for _, round := range []string{"cold", "warm"} {
+		for _, secretName := range []string{"cloud-a", "cloud-b"} {
+			err := resolver.Present(newChallengeRequest("challenge-"+round, "cool.example.com", "example.com", `{
+				"secretName": "`+secretName+`",
+				"secretNamespace": "bar",
+				"strategy": {
+					"kind": "BestEffort"
+				}
+			}`))
+			if err != nil {
+				t.Fatalf("%s %s: expected no error, got %v", round, secretName, err)
+			}
+		}
+	}
+
+	for name, zoneId := range map[string]string{"cloud-a": "aaaaa", "cloud-b": "bbbbb"} {
+		mockApi := clouds[name]
+		if mockApi.ZoneListCalls != 1 {
+			t.Errorf("%s: expected the zones to be listed once, got %d", name, mockApi.ZoneListCalls)
+		}
+		if len(mockApi.Updates) != 1 || mockApi.Updates[0].ZoneID != zoneId {
+			t.Errorf("%s: expected the recordset to be created in zone %s, got %v", name, zoneId, mockApi.Updates)
+		}
+		if len(mockApi.RecordSetPuts) != 1 || mockApi.RecordSetPuts[0].RecordSetID != zoneId+"-created-1" {
+			t.Errorf("%s: expected the second challenge to update the recordset of this cloud, got %v", name, mockApi.RecordSetPuts)
+		}
+	}
+
+	if len(resolver.zoneCache.entries) != 2 {
+		t.Errorf("expected one zone cache entry per cloud, got %d", len(resolver.zoneCache.entries))
+	}
+}
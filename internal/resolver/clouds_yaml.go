@@ -0,0 +1,218 @@
+package resolver
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/gophercloud/gophercloud/v2"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// cloudsYAMLKey holds a clouds.yaml file, as used by the openstack CLI, in the credentials secret.
+	cloudsYAMLKey = "clouds.yaml"
+	// cloudNameKey selects the cloud of the clouds.yaml file. It may be left out when the file
+	// defines a single cloud.
+	cloudNameKey = "cloud"
+)
+
+var ErrInvalidCloudsYAML = errors.New("invalid clouds.yaml")
+var ErrUnknownCloud = errors.New("cloud not found in clouds.yaml")
+var ErrConflictingCloudsYAML = errors.New("key describes the cloud, which clouds.yaml already does")
+var ErrUnsupportedCloudsYAML = errors.New("unsupported clouds.yaml setting")
+
+// cloudsYAML is the subset of the clouds.yaml format the webhook understands, following the
+// field names of gophercloud's clientconfig. Other fields are rejected rather than ignored, as
+// ignoring them would authenticate differently than the openstack CLI.
+type cloudsYAML struct {
+	Clouds map[string]cloudsYAMLCloud `json:"clouds"`
+}
+
+type cloudsYAMLCloud struct {
+	Auth               cloudsYAMLAuth     `json:"auth"`
+	AuthType           string             `json:"auth_type"`
+	RegionName         string             `json:"region_name"`
+	Regions            []cloudsYAMLRegion `json:"regions"`
+	Interface          string             `json:"interface"`
+	IdentityAPIVersion string             `json:"identity_api_version"`
+	// CACert holds PEM encoded certificates. Unlike for the openstack CLI it is not a path, as the
+	// webhook does not read files named by a secret.
+	CACert string `json:"cacert"`
+	Verify *bool  `json:"verify"`
+}
+
+// cloudsYAMLRegion is an entry of the regions of a cloud, given either by name or as an object.
+type cloudsYAMLRegion struct {
+	Name string `json:"name"`
+}
+
+func (r *cloudsYAMLRegion) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &r.Name); err == nil {
+		return nil
+	}
+
+	type region cloudsYAMLRegion
+	return json.Unmarshal(data, (*region)(r))
+}
+
+type cloudsYAMLAuth struct {
+	AuthURL                     string `json:"auth_url"`
+	Username                    string `json:"username"`
+	UserID                      string `json:"user_id"`
+	Password                    string `json:"password"`
+	ProjectName                 string `json:"project_name"`
+	ProjectID                   string `json:"project_id"`
+	UserDomainName              string `json:"user_domain_name"`
+	UserDomainID                string `json:"user_domain_id"`
+	ProjectDomainName           string `json:"project_domain_name"`
+	ProjectDomainID             string `json:"project_domain_id"`
+	DomainName                  string `json:"domain_name"`
+	DomainID                    string `json:"domain_id"`
+	ApplicationCredentialID     string `json:"application_credential_id"`
+	ApplicationCredentialName   string `json:"application_credential_name"`
+	ApplicationCredentialSecret string `json:"application_credential_secret"`
+}
+
+// authConfigFromCloudsYAML builds the auth config from the named cloud of a clouds.yaml file. The
+// region may be overridden by the region key of the secret, which has to be one of the cloud's
+// regions if it lists them.
+func authConfigFromCloudsYAML(content []byte, cloudName, region string) (*AuthConfig, error) {
+	var file cloudsYAML
+	if err := yaml.UnmarshalStrict(content, &file); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidCloudsYAML, err)
+	}
+
+	if cloudName == "" && len(file.Clouds) == 1 {
+		cloudName = slices.Collect(maps.Keys(file.Clouds))[0]
+	}
+
+	cloud, ok := file.Clouds[cloudName]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownCloud, cloudName)
+	}
+
+	switch cloud.AuthType {
+	case "", "password", "v3password", "v3applicationcredential":
+	default:
+		return nil, fmt.Errorf("%w: auth_type %q", ErrUnsupportedCloudsYAML, cloud.AuthType)
+	}
+
+	if cloud.Verify != nil && !*cloud.Verify {
+		return nil, fmt.Errorf("%w: verify: false, set insecureSkipVerify in the solver config instead", ErrUnsupportedCloudsYAML)
+	}
+
+	if cloud.CACert != "" && !strings.HasPrefix(strings.TrimSpace(cloud.CACert), "-----BEGIN") {
+		return nil, fmt.Errorf("%w: cacert has to hold the PEM encoded certificates rather than a path", ErrUnsupportedCloudsYAML)
+	}
+
+	availability, err := parseAvailability(cloud.Interface)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidCloudsYAML, err)
+	}
+
+	version, err := parseIdentityAPIVersion(cloud.IdentityAPIVersion)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidCloudsYAML, err)
+	}
+
+	region, err = cloudsYAMLRegionName(cloud, region)
+	if err != nil {
+		return nil, err
+	}
+
+	auth := cloud.Auth
+	cfg := &AuthConfig{
+		authOpts: gophercloud.AuthOptions{
+			IdentityEndpoint:            auth.AuthURL,
+			Username:                    auth.Username,
+			UserID:                      auth.UserID,
+			Password:                    auth.Password,
+			TenantName:                  auth.ProjectName,
+			TenantID:                    auth.ProjectID,
+			ApplicationCredentialID:     auth.ApplicationCredentialID,
+			ApplicationCredentialName:   auth.ApplicationCredentialName,
+			ApplicationCredentialSecret: auth.ApplicationCredentialSecret,
+			AllowReauth:                 true,
+		},
+		endpointOpts: gophercloud.EndpointOpts{
+			Region:       region,
+			Availability: availability,
+		},
+		caCert:             []byte(cloud.CACert),
+		identityAPIVersion: version,
+	}
+
+	// The domain of the user and the one of the project may differ, domain_id and domain_name stand
+	// in for either. The ID always wins over the name.
+	userDomainID := cmp.Or(auth.UserDomainID, auth.DomainID)
+	userDomainName := cmp.Or(auth.UserDomainName, auth.DomainName)
+	projectDomainID := cmp.Or(auth.ProjectDomainID, auth.DomainID)
+	projectDomainName := cmp.Or(auth.ProjectDomainName, auth.DomainName)
+	if userDomainID != "" {
+		cfg.authOpts.DomainID = userDomainID
+	} else {
+		cfg.authOpts.DomainName = userDomainName
+	}
+
+	if cfg.authOpts.IdentityEndpoint == "" {
+		return nil, fmt.Errorf("%w: %s", ErrMissingAuthValue, "auth_url")
+	}
+
+	if cfg.endpointOpts.Region == "" {
+		return nil, fmt.Errorf("%w: %s", ErrMissingAuthValue, "region_name")
+	}
+
+	if cfg.authOpts.ApplicationCredentialID != "" || cfg.authOpts.ApplicationCredentialName != "" || cfg.authOpts.ApplicationCredentialSecret != "" {
+		if err := validateApplicationCredential(cfg.authOpts); err != nil {
+			return nil, err
+		}
+		return cfg, nil
+	}
+
+	if cfg.authOpts.Username == "" && cfg.authOpts.UserID == "" {
+		return nil, fmt.Errorf("%w: %s", ErrMissingAuthValue, "username")
+	}
+
+	if cfg.authOpts.Password == "" {
+		return nil, fmt.Errorf("%w: %s", ErrMissingAuthValue, "password")
+	}
+
+	// A project given by name is looked up in its own domain, not in the user's.
+	switch {
+	case auth.ProjectID != "":
+		cfg.authOpts.Scope = &gophercloud.AuthScope{ProjectID: auth.ProjectID}
+	case auth.ProjectName != "" && projectDomainID != "":
+		cfg.authOpts.Scope = &gophercloud.AuthScope{ProjectName: auth.ProjectName, DomainID: projectDomainID}
+	case auth.ProjectName != "" && projectDomainName != "":
+		cfg.authOpts.Scope = &gophercloud.AuthScope{ProjectName: auth.ProjectName, DomainName: projectDomainName}
+	}
+
+	return cfg, nil
+}
+
+// cloudsYAMLRegionName returns the region to use, the override if given or else region_name, or the
+// only one of the cloud's regions.
+func cloudsYAMLRegionName(cloud cloudsYAMLCloud, override string) (string, error) {
+	regions := make([]string, 0, len(cloud.Regions))
+	for _, region := range cloud.Regions {
+		regions = append(regions, region.Name)
+	}
+
+	if override != "" {
+		if len(regions) > 0 && !slices.Contains(regions, override) {
+			return "", fmt.Errorf("%w: region %q is not one of the cloud's regions %v", ErrInvalidCloudsYAML, override, regions)
+		}
+		return override, nil
+	}
+
+	if cloud.RegionName == "" && len(regions) == 1 {
+		return regions[0], nil
+	}
+
+	return cloud.RegionName, nil
+}
@@ -5,7 +5,12 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
 
+	"github.com/cert-manager/cert-manager/pkg/acme/webhook/apis/acme/v1alpha1"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 )
 
@@ -23,21 +28,219 @@ const (
 	// StrategyKindZoneName
 	// Forces always to use a particular zone name, regardless of everything else.
 	StrategyKindZoneName = "ZoneName"
+
+	// StrategyKindZoneID
+	// Uses the zone with the given ID without any name based lookup.
+	StrategyKindZoneID = "ZoneID"
+
+	// StrategyKindRegex
+	// Selects among the zones whose name matches a pattern the longest one containing the record.
+	StrategyKindRegex = "Regex"
+
+	// StrategyKindAuto
+	// Tries the SOA strategy first and falls back to BestEffort when no zone is named like the
+	// zone resolved by cert-manager.
+	StrategyKindAuto = "Auto"
 )
 
+const (
+	// WriteModeAuto creates the recordset when it is absent and updates it otherwise.
+	WriteModeAuto = "auto"
+	// WriteModeCreateOnly never updates an existing recordset, for credentials that may only create.
+	WriteModeCreateOnly = "create-only"
+	// WriteModeUpdateOnly never creates a recordset, for credentials that may only update.
+	WriteModeUpdateOnly = "update-only"
+)
+
+// strategyKinds are the canonical spellings of all supported strategy kinds.
+var strategyKinds = []string{StrategyKindSOA, StrategyKindBestEffort, StrategyKindZoneName, StrategyKindZoneID, StrategyKindRegex, StrategyKindAuto}
+
 var ErrCannotParse = errors.New("cannot parse the config")
 var ErrMissingRequiredField = errors.New("missing required field")
 var ErrInvalidStrategy = errors.New("unrecognized strategy")
+var ErrInvalidZoneName = errors.New("invalid zone name")
+
+// zoneLabelPattern matches a single label of a zone name: letters, digits, hyphens and the
+// underscores of service labels, neither starting nor ending with a hyphen.
+var zoneLabelPattern = regexp.MustCompile(`^[A-Za-z0-9_]([A-Za-z0-9_-]{0,61}[A-Za-z0-9_])?$`)
 
 type Strategy struct {
-	Kind     string  `json:"kind"`
-	ZoneName *string `json:"zoneName,omitempty"`
+	Kind string `json:"kind"`
+	// ZoneName holds the zones of the ZoneName strategy. Of several, the one containing the record
+	// is used.
+	ZoneName ZoneNames `json:"zoneName,omitempty"`
+	// ZoneNameFallback makes the ZoneName strategy fall back to the closest parent zone of ZoneName
+	// when no zone with exactly that name exists.
+	ZoneNameFallback bool `json:"zoneNameFallback,omitempty"`
+	// WalkLabels makes the BestEffort match look up the record name and its parents by name, most
+	// specific first, instead of listing every zone.
+	WalkLabels bool `json:"walkLabels,omitempty"`
+	// ZoneID is the designate ID of the zone used by the ZoneID strategy.
+	ZoneID *string `json:"zoneId,omitempty"`
+	// ZonePattern is the regular expression zone names are matched against by the Regex strategy.
+	ZonePattern *string `json:"zonePattern,omitempty"`
+	// zoneRegexp is ZonePattern compiled by ParseConfig.
+	zoneRegexp *regexp.Regexp
+}
+
+// ZoneNames is a list of zone names that is also accepted, and encoded, as a single string.
+type ZoneNames []string
+
+func (z *ZoneNames) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*z = ZoneNames{single}
+		return nil
+	}
+
+	var names []string
+	if err := json.Unmarshal(data, &names); err != nil {
+		return err
+	}
+	*z = names
+	return nil
+}
+
+func (z ZoneNames) MarshalJSON() ([]byte, error) {
+	if len(z) == 1 {
+		return json.Marshal(z[0])
+	}
+
+	return json.Marshal([]string(z))
+}
+
+// zoneNameFor returns the zone name containing the record name, the longest if several do.
+func (s *Strategy) zoneNameFor(recordName string) (string, bool) {
+	var matched string
+	for _, zoneName := range s.ZoneName {
+		if isWithinZone(recordName, zoneName) && len(enforceTrailingDot(zoneName)) > len(enforceTrailingDot(matched)) {
+			matched = zoneName
+		}
+	}
+
+	return matched, matched != ""
 }
 
 type ChallengeConfig struct {
-	SecretName      string    `json:"secretName"`
-	SecretNamespace string    `json:"secretNamespace"`
+	// SecretName and SecretNamespace reference the credentials secret. Without them the challenge
+	// falls back to ambient credentials from the environment, if the issuer allows it.
+	SecretName      string    `json:"secretName,omitempty"`
+	SecretNamespace string    `json:"secretNamespace,omitempty"`
 	Strategy        *Strategy `json:"strategy,omitempty"`
+	// OwnershipMarker stores an additional TXT value identifying this webhook as the owner of the
+	// recordset. Unlike a description it survives updates on every cloud.
+	OwnershipMarker bool `json:"ownershipMarker,omitempty"`
+	// WaitForPropagation makes Present wait until designate reports the written recordset as
+	// ACTIVE with no pending action.
+	WaitForPropagation bool `json:"waitForPropagation,omitempty"`
+	// PropagationTimeout bounds the wait of WaitForPropagation, as a duration such as "90s". Defaults
+	// to recordSetWaitTimeout.
+	PropagationTimeout string `json:"propagationTimeout,omitempty"`
+	// propagationTimeout is PropagationTimeout parsed by ParseConfig.
+	propagationTimeout time.Duration
+	// SelfCheckNameservers are queried for the challenge after it is written, as host or host:port,
+	// and Present only returns once all of them serve it, bounded by PropagationTimeout.
+	SelfCheckNameservers []string `json:"selfCheckNameservers,omitempty"`
+	// selfCheckNameservers is SelfCheckNameservers as host:port, normalized by ParseConfig.
+	selfCheckNameservers []string
+	// HTTPTimeout bounds every HTTP request to keystone and designate, as a duration such as "10s",
+	// so that a stuck connection cannot hang the challenge. Defaults to defaultHTTPTimeout.
+	HTTPTimeout string `json:"httpTimeout,omitempty"`
+	// httpTimeout is HTTPTimeout parsed by ParseConfig.
+	httpTimeout time.Duration
+	// ZoneScope restricts the zones matched by name to those of the authenticated project with
+	// ZoneScopeProject. Defaults to ZoneScopeAll.
+	ZoneScope string `json:"zoneScope,omitempty"`
+	// FailOnAmbiguousZone fails challenges for which several zones of the looked up name are
+	// listed, e.g. of other projects, instead of picking the first one.
+	FailOnAmbiguousZone bool `json:"failOnAmbiguousZone,omitempty"`
+	// Preflight checks, before writing, that the selected zone is ACTIVE and PRIMARY, that it
+	// contains the record name and that the credentials may write to it, see preflight.
+	Preflight bool `json:"preflight,omitempty"`
+	// RecordNameOverride is the exact name of the TXT recordset to manage instead of the challenge's
+	// resolved FQDN, e.g. for delegated names that do not carry the _acme-challenge label.
+	RecordNameOverride string `json:"recordNameOverride,omitempty"`
+	// recordNameFunc replaces the resolved FQDN as the record name, see WithRecordNameFunc.
+	recordNameFunc func(ch *v1alpha1.ChallengeRequest) string
+	// RecordTrailingDot controls whether the record name is sent fully qualified, i.e. with a
+	// trailing dot. Defaults to true. Zone queries always carry the trailing dot.
+	RecordTrailingDot *bool `json:"recordTrailingDot,omitempty"`
+	// BaseRecords are TXT values that always have to exist next to the challenge, e.g. a
+	// verification token. Present ensures them and CleanUp never removes them.
+	BaseRecords []string `json:"baseRecords,omitempty"`
+	// WriteMode restricts Present to creating or updating the recordset. Defaults to WriteModeAuto.
+	WriteMode string `json:"writeMode,omitempty"`
+	// MergeDuplicateRecordSets consolidates duplicate recordsets of the challenge, e.g. created by a
+	// race, into the oldest one before touching them.
+	MergeDuplicateRecordSets bool `json:"mergeDuplicateRecordSets,omitempty"`
+	// Region overrides the region of the credentials, so that issuers sharing a secret can target
+	// different regions.
+	Region string `json:"region,omitempty"`
+	// InsecureSkipVerify disables TLS certificate verification towards keystone and designate. Only
+	// meant for test clouds with self-signed certificates.
+	InsecureSkipVerify bool `json:"insecureSkipVerify,omitempty"`
+	// ZoneView selects the view of split-horizon zones, for deployments that serve several zones of
+	// the same name and pick one by the zoneViewHeader.
+	ZoneView string `json:"zoneView,omitempty"`
+	// AllProjects sends the allProjectsHeader with every request, so that credentials of a central
+	// DNS project with the matching policy find and write the zones of all projects.
+	AllProjects bool `json:"allProjects,omitempty"`
+	// RecordSetDescription is set as the description of the recordsets Present writes, for tracing
+	// them back to the issuer. It is left out on clouds whose DNS API predates v2.1.
+	RecordSetDescription string `json:"recordSetDescription,omitempty"`
+	// SafeCleanup makes CleanUp keep recordsets it would delete unless they are marked as managed by
+	// the webhook, by their description or the ownership marker.
+	SafeCleanup bool `json:"safeCleanup,omitempty"`
+}
+
+// hasSecret reports whether the config references a credentials secret.
+func (c *ChallengeConfig) hasSecret() bool {
+	return c.SecretName != "" && c.SecretNamespace != ""
+}
+
+// waitTimeout returns how long to wait for a written recordset to settle.
+func (c *ChallengeConfig) waitTimeout() time.Duration {
+	if c.propagationTimeout > 0 {
+		return c.propagationTimeout
+	}
+
+	return recordSetWaitTimeout
+}
+
+// requestTimeout returns the timeout of every HTTP request of the challenge.
+func (c *ChallengeConfig) requestTimeout() time.Duration {
+	if c.httpTimeout > 0 {
+		return c.httpTimeout
+	}
+
+	return defaultHTTPTimeout
+}
+
+// isBaseRecord reports whether rec is one of the configured base records.
+func (c *ChallengeConfig) isBaseRecord(rec string) bool {
+	return slices.ContainsFunc(c.BaseRecords, func(base string) bool { return sameRecordValue(rec, base) })
+}
+
+// recordName returns the name of the TXT recordset holding the challenge, fully qualified unless
+// RecordTrailingDot is turned off.
+func (c *ChallengeConfig) recordName(ch *v1alpha1.ChallengeRequest) string {
+	name := ch.ResolvedFQDN
+	if c.recordNameFunc != nil {
+		name = c.recordNameFunc(ch)
+	}
+	if c.RecordNameOverride != "" {
+		name = c.RecordNameOverride
+	}
+
+	if c.RecordTrailingDot != nil && !*c.RecordTrailingDot {
+		return strings.TrimSuffix(name, ".")
+	}
+
+	return enforceTrailingDot(name)
 }
 
 func ParseConfig(input *apiextensionsv1.JSON) (*ChallengeConfig, error) {
@@ -48,27 +251,110 @@ func ParseConfig(input *apiextensionsv1.JSON) (*ChallengeConfig, error) {
 		return nil, fmt.Errorf("%w: %v", ErrCannotParse, err)
 	}
 
-	if result.SecretName == "" {
+	if err := ValidateConfig(input.Raw); err != nil {
+		return nil, err
+	}
+
+	// Both may be left out to use ambient credentials, but one is useless without the other.
+	if result.SecretName == "" && result.SecretNamespace != "" {
 		return nil, fmt.Errorf("%w: %s", ErrMissingRequiredField, "secretName")
 	}
 
-	if result.SecretNamespace == "" {
+	if result.SecretNamespace == "" && result.SecretName != "" {
 		return nil, fmt.Errorf("%w: %s", ErrMissingRequiredField, "secretNamespace")
 	}
 
+	if result.PropagationTimeout != "" {
+		result.propagationTimeout, err = time.ParseDuration(result.PropagationTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("%w: propagationTimeout: %v", ErrCannotParse, err)
+		}
+		if result.propagationTimeout <= 0 {
+			return nil, fmt.Errorf("%w: propagationTimeout: must be positive, got %s", ErrCannotParse, result.PropagationTimeout)
+		}
+	}
+
+	for _, nameserver := range result.SelfCheckNameservers {
+		normalized, err := normalizeNameserver(nameserver)
+		if err != nil {
+			return nil, fmt.Errorf("%w: selfCheckNameservers: %v", ErrCannotParse, err)
+		}
+		result.selfCheckNameservers = append(result.selfCheckNameservers, normalized)
+	}
+
+	if result.HTTPTimeout != "" {
+		result.httpTimeout, err = time.ParseDuration(result.HTTPTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("%w: httpTimeout: %v", ErrCannotParse, err)
+		}
+		if result.httpTimeout <= 0 {
+			return nil, fmt.Errorf("%w: httpTimeout: must be positive, got %s", ErrCannotParse, result.HTTPTimeout)
+		}
+	}
+
+	// cert-manager already resolves the zone of the challenge, so SOA is the natural default.
 	if result.Strategy == nil {
-		return nil, fmt.Errorf("%w: %s", ErrMissingRequiredField, "strategy")
+		result.Strategy = &Strategy{Kind: StrategyKindSOA}
 	}
 
-	if result.Strategy.Kind != StrategyKindSOA &&
-		result.Strategy.Kind != StrategyKindBestEffort &&
-		result.Strategy.Kind != StrategyKindZoneName {
+	kind, ok := canonicalStrategyKind(result.Strategy.Kind)
+	if !ok {
 		return nil, fmt.Errorf("%w: %s", ErrInvalidStrategy, "strategy")
 	}
+	result.Strategy.Kind = kind
 
-	if result.Strategy.Kind == StrategyKindZoneName && result.Strategy.ZoneName == nil {
+	if result.Strategy.Kind == StrategyKindZoneName && len(result.Strategy.ZoneName) == 0 {
 		return nil, fmt.Errorf("%w: %s", ErrMissingRequiredField, "strategy.zoneName")
 	}
 
+	for i, zoneName := range result.Strategy.ZoneName {
+		if err := validateZoneName(zoneName); err != nil {
+			return nil, fmt.Errorf("%w: strategy.zoneName: %w", ErrInvalidStrategy, err)
+		}
+		result.Strategy.ZoneName[i] = enforceTrailingDot(zoneName)
+	}
+
+	if result.Strategy.Kind == StrategyKindZoneID && result.Strategy.ZoneID == nil {
+		return nil, fmt.Errorf("%w: %s", ErrMissingRequiredField, "strategy.zoneId")
+	}
+
+	if result.Strategy.Kind == StrategyKindRegex {
+		if result.Strategy.ZonePattern == nil {
+			return nil, fmt.Errorf("%w: %s", ErrMissingRequiredField, "strategy.zonePattern")
+		}
+
+		result.Strategy.zoneRegexp, err = regexp.Compile(*result.Strategy.ZonePattern)
+		if err != nil {
+			return nil, fmt.Errorf("%w: strategy.zonePattern: %v", ErrInvalidStrategy, err)
+		}
+	}
+
 	return result, nil
 }
+
+// validateZoneName checks that the name is a syntactically valid DNS name, optionally fully qualified.
+func validateZoneName(name string) error {
+	trimmed := strings.TrimSuffix(name, ".")
+	if trimmed == "" || len(trimmed) > 253 {
+		return fmt.Errorf("%w: %q must have between 1 and 253 characters", ErrInvalidZoneName, name)
+	}
+
+	for _, label := range strings.Split(trimmed, ".") {
+		if !zoneLabelPattern.MatchString(label) {
+			return fmt.Errorf("%w: %q has the invalid label %q", ErrInvalidZoneName, name, label)
+		}
+	}
+
+	return nil
+}
+
+// canonicalStrategyKind matches the kind case-insensitively and returns its canonical spelling.
+func canonicalStrategyKind(kind string) (string, bool) {
+	for _, known := range strategyKinds {
+		if strings.EqualFold(kind, known) {
+			return known, true
+		}
+	}
+
+	return "", false
+}
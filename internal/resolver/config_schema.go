@@ -0,0 +1,97 @@
+package resolver
+
+import (
+	"bytes"
+	_ "embed"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"github.com/santhosh-tekuri/jsonschema/v6/kind"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+var ErrInvalidConfig = errors.New("invalid config")
+
+//go:embed config.schema.json
+var configSchemaSource []byte
+
+var configSchema = mustCompileConfigSchema()
+
+func mustCompileConfigSchema() *jsonschema.Schema {
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(configSchemaSource))
+	if err != nil {
+		panic(fmt.Sprintf("malformed embedded config schema: %v", err))
+	}
+
+	compiler := jsonschema.NewCompiler()
+	if err := compiler.AddResource("config.schema.json", doc); err != nil {
+		panic(fmt.Sprintf("malformed embedded config schema: %v", err))
+	}
+
+	return compiler.MustCompile("config.schema.json")
+}
+
+// ValidateConfig checks a raw challenge config against the embedded JSON schema. Every violation is
+// reported with the path of the offending field, e.g. "strategy.zoneName is required". Missing fields
+// wrap ErrMissingRequiredField, everything else wraps ErrInvalidConfig.
+func ValidateConfig(raw []byte) error {
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(raw))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrCannotParse, err)
+	}
+
+	err = configSchema.Validate(doc)
+	if err == nil {
+		return nil
+	}
+
+	var validationErr *jsonschema.ValidationError
+	if !errors.As(err, &validationErr) {
+		return fmt.Errorf("%w: %v", ErrInvalidConfig, err)
+	}
+
+	return errors.Join(schemaViolations(validationErr)...)
+}
+
+// schemaViolations flattens the validation error tree into one error per violated keyword.
+func schemaViolations(validationErr *jsonschema.ValidationError) []error {
+	if len(validationErr.Causes) > 0 {
+		var result []error
+		for _, cause := range validationErr.Causes {
+			result = append(result, schemaViolations(cause)...)
+		}
+		return result
+	}
+
+	location := strings.Join(validationErr.InstanceLocation, ".")
+
+	var missingFields []string
+	switch required := validationErr.ErrorKind.(type) {
+	case *kind.Required:
+		missingFields = required.Missing
+	case *kind.DependentRequired:
+		missingFields = required.Missing
+	}
+
+	if missingFields != nil {
+		result := make([]error, 0, len(missingFields))
+		for _, missing := range missingFields {
+			field := missing
+			if location != "" {
+				field = location + "." + missing
+			}
+			result = append(result, fmt.Errorf("%w: %s is required", ErrMissingRequiredField, field))
+		}
+		return result
+	}
+
+	if location == "" {
+		location = "config"
+	}
+	printer := message.NewPrinter(language.English)
+
+	return []error{fmt.Errorf("%w: %s: %s", ErrInvalidConfig, location, validationErr.ErrorKind.LocalizedString(printer))}
+}
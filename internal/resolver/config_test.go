@@ -1,12 +1,16 @@
 package resolver
 
 import (
+	"encoding/json"
 	"errors"
+	"slices"
+	"strings"
 
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	"k8s.io/utils/ptr"
 
 	"testing"
+	"time"
 )
 
 func TestParseConfig(t *testing.T) {
@@ -66,7 +70,136 @@ func TestParseConfig(t *testing.T) {
 			expectedConfig: &ChallengeConfig{
 				Strategy: &Strategy{
 					Kind:     StrategyKindZoneName,
-					ZoneName: ptr.To("example.com."),
+					ZoneName: ZoneNames{"example.com."},
+				},
+				SecretName:      "foo",
+				SecretNamespace: "bar",
+			},
+			expectedError: nil,
+		},
+		{
+			name: "parseable config with a list of zone names",
+			input: `{
+				"strategy":{
+					"kind":"ZoneName",
+					"zoneName":["example.com.","example.org.","example.net."]
+				},
+				"secretName":"foo",
+				"secretNamespace":"bar"
+			}`,
+			expectedConfig: &ChallengeConfig{
+				Strategy: &Strategy{
+					Kind:     StrategyKindZoneName,
+					ZoneName: ZoneNames{"example.com.", "example.org.", "example.net."},
+				},
+				SecretName:      "foo",
+				SecretNamespace: "bar",
+			},
+			expectedError: nil,
+		},
+		{
+			name: "parseable config with ZoneID strategy",
+			input: `{
+				"strategy":{
+					"kind":"zoneid",
+					"zoneId":"a86dba58-0043-4cc6-a1bb-69d5e86f3ca3"
+				},
+				"secretName":"foo",
+				"secretNamespace":"bar"
+			}`,
+			expectedConfig: &ChallengeConfig{
+				Strategy: &Strategy{
+					Kind:   StrategyKindZoneID,
+					ZoneID: ptr.To("a86dba58-0043-4cc6-a1bb-69d5e86f3ca3"),
+				},
+				SecretName:      "foo",
+				SecretNamespace: "bar",
+			},
+			expectedError: nil,
+		},
+		{
+			name: "parseable config with Regex strategy",
+			input: `{
+				"strategy":{
+					"kind":"Regex",
+					"zonePattern":"^.*\\.staging\\.example\\.com\\.$"
+				},
+				"secretName":"foo",
+				"secretNamespace":"bar"
+			}`,
+			expectedConfig: &ChallengeConfig{
+				Strategy: &Strategy{
+					Kind:        StrategyKindRegex,
+					ZonePattern: ptr.To(`^.*\.staging\.example\.com\.$`),
+				},
+				SecretName:      "foo",
+				SecretNamespace: "bar",
+			},
+			expectedError: nil,
+		},
+		{
+			name: "Regex strategy with an invalid pattern",
+			input: `{
+				"strategy":{
+					"kind":"Regex",
+					"zonePattern":"(example"
+				},
+				"secretName":"foo",
+				"secretNamespace":"bar"
+			}`,
+			expectedConfig: nil,
+			expectedError:  ErrInvalidStrategy,
+		},
+		{
+			name: "lowercase SOA strategy",
+			input: `{
+				"strategy":{
+					"kind":"soa"
+				},
+				"secretName":"foo",
+				"secretNamespace":"bar"
+			}`,
+			expectedConfig: &ChallengeConfig{
+				Strategy: &Strategy{
+					Kind: StrategyKindSOA,
+				},
+				SecretName:      "foo",
+				SecretNamespace: "bar",
+			},
+			expectedError: nil,
+		},
+		{
+			name: "lowercase BestEffort strategy",
+			input: `{
+				"strategy":{
+					"kind":"besteffort"
+				},
+				"secretName":"foo",
+				"secretNamespace":"bar"
+			}`,
+			expectedConfig: &ChallengeConfig{
+				Strategy: &Strategy{
+					Kind: StrategyKindBestEffort,
+				},
+				SecretName:      "foo",
+				SecretNamespace: "bar",
+			},
+			expectedError: nil,
+		},
+		{
+			name: "mixed case ZoneName strategy",
+			input: `{
+				"strategy":{
+					"kind":"zoneNAME",
+					"zoneName":"example.com."
+				},
+				"secretName":"foo",
+				"secretNamespace":"bar"
+			}`,
+			expectedConfig: &ChallengeConfig{
+				Strategy: &Strategy{
+					Kind:     StrategyKindZoneName,
+					ZoneName: ZoneNames{"example.com."},
 				},
 				SecretName:      "foo",
 				SecretNamespace: "bar",
@@ -80,8 +213,23 @@ func TestParseConfig(t *testing.T) {
 			expectedError:  ErrCannotParse,
 		},
 		{
-			name: "missing strategy",
+			name: "missing strategy defaults to SOA",
+			input: `{
+				"secretName": "foo",
+				"secretNamespace": "bar"
+			}`,
+			expectedConfig: &ChallengeConfig{
+				Strategy: &Strategy{
+					Kind: StrategyKindSOA,
+				},
+				SecretName:      "foo",
+				SecretNamespace: "bar",
+			},
+		},
+		{
+			name: "empty strategy still requires a kind",
 			input: `{
+				"strategy": {},
 				"secretName": "foo",
 				"secretNamespace": "bar"
 			}`,
@@ -134,6 +282,50 @@ func TestParseConfig(t *testing.T) {
 			expectedConfig: nil,
 			expectedError:  ErrInvalidStrategy,
 		},
+		{
+			name: "parseable config with propagationTimeout",
+			input: `{
+				"strategy": {
+					"kind": "SOA"
+				},
+				"secretName": "foo",
+				"secretNamespace": "bar",
+				"waitForPropagation": true,
+				"propagationTimeout": "1m30s"
+			}`,
+			expectedConfig: &ChallengeConfig{
+				Strategy: &Strategy{
+					Kind: StrategyKindSOA,
+				},
+				SecretName:         "foo",
+				SecretNamespace:    "bar",
+				propagationTimeout: 90 * time.Second,
+			},
+		},
+		{
+			name: "zero propagationTimeout",
+			input: `{
+				"strategy": {
+					"kind": "SOA"
+				},
+				"secretName": "foo",
+				"secretNamespace": "bar",
+				"propagationTimeout": "0s"
+			}`,
+			expectedError: ErrCannotParse,
+		},
+		{
+			name: "invalid strategy close to a known one",
+			input: `{
+				"strategy": {
+					"kind": "best-effort"
+				},
+				"secretName": "foo",
+				"secretNamespace": "bar"
+			}`,
+			expectedConfig: nil,
+			expectedError:  ErrInvalidStrategy,
+		},
 	}
 
 	for _, tc := range tcs {
@@ -166,15 +358,308 @@ func TestParseConfig(t *testing.T) {
 				t.Errorf("expected secretNamespace %v but got %v", tc.expectedConfig.SecretNamespace, config.SecretNamespace)
 			}
 
+			if tc.expectedConfig.propagationTimeout != config.propagationTimeout {
+				t.Errorf("expected propagationTimeout %v but got %v", tc.expectedConfig.propagationTimeout, config.propagationTimeout)
+			}
+
 			if tc.expectedConfig.Strategy.Kind != config.Strategy.Kind {
 				t.Errorf("expected strategy kind %v but got %v", tc.expectedConfig.Strategy.Kind, config.Strategy.Kind)
 			}
 
+			if tc.expectedConfig.Strategy.Kind == StrategyKindRegex &&
+				(*tc.expectedConfig.Strategy.ZonePattern != *config.Strategy.ZonePattern || config.Strategy.zoneRegexp.String() != *config.Strategy.ZonePattern) {
+				t.Errorf("expected compiled zonePattern %v but got %v", *tc.expectedConfig.Strategy.ZonePattern, config.Strategy.zoneRegexp)
+			}
+
 			if tc.expectedConfig.Strategy.Kind == StrategyKindZoneName &&
-				*tc.expectedConfig.Strategy.ZoneName != *config.Strategy.ZoneName {
+				!slices.Equal(tc.expectedConfig.Strategy.ZoneName, config.Strategy.ZoneName) {
 				t.Errorf("expected zoneName %v but got %v", tc.expectedConfig.Strategy.ZoneName, config.Strategy.ZoneName)
 			}
+
+			if tc.expectedConfig.Strategy.Kind == StrategyKindZoneID &&
+				*tc.expectedConfig.Strategy.ZoneID != *config.Strategy.ZoneID {
+				t.Errorf("expected zoneId %v but got %v", tc.expectedConfig.Strategy.ZoneID, config.Strategy.ZoneID)
+			}
 		})
 	}
 
 }
+
+func TestParseConfig_ZoneName(t *testing.T) {
+	tcs := []struct {
+		name          string
+		zoneName      string
+		expectedNames ZoneNames
+		expectedError error
+	}{
+		{
+			name:          "normalized to a trailing dot",
+			zoneName:      `"example.com"`,
+			expectedNames: ZoneNames{"example.com."},
+		},
+		{
+			name:          "list with service labels",
+			zoneName:      `["Example.com.", "_acme.example-2.org"]`,
+			expectedNames: ZoneNames{"Example.com.", "_acme.example-2.org."},
+		},
+		{
+			name:          "empty label",
+			zoneName:      `"exmaple..com"`,
+			expectedError: ErrInvalidZoneName,
+		},
+		{
+			name:          "surrounding whitespace",
+			zoneName:      `" example.com."`,
+			expectedError: ErrInvalidZoneName,
+		},
+		{
+			name:          "whitespace within a label",
+			zoneName:      `"example .com"`,
+			expectedError: ErrInvalidZoneName,
+		},
+		{
+			name:          "label starting with a hyphen",
+			zoneName:      `"-example.com"`,
+			expectedError: ErrInvalidZoneName,
+		},
+		{
+			name:          "several trailing dots",
+			zoneName:      `"example.com.."`,
+			expectedError: ErrInvalidZoneName,
+		},
+		{
+			name:          "invalid character",
+			zoneName:      `"exa$mple.com"`,
+			expectedError: ErrInvalidZoneName,
+		},
+		{
+			name:          "root zone",
+			zoneName:      `"."`,
+			expectedError: ErrInvalidZoneName,
+		},
+		{
+			name:          "label too long",
+			zoneName:      `"` + strings.Repeat("a", 64) + `.com"`,
+			expectedError: ErrInvalidZoneName,
+		},
+		{
+			name:          "one invalid name of a list",
+			zoneName:      `["example.com.", "example..org."]`,
+			expectedError: ErrInvalidZoneName,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			config, err := ParseConfig(&apiextensionsv1.JSON{Raw: []byte(`{
+				"secretName": "foo",
+				"secretNamespace": "bar",
+				"strategy": {"kind": "ZoneName", "zoneName": ` + tc.zoneName + `}
+			}`)})
+			if tc.expectedError != nil {
+				if !errors.Is(err, tc.expectedError) || !errors.Is(err, ErrInvalidStrategy) {
+					t.Errorf("expected errors %v and %v, got %v", tc.expectedError, ErrInvalidStrategy, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if !slices.Equal(config.Strategy.ZoneName, tc.expectedNames) {
+				t.Errorf("expected zoneName %v but got %v", tc.expectedNames, config.Strategy.ZoneName)
+			}
+		})
+	}
+}
+
+func TestValidateConfig(t *testing.T) {
+	tcs := []struct {
+		name             string
+		input            string
+		expectedError    error
+		expectedMessages []string
+	}{
+		{
+			name: "valid config",
+			input: `{
+				"secretName": "foo",
+				"secretNamespace": "bar",
+				"strategy": {
+					"kind": "ZoneName",
+					"zoneName": "example.com."
+				}
+			}`,
+		},
+		{
+			name: "missing zoneName for ZoneName strategy",
+			input: `{
+				"secretName": "foo",
+				"secretNamespace": "bar",
+				"strategy": {
+					"kind": "zonename"
+				}
+			}`,
+			expectedError:    ErrMissingRequiredField,
+			expectedMessages: []string{"strategy.zoneName is required"},
+		},
+		{
+			name: "empty list of zone names",
+			input: `{
+				"secretName": "foo",
+				"secretNamespace": "bar",
+				"strategy": {
+					"kind": "ZoneName",
+					"zoneName": []
+				}
+			}`,
+			expectedError:    ErrInvalidConfig,
+			expectedMessages: []string{"strategy.zoneName:"},
+		},
+		{
+			name: "missing zonePattern for Regex strategy",
+			input: `{
+				"secretName": "foo",
+				"secretNamespace": "bar",
+				"strategy": {
+					"kind": "regex"
+				}
+			}`,
+			expectedError:    ErrMissingRequiredField,
+			expectedMessages: []string{"strategy.zonePattern is required"},
+		},
+		{
+			name: "missing zoneId for ZoneID strategy",
+			input: `{
+				"secretName": "foo",
+				"secretNamespace": "bar",
+				"strategy": {
+					"kind": "ZoneID"
+				}
+			}`,
+			expectedError:    ErrMissingRequiredField,
+			expectedMessages: []string{"strategy.zoneId is required"},
+		},
+		{
+			name: "no secret for ambient credentials",
+			input: `{
+				"strategy": {
+					"kind": "SOA"
+				}
+			}`,
+		},
+		{
+			name: "secretNamespace without secretName",
+			input: `{
+				"secretNamespace": "bar",
+				"strategy": {
+					"kind": "SOA"
+				}
+			}`,
+			expectedError:    ErrMissingRequiredField,
+			expectedMessages: []string{"secretName is required"},
+		},
+		{
+			name: "wrong type for strategy kind",
+			input: `{
+				"secretName": "foo",
+				"secretNamespace": "bar",
+				"strategy": {
+					"kind": 1
+				}
+			}`,
+			expectedError:    ErrInvalidConfig,
+			expectedMessages: []string{"strategy.kind:"},
+		},
+		{
+			name: "strategy is not an object",
+			input: `{
+				"secretName": "foo",
+				"secretNamespace": "bar",
+				"strategy": "SOA"
+			}`,
+			expectedError:    ErrInvalidConfig,
+			expectedMessages: []string{"strategy:"},
+		},
+		{
+			name: "empty secretName",
+			input: `{
+				"secretName": "",
+				"secretNamespace": "bar",
+				"strategy": {
+					"kind": "SOA"
+				}
+			}`,
+			expectedError:    ErrInvalidConfig,
+			expectedMessages: []string{"secretName:"},
+		},
+		{
+			name: "empty recordNameOverride",
+			input: `{
+				"secretName": "foo",
+				"secretNamespace": "bar",
+				"recordNameOverride": "",
+				"strategy": {
+					"kind": "SOA"
+				}
+			}`,
+			expectedError:    ErrInvalidConfig,
+			expectedMessages: []string{"recordNameOverride:"},
+		},
+		{
+			name: "unknown writeMode",
+			input: `{
+				"secretName": "foo",
+				"secretNamespace": "bar",
+				"writeMode": "upsert",
+				"strategy": {
+					"kind": "SOA"
+				}
+			}`,
+			expectedError:    ErrInvalidConfig,
+			expectedMessages: []string{"writeMode:"},
+		},
+		{
+			name:          "unparseable config",
+			input:         "{",
+			expectedError: ErrCannotParse,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidateConfig([]byte(tc.input))
+			if tc.expectedError == nil {
+				if err != nil {
+					t.Errorf("expected no error, got %v", err)
+				}
+				return
+			}
+
+			if !errors.Is(err, tc.expectedError) {
+				t.Errorf("expected error %v, got %v", tc.expectedError, err)
+				return
+			}
+
+			for _, message := range tc.expectedMessages {
+				if !strings.Contains(err.Error(), message) {
+					t.Errorf("expected error to contain %q, got %q", message, err.Error())
+				}
+			}
+		})
+	}
+}
+
+func TestZoneNames_JSON(t *testing.T) {
+	for _, encoded := range []string{`"example.com."`, `["example.com.","example.org."]`} {
+		var names ZoneNames
+		if err := json.Unmarshal([]byte(encoded), &names); err != nil {
+			t.Fatalf("expected no error decoding %s, got %v", encoded, err)
+		}
+
+		actual, err := json.Marshal(names)
+		if err != nil || string(actual) != encoded {
+			t.Errorf("expected %s to be encoded as is, got %s, %v", encoded, actual, err)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package resolver
+
+import "sync"
+
+const (
+	DecisionOperationPresent = "present"
+	DecisionOperationCleanUp = "cleanup"
+
+	DecisionActionCreated = "created"
+	DecisionActionUpdated = "updated"
+	DecisionActionDeleted = string(cleanupActionDeleted)
+	DecisionActionNoop    = string(cleanupActionNoop)
+)
+
+// Decision describes what the resolver did for a challenge: the zone it chose and what happened to
+// the challenge recordset.
+type Decision struct {
+	// Operation is either DecisionOperationPresent or DecisionOperationCleanUp.
+	Operation string
+	FQDN      string
+	ZoneID    string
+	ZoneName  string
+	// Action is one of the DecisionAction constants.
+	Action string
+	// CredentialSource is one of the CredentialSource constants.
+	CredentialSource string
+}
+
+// DecisionRecorder is implemented by solvers that can report their last decision, so that end to
+// end tests can assert on more than the DNS answer.
+type DecisionRecorder interface {
+	LastDecision() (Decision, bool)
+}
+
+var _ DecisionRecorder = (*designateDnsResolver)(nil)
+
+// WithDecisionRecording makes the resolver remember the decision of the last successful challenge,
+// see LastDecision.
+func WithDecisionRecording(enabled bool) Option {
+	return func(d *designateDnsResolver) {
+		if enabled {
+			d.decisions = new(decisionRecorder)
+		} else {
+			d.decisions = nil
+		}
+	}
+}
+
+// LastDecision returns the decision of the last successful challenge. It reports false if no
+// challenge succeeded yet or decision recording is disabled.
+func (d *designateDnsResolver) LastDecision() (Decision, bool) {
+	return d.decisions.last()
+}
+
+// decisionRecorder holds the last decision. A nil recorder records nothing.
+type decisionRecorder struct {
+	mu       sync.Mutex
+	decision Decision
+	recorded bool
+}
+
+func (r *decisionRecorder) record(decision Decision) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.decision = decision
+	r.recorded = true
+}
+
+func (r *decisionRecorder) last() (Decision, bool) {
+	if r == nil {
+		return Decision{}, false
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.decision, r.recorded
+}
@@ -0,0 +1,137 @@
+package resolver
+
+import (
+	"testing"
+
+	mockresolver "github.com/rikotsev/cert-manager-webhook-designate/internal/resolver/mock"
+)
+
+func TestDesignateDnsResolver_LastDecision(t *testing.T) {
+	mockApi := mockresolver.CreateMockOpenstackApi(t)
+	mockApi.Zones = []mockresolver.MockZone{
+		{
+			ID:   "12345",
+			Name: "example.com.",
+		},
+	}
+	resolver := newTestResolver(t, mockApi)
+	WithDecisionRecording(true)(resolver)
+
+	if _, ok := resolver.LastDecision(); ok {
+		t.Fatal("expected no decision before the first challenge")
+	}
+
+	config := `{
+		"secretName": "foo",
+		"secretNamespace": "bar",
+		"strategy": {
+			"kind": "BestEffort"
+		}
+	}`
+	first := newChallengeRequest("first", "cool.example.com", "example.com", config)
+	second := newChallengeRequest("second", "cool.example.com", "example.com", config)
+
+	steps := []struct {
+		name     string
+		run      func() error
+		expected Decision
+	}{
+		{
+			name: "present creates",
+			run:  func() error { return resolver.Present(first) },
+			expected: Decision{
+				Operation:        DecisionOperationPresent,
+				FQDN:             "cool.example.com",
+				ZoneID:           "12345",
+				ZoneName:         "example.com.",
+				Action:           DecisionActionCreated,
+				CredentialSource: CredentialSourceSecret,
+			},
+		},
+		{
+			name: "present updates",
+			run:  func() error { return resolver.Present(second) },
+			expected: Decision{
+				Operation:        DecisionOperationPresent,
+				FQDN:             "cool.example.com",
+				ZoneID:           "12345",
+				ZoneName:         "example.com.",
+				Action:           DecisionActionUpdated,
+				CredentialSource: CredentialSourceSecret,
+			},
+		},
+		{
+			name: "present is a noop",
+			run:  func() error { return resolver.Present(second) },
+			expected: Decision{
+				Operation:        DecisionOperationPresent,
+				FQDN:             "cool.example.com",
+				ZoneID:           "12345",
+				ZoneName:         "example.com.",
+				Action:           DecisionActionNoop,
+				CredentialSource: CredentialSourceSecret,
+			},
+		},
+		{
+			name: "cleanup updates",
+			run:  func() error { return resolver.CleanUp(first) },
+			expected: Decision{
+				Operation:        DecisionOperationCleanUp,
+				FQDN:             "cool.example.com",
+				ZoneID:           "12345",
+				ZoneName:         "example.com.",
+				Action:           DecisionActionUpdated,
+				CredentialSource: CredentialSourceSecret,
+			},
+		},
+		{
+			name: "cleanup deletes",
+			run:  func() error { return resolver.CleanUp(second) },
+			expected: Decision{
+				Operation:        DecisionOperationCleanUp,
+				FQDN:             "cool.example.com",
+				ZoneID:           "12345",
+				ZoneName:         "example.com.",
+				Action:           DecisionActionDeleted,
+				CredentialSource: CredentialSourceSecret,
+			},
+		},
+	}
+
+	for _, step := range steps {
+		if err := step.run(); err != nil {
+			t.Fatalf("%s: unexpected error: %v", step.name, err)
+		}
+
+		decision, ok := resolver.LastDecision()
+		if !ok || decision != step.expected {
+			t.Errorf("%s: expected decision %+v, got %+v", step.name, step.expected, decision)
+		}
+	}
+}
+
+func TestDesignateDnsResolver_LastDecision_Disabled(t *testing.T) {
+	mockApi := mockresolver.CreateMockOpenstackApi(t)
+	mockApi.Zones = []mockresolver.MockZone{
+		{
+			ID:   "12345",
+			Name: "example.com.",
+		},
+	}
+	resolver := newTestResolver(t, mockApi)
+
+	err := resolver.Present(newChallengeRequest("challenge", "cool.example.com", "example.com", `{
+		"secretName": "foo",
+		"secretNamespace": "bar",
+		"strategy": {
+			"kind": "SOA"
+		}
+	}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, ok := resolver.LastDecision(); ok {
+		t.Error("expected no decision without decision recording")
+	}
+}
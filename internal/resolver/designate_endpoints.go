@@ -0,0 +1,63 @@
+package resolver
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/gophercloud/gophercloud/v2/openstack"
+	"sigs.k8s.io/yaml"
+)
+
+var ErrInvalidDesignateEndpoints = errors.New("invalid designateEndpoints")
+
+// newDesignateClient returns the designate client for the region of the auth config. The endpoint is
+// taken from the designateEndpoints of the credentials secret if it lists the region, and from the
+// service catalog otherwise.
+func newDesignateClient(providerClient *gophercloud.ProviderClient, authCfg *AuthConfig) (*gophercloud.ServiceClient, error) {
+	endpoint, err := authCfg.designateEndpoint()
+	if err != nil {
+		return nil, err
+	}
+
+	if endpoint == "" {
+		return openstack.NewDNSV2(providerClient, authCfg.endpointOpts)
+	}
+
+	return &gophercloud.ServiceClient{
+		ProviderClient: providerClient,
+		Endpoint:       endpoint,
+		ResourceBase:   endpoint + "v2/",
+		Type:           "dns",
+	}, nil
+}
+
+// designateEndpoint looks up the region in designateEndpoints, a YAML map of region names to
+// designate URLs. Region names are matched case-insensitively. It returns an empty endpoint if the
+// region is not listed.
+func (c *AuthConfig) designateEndpoint() (string, error) {
+	if c.designateEndpoints == "" {
+		return "", nil
+	}
+
+	var endpoints map[string]string
+	if err := yaml.Unmarshal([]byte(c.designateEndpoints), &endpoints); err != nil {
+		return "", fmt.Errorf("%w: %w", ErrInvalidDesignateEndpoints, err)
+	}
+
+	for region, endpoint := range endpoints {
+		if !strings.EqualFold(region, c.endpointOpts.Region) {
+			continue
+		}
+
+		u, err := url.Parse(strings.TrimSpace(endpoint))
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return "", fmt.Errorf("%w: %q of region %s is not an http or https URL", ErrInvalidDesignateEndpoints, endpoint, region)
+		}
+		return gophercloud.NormalizeURL(u.String()), nil
+	}
+
+	return "", nil
+}
@@ -0,0 +1,96 @@
+package resolver
+
+import (
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+
+	mockresolver "github.com/rikotsev/cert-manager-webhook-designate/internal/resolver/mock"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestDesignateDnsResolver_Present_DesignateEndpoints(t *testing.T) {
+	newRegion := func() (*mockresolver.OpenstackApiMock, *httptest.Server) {
+		mockApi := mockresolver.CreateMockOpenstackApi(t)
+		mockApi.Zones = []mockresolver.MockZone{
+			{
+				ID:   "12345",
+				Name: "example.com.",
+			},
+		}
+		server := httptest.NewServer(mockApi)
+		t.Cleanup(server.Close)
+		return mockApi, server
+	}
+	regionOne, regionOneServer := newRegion()
+	regionTwo, regionTwoServer := newRegion()
+
+	resolver := new(designateDnsResolver)
+	resolver.configProvider = &authConfigProvider{
+		client: fake.NewClientset(dummySecret("foo", "bar", map[string]string{
+			"tenantName":        "testTenant",
+			"domainId":          "testDomainId",
+			"username":          "john-doe",
+			"password":          "secretpass",
+			"region":            "RegionOne",
+			"identityEndpoint":  regionOneServer.URL,
+			"insecureAllowHTTP": "true",
+			"designateEndpoints": fmt.Sprintf("RegionOne: %s/dns\nRegionTwo: %s/dns/\n",
+				regionOneServer.URL, regionTwoServer.URL),
+		})),
+	}
+
+	tcs := []struct {
+		name          string
+		region        string
+		expectedError error
+		expected      *mockresolver.OpenstackApiMock
+	}{
+		{name: "region of the secret", expected: regionOne},
+		{name: "overridden region", region: "RegionTwo", expected: regionTwo},
+		{name: "region in another case", region: "regiontwo", expected: regionTwo},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			regionOne.Updates, regionTwo.Updates = nil, nil
+			regionOne.RecordSets, regionTwo.RecordSets = nil, nil
+
+			region := ""
+			if tc.region != "" {
+				region = fmt.Sprintf(`"region": %q,`, tc.region)
+			}
+			err := resolver.Present(newChallengeRequest("challenge", "cool.example.com", "example.com", fmt.Sprintf(`{
+				"secretName": "foo",
+				"secretNamespace": "bar",
+				%s
+				"strategy": {
+					"kind": "SOA"
+				}
+			}`, region)))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			for name, mockApi := range map[string]*mockresolver.OpenstackApiMock{"RegionOne": regionOne, "RegionTwo": regionTwo} {
+				expectedCreates := 0
+				if mockApi == tc.expected {
+					expectedCreates = 1
+				}
+				if len(mockApi.Updates) != expectedCreates {
+					t.Errorf("expected %d creates in %s, got %d", expectedCreates, name, len(mockApi.Updates))
+				}
+			}
+		})
+	}
+
+	t.Run("invalid endpoint", func(t *testing.T) {
+		authCfg := &AuthConfig{designateEndpoints: "RegionOne: ftp://example.com"}
+		authCfg.endpointOpts.Region = "RegionOne"
+
+		if _, err := authCfg.designateEndpoint(); !errors.Is(err, ErrInvalidDesignateEndpoints) {
+			t.Errorf("expected error %v, got %v", ErrInvalidDesignateEndpoints, err)
+		}
+	})
+}
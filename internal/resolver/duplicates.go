@@ -0,0 +1,52 @@
+package resolver
+
+import (
+	"cmp"
+	"context"
+	"slices"
+
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/gophercloud/gophercloud/v2/openstack/dns/v2/recordsets"
+	"k8s.io/klog/v2"
+)
+
+// mergeDuplicateRecordSets consolidates recordsets of the same name and type, as left behind by
+// racing creates, into the oldest one. Records of the others are merged into it before they are
+// deleted, so no challenge is lost.
+func mergeDuplicateRecordSets(ctx context.Context, designateClient *gophercloud.ServiceClient, zoneId string, allRecordSets []recordsets.RecordSet) ([]recordsets.RecordSet, error) {
+	if len(allRecordSets) < 2 {
+		return allRecordSets, nil
+	}
+
+	sorted := slices.SortedStableFunc(slices.Values(allRecordSets), func(a, b recordsets.RecordSet) int {
+		return cmp.Or(a.CreatedAt.Compare(b.CreatedAt), cmp.Compare(a.ID, b.ID))
+	})
+	oldest, duplicates := sorted[0], sorted[1:]
+
+	merged := slices.Clone(oldest.Records)
+	for _, duplicate := range duplicates {
+		for _, rec := range duplicate.Records {
+			if !slices.ContainsFunc(merged, func(existing string) bool { return sameRecordValue(existing, rec) }) {
+				merged = append(merged, rec)
+			}
+		}
+	}
+
+	if len(merged) != len(oldest.Records) {
+		result := recordsets.Update(ctx, designateClient, zoneId, oldest.ID, updateOpts(oldest, merged))
+		if result.Err != nil {
+			return nil, writeError(result.Err, zoneId)
+		}
+		oldest.Records = merged
+	}
+
+	for _, duplicate := range duplicates {
+		if err := recordsets.Delete(ctx, designateClient, zoneId, duplicate.ID).ExtractErr(); err != nil {
+			return nil, writeError(err, zoneId)
+		}
+	}
+
+	klog.InfoS("merged duplicate recordsets", "zoneId", zoneId, "name", oldest.Name, "recordSetId", oldest.ID, "duplicates", len(duplicates))
+
+	return []recordsets.RecordSet{oldest}, nil
+}
@@ -0,0 +1,162 @@
+package resolver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/cert-manager/cert-manager/pkg/acme/webhook/apis/acme/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
+)
+
+// Reasons of the events recorded for challenges, see WithChallengeEvents.
+const (
+	EventReasonPresented     = "Presented"
+	EventReasonPresentFailed = "PresentFailed"
+	EventReasonCleanedUp     = "CleanedUp"
+	EventReasonCleanUpFailed = "CleanUpFailed"
+)
+
+// challengeGroupVersion and challengeKind identify the cert-manager challenges events are recorded for.
+const (
+	challengeGroupVersion = "acme.cert-manager.io/v1"
+	challengeKind         = "Challenge"
+)
+
+// challengeResource is the resource of the challenges looked up to record events for.
+var challengeResource = schema.GroupVersionResource{Group: "acme.cert-manager.io", Version: "v1", Resource: "challenges"}
+
+// challengeLookupTimeout bounds looking up the challenge an event is recorded for.
+const challengeLookupTimeout = 5 * time.Second
+
+var ErrChallengeNotFound = errors.New("challenge not found")
+
+// WithChallengeEvents records a Kubernetes event in the namespace of the issuer of every challenge
+// once it is presented or cleaned up, or fails to be. The events are only sent once Initialize has
+// built the Kubernetes client.
+func WithChallengeEvents(enabled bool) Option {
+	return func(d *designateDnsResolver) {
+		d.challengeEvents = enabled
+	}
+}
+
+// eventRecorder records the events of challenges. A nil recorder records nothing, so that events
+// can be recorded unconditionally.
+type eventRecorder struct {
+	broadcaster record.EventBroadcaster
+	recorder    record.EventRecorder
+	challenges  dynamic.NamespaceableResourceInterface
+	// names holds the names of the challenges looked up, by UID.
+	names sync.Map
+}
+
+// newEventRecorder returns a recorder sending events through client until it is shut down. The
+// challenges events refer to are looked up through dynamicClient.
+func newEventRecorder(client kubernetes.Interface, dynamicClient dynamic.Interface) *eventRecorder {
+	broadcaster := record.NewBroadcaster()
+	broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: client.CoreV1().Events("")})
+
+	return &eventRecorder{
+		broadcaster: broadcaster,
+		recorder:    broadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: Name}),
+		challenges:  dynamicClient.Resource(challengeResource),
+	}
+}
+
+// challengeReference refers to the challenge of a request. The request carries the UID and namespace
+// of the challenge but not its name, so the challenge is looked up by UID once.
+func (r *eventRecorder) challengeReference(ch *v1alpha1.ChallengeRequest) (*corev1.ObjectReference, error) {
+	name, ok := r.names.Load(ch.UID)
+	if !ok {
+		ctx, cancel := context.WithTimeout(context.Background(), challengeLookupTimeout)
+		defer cancel()
+
+		list, err := r.challenges.Namespace(ch.ResourceNamespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		for _, challenge := range list.Items {
+			if challenge.GetUID() == ch.UID {
+				name, ok = challenge.GetName(), true
+				break
+			}
+		}
+		if !ok {
+			return nil, fmt.Errorf("%w: %s/%s", ErrChallengeNotFound, ch.ResourceNamespace, ch.UID)
+		}
+		r.names.Store(ch.UID, name)
+	}
+
+	return &corev1.ObjectReference{
+		APIVersion: challengeGroupVersion,
+		Kind:       challengeKind,
+		Namespace:  ch.ResourceNamespace,
+		Name:       name.(string),
+		UID:        ch.UID,
+	}, nil
+}
+
+// event records an event for the challenge of a request. Without the challenge to refer to the event
+// is dropped, as it would not show up with the challenge anyway.
+func (r *eventRecorder) event(ch *v1alpha1.ChallengeRequest, eventType, reason, messageFmt string, args ...any) {
+	ref, err := r.challengeReference(ch)
+	if err != nil {
+		klog.V(2).InfoS("not recording challenge event", "reason", reason, "fqdn", ch.ResolvedFQDN, "err", err)
+		return
+	}
+
+	r.recorder.Eventf(ref, eventType, reason, messageFmt, args...)
+}
+
+// presented records the outcome of presenting a challenge, with the decision taken if it succeeded.
+func (r *eventRecorder) presented(ch *v1alpha1.ChallengeRequest, decision Decision, err error) {
+	if r == nil {
+		return
+	}
+
+	if err != nil {
+		r.event(ch, corev1.EventTypeWarning, EventReasonPresentFailed,
+			"Failed to present TXT record %s: %v", ch.ResolvedFQDN, err)
+		return
+	}
+
+	r.event(ch, corev1.EventTypeNormal, EventReasonPresented,
+		"Presented TXT record %s in zone %s (%s)", ch.ResolvedFQDN, decision.ZoneName, decision.Action)
+}
+
+// cleanedUp records the outcome of cleaning up a challenge, with its summary if it succeeded.
+func (r *eventRecorder) cleanedUp(ch *v1alpha1.ChallengeRequest, summary *cleanupSummary, err error) {
+	if r == nil {
+		return
+	}
+
+	if err != nil {
+		r.event(ch, corev1.EventTypeWarning, EventReasonCleanUpFailed,
+			"Failed to clean up TXT record %s: %v", ch.ResolvedFQDN, err)
+		return
+	}
+
+	r.event(ch, corev1.EventTypeNormal, EventReasonCleanedUp,
+		"Cleaned up TXT record %s in zone %s (%s)", ch.ResolvedFQDN, summary.zoneName, summary.action)
+	// The challenge is done with once cleaned up.
+	r.names.Delete(ch.UID)
+}
+
+// shutdown stops sending events, dropping those not sent yet.
+func (r *eventRecorder) shutdown() {
+	if r == nil {
+		return
+	}
+
+	r.broadcaster.Shutdown()
+}
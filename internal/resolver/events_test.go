@@ -0,0 +1,119 @@
+package resolver
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	mockresolver "github.com/rikotsev/cert-manager-webhook-designate/internal/resolver/mock"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestDesignateDnsResolver_ChallengeEvents(t *testing.T) {
+	mockApi := mockresolver.CreateMockOpenstackApi(t)
+	mockApi.Zones = []mockresolver.MockZone{
+		{
+			ID:   "12345",
+			Name: "example.com.",
+		},
+	}
+	resolver := newTestResolver(t, mockApi)
+	client := fake.NewClientset()
+	dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{challengeResource: "ChallengeList"},
+		newChallenge("bar", "cert-1-2345-6789", "challenge-uid"),
+		newChallenge("bar", "cert-2-2345-6789", "failing-uid"),
+	)
+	resolver.events = newEventRecorder(client, dynamicClient)
+	t.Cleanup(resolver.events.shutdown)
+
+	config := `{
+		"secretName": "foo",
+		"secretNamespace": "bar",
+		"strategy": {
+			"kind": "SOA"
+		}
+	}`
+	ch := newChallengeRequest("challenge", "cool.example.com", "example.com", config)
+	ch.ResourceNamespace = "bar"
+	ch.DNSName = "cool.example.com"
+	ch.UID = "challenge-uid"
+	if err := resolver.Present(ch); err != nil {
+		t.Fatalf("expected no error presenting, got %v", err)
+	}
+	if err := resolver.CleanUp(ch); err != nil {
+		t.Fatalf("expected no error cleaning up, got %v", err)
+	}
+
+	failing := newChallengeRequest("challenge", "cool.example.org", "example.org", config)
+	failing.ResourceNamespace = "bar"
+	failing.DNSName = "cool.example.org"
+	failing.UID = "failing-uid"
+	if err := resolver.Present(failing); err == nil {
+		t.Fatal("expected presenting outside of every zone to fail")
+	}
+
+	// Events of challenges that cannot be found are dropped.
+	unknown := newChallengeRequest("challenge", "other.example.com", "example.com", config)
+	unknown.ResourceNamespace = "bar"
+	unknown.UID = "unknown-uid"
+	if err := resolver.Present(unknown); err != nil {
+		t.Fatalf("expected no error presenting, got %v", err)
+	}
+
+	expected := []string{
+		corev1.EventTypeNormal + "/" + EventReasonPresented,
+		corev1.EventTypeNormal + "/" + EventReasonCleanedUp,
+		corev1.EventTypeWarning + "/" + EventReasonPresentFailed,
+	}
+	var events []corev1.Event
+	waitFor(t, func() bool {
+		list, err := client.CoreV1().Events("bar").List(context.Background(), metav1.ListOptions{})
+		if err != nil {
+			t.Fatalf("expected no error listing events, got %v", err)
+		}
+		events = list.Items
+		return len(events) == len(expected)
+	})
+
+	var got []string
+	for _, event := range events {
+		got = append(got, event.Type+"/"+event.Reason)
+
+		if event.InvolvedObject.Kind != challengeKind || event.InvolvedObject.Namespace != "bar" {
+			t.Errorf("expected the event to refer to a challenge in bar, got %+v", event.InvolvedObject)
+		}
+	}
+	slices.Sort(got)
+	slices.Sort(expected)
+	if !slices.Equal(got, expected) {
+		t.Errorf("expected events %v, got %v", expected, got)
+	}
+
+	for _, event := range events {
+		if event.Reason == EventReasonPresented && (event.InvolvedObject.UID != "challenge-uid" || event.InvolvedObject.Name != "cert-1-2345-6789") {
+			t.Errorf("expected the event to refer to the challenge, got %+v", event.InvolvedObject)
+		}
+		if event.Reason == EventReasonPresentFailed && event.InvolvedObject.Name != "cert-2-2345-6789" {
+			t.Errorf("expected the event to refer to the failing challenge, got %+v", event.InvolvedObject)
+		}
+	}
+}
+
+// newChallenge returns a cert-manager challenge as seen through the dynamic client.
+func newChallenge(namespace, name, uid string) *unstructured.Unstructured {
+	challenge := &unstructured.Unstructured{}
+	challenge.SetAPIVersion(challengeGroupVersion)
+	challenge.SetKind(challengeKind)
+	challenge.SetNamespace(namespace)
+	challenge.SetName(name)
+	challenge.SetUID(types.UID(uid))
+	return challenge
+}
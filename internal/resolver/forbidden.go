@@ -0,0 +1,24 @@
+package resolver
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gophercloud/gophercloud/v2"
+)
+
+// forbiddenAware marks errors of writes rejected with 403 Forbidden with ErrZoneNotWritable. Credentials
+// that may list a zone, such as one shared with their project, are not necessarily permitted to
+// change its recordsets. Other errors are returned as is.
+func forbiddenAware(err error, zoneId string) error {
+	if !gophercloud.ResponseCodeIs(err, http.StatusForbidden) {
+		return err
+	}
+
+	return fmt.Errorf("%w: the credentials are not permitted to change recordsets in zone %s, e.g. because it is shared with their project by another one: %w", ErrZoneNotWritable, zoneId, err)
+}
+
+// writeError classifies the error of a recordset write in the zone, see readOnlyAware and forbiddenAware.
+func writeError(err error, zoneId string) error {
+	return forbiddenAware(readOnlyAware(err), zoneId)
+}
@@ -0,0 +1,90 @@
+package resolver
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gophercloud/gophercloud/v2"
+
+	mockresolver "github.com/rikotsev/cert-manager-webhook-designate/internal/resolver/mock"
+)
+
+func TestDesignateDnsResolver_ForbiddenWrites(t *testing.T) {
+	tcs := []struct {
+		name    string
+		records []string
+		call    func(*designateDnsResolver, string) error
+	}{
+		{
+			name: "present creating a recordset",
+			call: func(d *designateDnsResolver, config string) error {
+				return d.Present(newChallengeRequest("challenge", "cool.example.com", "example.com", config))
+			},
+		},
+		{
+			name:    "present updating a recordset",
+			records: []string{"\"other\""},
+			call: func(d *designateDnsResolver, config string) error {
+				return d.Present(newChallengeRequest("challenge", "cool.example.com", "example.com", config))
+			},
+		},
+		{
+			name:    "cleanup deleting a recordset",
+			records: []string{"\"challenge\""},
+			call: func(d *designateDnsResolver, config string) error {
+				return d.CleanUp(newChallengeRequest("challenge", "cool.example.com", "example.com", config))
+			},
+		},
+		{
+			name:    "cleanup updating a recordset",
+			records: []string{"\"challenge\"", "\"other\""},
+			call: func(d *designateDnsResolver, config string) error {
+				return d.CleanUp(newChallengeRequest("challenge", "cool.example.com", "example.com", config))
+			},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			mockApi := mockresolver.CreateMockOpenstackApi(t)
+			mockApi.ForbiddenWrites = true
+			mockApi.Zones = []mockresolver.MockZone{
+				{
+					ID:   "12345",
+					Name: "example.com.",
+				},
+			}
+			if tc.records != nil {
+				mockApi.RecordSets = []mockresolver.MockRecordSet{
+					{
+						ID:      "rs-1",
+						ZoneID:  "12345",
+						Name:    "cool.example.com.",
+						Type:    "TXT",
+						Records: tc.records,
+					},
+				}
+			}
+			resolver := newTestResolver(t, mockApi)
+
+			err := tc.call(resolver, `{
+				"secretName": "foo",
+				"secretNamespace": "bar",
+				"strategy": {
+					"kind": "SOA"
+				}
+			}`)
+			if !errors.Is(err, ErrZoneNotWritable) {
+				t.Fatalf("expected error %v, got %v", ErrZoneNotWritable, err)
+			}
+			if !strings.Contains(err.Error(), "zone 12345") {
+				t.Errorf("expected the error to name the zone, got %v", err)
+			}
+			if !gophercloud.ResponseCodeIs(err, http.StatusForbidden) {
+				t.Errorf("expected the error to wrap the 403 response, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package resolver
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+var ErrInvalidIdentityEndpoint = errors.New("invalid identity endpoint")
+var ErrInsecureIdentityEndpoint = errors.New("the identity endpoint does not use https, set insecureAllowHTTP to allow it")
+
+// canonicalIdentityEndpoint trims the endpoint, e.g. of the trailing newline of a value written with
+// echo, and makes sure it is an absolute https URL. Plain http, which would send the credentials
+// unencrypted, is only accepted if the secret sets insecureAllowHTTP.
+func canonicalIdentityEndpoint(endpoint string, allowHTTP bool) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(endpoint))
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrInvalidIdentityEndpoint, err)
+	}
+
+	switch u.Scheme {
+	case "https":
+	case "http":
+		if !allowHTTP {
+			return "", fmt.Errorf("%w: %s", ErrInsecureIdentityEndpoint, u.Redacted())
+		}
+	default:
+		return "", fmt.Errorf("%w: %q, expected an http or https URL", ErrInvalidIdentityEndpoint, u.Redacted())
+	}
+
+	if u.Host == "" {
+		return "", fmt.Errorf("%w: %q has no host", ErrInvalidIdentityEndpoint, u.Redacted())
+	}
+
+	return u.String(), nil
+}
@@ -0,0 +1,53 @@
+package resolver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/gophercloud/gophercloud/v2/openstack"
+)
+
+var ErrInvalidIdentityAPIVersion = errors.New("invalid identity API version, expected v2 or v3")
+
+// identityAPIVersion selects the keystone API to authenticate against.
+type identityAPIVersion string
+
+const (
+	// identityAPIVersionAuto picks the version from the version document of the identity endpoint.
+	identityAPIVersionAuto identityAPIVersion = ""
+	// identityAPIVersionV2 authenticates against /v2.0/tokens.
+	identityAPIVersionV2 identityAPIVersion = "v2"
+	// identityAPIVersionV3 authenticates against /v3/auth/tokens.
+	identityAPIVersionV3 identityAPIVersion = "v3"
+)
+
+// parseIdentityAPIVersion accepts the forms of OS_IDENTITY_API_VERSION, e.g. "3", "v3" or "2.0".
+func parseIdentityAPIVersion(version string) (identityAPIVersion, error) {
+	switch strings.TrimPrefix(strings.ToLower(strings.TrimSpace(version)), "v") {
+	case "":
+		return identityAPIVersionAuto, nil
+	case "2", "2.0":
+		return identityAPIVersionV2, nil
+	case "3", "3.0":
+		return identityAPIVersionV3, nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrInvalidIdentityAPIVersion, version)
+	}
+}
+
+// authenticate authenticates the provider client with the configured identity API version. The
+// path is derived from the base of the identity endpoint, so an endpoint such as .../v3 can still
+// be forced to v2.
+func (c *AuthConfig) authenticate(ctx context.Context, client *gophercloud.ProviderClient) error {
+	switch c.identityAPIVersion {
+	case identityAPIVersionV2:
+		return openstack.AuthenticateV2(ctx, client, &c.authOpts, gophercloud.EndpointOpts{})
+	case identityAPIVersionV3:
+		return openstack.AuthenticateV3(ctx, client, &c.authOpts, gophercloud.EndpointOpts{})
+	default:
+		return openstack.Authenticate(ctx, client, c.authOpts)
+	}
+}
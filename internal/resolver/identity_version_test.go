@@ -0,0 +1,90 @@
+package resolver
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	mockresolver "github.com/rikotsev/cert-manager-webhook-designate/internal/resolver/mock"
+)
+
+func TestParseIdentityAPIVersion(t *testing.T) {
+	tcs := map[string]identityAPIVersion{
+		"":     identityAPIVersionAuto,
+		"2":    identityAPIVersionV2,
+		"v2.0": identityAPIVersionV2,
+		"3":    identityAPIVersionV3,
+		" V3 ": identityAPIVersionV3,
+	}
+	for input, expected := range tcs {
+		actual, err := parseIdentityAPIVersion(input)
+		if err != nil {
+			t.Errorf("expected no error for %q, got %v", input, err)
+		}
+		if actual != expected {
+			t.Errorf("expected %q for %q, got %q", expected, input, actual)
+		}
+	}
+
+	if _, err := parseIdentityAPIVersion("4"); !errors.Is(err, ErrInvalidIdentityAPIVersion) {
+		t.Errorf("expected error %v, got %v", ErrInvalidIdentityAPIVersion, err)
+	}
+}
+
+func TestDesignateDnsResolver_Present_IdentityAPIVersion(t *testing.T) {
+	tcs := []struct {
+		name         string
+		version      string
+		expectedPath string
+	}{
+		{
+			name:         "auto-detected from the versions document",
+			expectedPath: "/tokens",
+		},
+		{
+			name:         "forced v2",
+			version:      "v2",
+			expectedPath: "/v2.0/tokens",
+		},
+		{
+			name:         "forced v3",
+			version:      "3",
+			expectedPath: "/v3/auth/tokens",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			mockApi := mockresolver.CreateMockOpenstackApi(t)
+			mockApi.Zones = []mockresolver.MockZone{
+				{
+					ID:   "12345",
+					Name: "example.com.",
+				},
+			}
+			resolver := newTestResolver(t, mockApi)
+			if tc.version != "" {
+				setSecretValue(t, resolver, "identityApiVersion", tc.version)
+			}
+
+			err := resolver.Present(newChallengeRequest("challenge", "cool.example.com", "example.com", `{
+				"secretName": "foo",
+				"secretNamespace": "bar",
+				"zoneScope": "project",
+				"strategy": {
+					"kind": "BestEffort"
+				}
+			}`))
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if !slices.Equal(mockApi.TokenPaths, []string{tc.expectedPath}) {
+				t.Errorf("expected to authenticate at %s, got %v", tc.expectedPath, mockApi.TokenPaths)
+			}
+			if len(mockApi.RecordSets) != 1 {
+				t.Errorf("expected the challenge recordset to be created, got %d recordsets", len(mockApi.RecordSets))
+			}
+		})
+	}
+}
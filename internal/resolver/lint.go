@@ -0,0 +1,111 @@
+package resolver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+// lintReachabilityTimeout bounds the request checking that the identity endpoint is reachable.
+const lintReachabilityTimeout = 10 * time.Second
+
+var ErrUnreachableIdentityEndpoint = errors.New("the identity endpoint is not reachable")
+var ErrSecretReferenceMismatch = errors.New("the config references a different secret")
+
+// Lint checks an issuer config together with the credentials secret it references, before they are
+// applied, and returns every problem found: parse and schema errors, strategy requirements, unknown
+// and missing secret keys, invalid secret values and an unreachable identity endpoint. A nil secret
+// only lints the config. Lint does not authenticate, so wrong credentials go unnoticed.
+func Lint(ctx context.Context, config []byte, secret *corev1.Secret) []error {
+	var problems []error
+
+	cfg, err := ParseConfig(&apiextensionsv1.JSON{Raw: config})
+	if err != nil {
+		problems = append(problems, unjoin(err)...)
+		// Carry on with what can be decoded, to check the config against the secret regardless.
+		cfg = new(ChallengeConfig)
+		_ = json.Unmarshal(config, cfg)
+	}
+
+	if secret == nil {
+		return problems
+	}
+
+	if cfg.hasSecret() && secret.Name != "" && (cfg.SecretNamespace != secret.Namespace || cfg.SecretName != secret.Name) {
+		problems = append(problems, fmt.Errorf("%w: %s/%s instead of %s/%s", ErrSecretReferenceMismatch, cfg.SecretNamespace, cfg.SecretName, secret.Namespace, secret.Name))
+	}
+
+	data := secretData(secret)
+	problems = append(problems, unjoin((&authConfigProvider{unknownKeys: UnknownSecretKeysError}).checkUnknownKeys(secret.Namespace, secret.Name, data))...)
+
+	authCfg, err := (&authConfigProvider{}).fromSecret(secret.Namespace, secret.Name, data, false)
+	if err != nil {
+		return append(problems, unjoin(err)...)
+	}
+
+	if _, err := authCfg.designateEndpoint(); err != nil {
+		problems = append(problems, err)
+	}
+
+	httpClient, err := tlsHTTPClient(authCfg.caCert, cfg.InsecureSkipVerify)
+	if err != nil {
+		return append(problems, err)
+	}
+
+	if err := checkReachable(ctx, httpClient, authCfg.authOpts.IdentityEndpoint); err != nil {
+		problems = append(problems, err)
+	}
+
+	return problems
+}
+
+// checkReachable reports whether the endpoint answers HTTP requests at all, whatever the status.
+func checkReachable(ctx context.Context, httpClient *http.Client, endpoint string) error {
+	ctx, cancel := context.WithTimeout(ctx, lintReachabilityTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrUnreachableIdentityEndpoint, err)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrUnreachableIdentityEndpoint, err)
+	}
+
+	return resp.Body.Close()
+}
+
+// secretData merges the string data of a secret read from a manifest into its data, like the API
+// server does when the secret is applied.
+func secretData(secret *corev1.Secret) map[string][]byte {
+	data := make(map[string][]byte, len(secret.Data)+len(secret.StringData))
+	for key, value := range secret.Data {
+		data[key] = value
+	}
+	for key, value := range secret.StringData {
+		data[key] = []byte(value)
+	}
+
+	return data
+}
+
+// unjoin splits an error created by errors.Join into its errors.
+func unjoin(err error) []error {
+	if err == nil {
+		return nil
+	}
+
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		return joined.Unwrap()
+	}
+
+	return []error{err}
+}
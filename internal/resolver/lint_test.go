@@ -0,0 +1,104 @@
+package resolver
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	mockresolver "github.com/rikotsev/cert-manager-webhook-designate/internal/resolver/mock"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestLint(t *testing.T) {
+	openstackMock := httptest.NewServer(mockresolver.CreateMockOpenstackApi(t))
+	t.Cleanup(openstackMock.Close)
+
+	validSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "bar", Name: "foo"},
+		StringData: map[string]string{
+			"tenantName":        "testTenant",
+			"domainName":        "testDomainName",
+			"username":          "john-doe",
+			"password":          "secretpass",
+			"region":            "RegionOne",
+			"identityEndpoint":  openstackMock.URL,
+			"insecureAllowHTTP": "true",
+		},
+	}
+
+	tcs := []struct {
+		name           string
+		config         string
+		secret         *corev1.Secret
+		expectedErrors []error
+	}{
+		{
+			name:   "valid config and secret",
+			config: `{"secretName": "foo", "secretNamespace": "bar", "strategy": {"kind": "BestEffort"}}`,
+			secret: validSecret,
+		},
+		{
+			name:   "config without secret",
+			config: `{"strategy": {"kind": "SOA"}}`,
+		},
+		{
+			name:   "multiple issues",
+			config: `{"secretName": "foo", "secretNamespace": "bar", "strategy": {"kind": "ZoneName"}}`,
+			secret: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "bar", Name: "other"},
+				StringData: map[string]string{
+					"tenantName": "testTenant",
+					"domainName": "testDomainName",
+					"usrname":    "john-doe",
+					"region":     "RegionOne",
+				},
+			},
+			expectedErrors: []error{
+				ErrMissingRequiredField,
+				ErrSecretReferenceMismatch,
+				ErrUnknownAuthValue,
+				ErrMissingAuthValue,
+				ErrMissingAuthValue,
+				ErrMissingAuthValue,
+			},
+		},
+		{
+			name:   "unreachable identity endpoint and invalid designate endpoints",
+			config: `{"secretName": "foo", "secretNamespace": "bar", "strategy": {"kind": "BestEffort"}}`,
+			secret: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "bar", Name: "foo"},
+				Data: map[string][]byte{
+					"tenantName":         []byte("testTenant"),
+					"domainName":         []byte("testDomainName"),
+					"username":           []byte("john-doe"),
+					"password":           []byte("secretpass"),
+					"region":             []byte("RegionOne"),
+					"identityEndpoint":   []byte("http://127.0.0.1:1"),
+					"insecureAllowHTTP":  []byte("true"),
+					"designateEndpoints": []byte("- not a map"),
+				},
+			},
+			expectedErrors: []error{
+				ErrInvalidDesignateEndpoints,
+				ErrUnreachableIdentityEndpoint,
+			},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			problems := Lint(context.Background(), []byte(tc.config), tc.secret)
+
+			if len(problems) != len(tc.expectedErrors) {
+				t.Fatalf("expected %d problems, got %d: %v", len(tc.expectedErrors), len(problems), problems)
+			}
+			for i, expected := range tc.expectedErrors {
+				if !errors.Is(problems[i], expected) {
+					t.Errorf("expected problem %d to be %v, got %v", i, expected, problems[i])
+				}
+			}
+		})
+	}
+}
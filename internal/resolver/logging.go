@@ -0,0 +1,17 @@
+package resolver
+
+import (
+	"log/slog"
+	"os"
+)
+
+// logger emits the structured log lines describing the outcome of every challenge, with fields
+// such as fqdn, zoneId, strategy and recordsetId to correlate them. Everything else keeps going
+// through klog, which feeds cert-manager's leveled output.
+var logger = slog.New(slog.NewTextHandler(os.Stderr, nil))
+
+// SetLogger replaces the logger of the challenge logs, e.g. to change its level. It has to be called
+// before challenges are served.
+func SetLogger(l *slog.Logger) {
+	logger = l
+}
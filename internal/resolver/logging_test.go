@@ -0,0 +1,60 @@
+package resolver
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	mockresolver "github.com/rikotsev/cert-manager-webhook-designate/internal/resolver/mock"
+)
+
+func TestDesignateDnsResolver_Present_LogsOutcome(t *testing.T) {
+	var buf bytes.Buffer
+	previous := logger
+	SetLogger(slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	t.Cleanup(func() { SetLogger(previous) })
+
+	mockApi := mockresolver.CreateMockOpenstackApi(t)
+	mockApi.Zones = []mockresolver.MockZone{
+		{
+			ID:   "12345",
+			Name: "example.com.",
+		},
+	}
+	resolver := newTestResolver(t, mockApi)
+
+	if err := resolver.Present(newChallengeRequest("key", "cool.example.com", "example.com", `{"secretName": "foo", "secretNamespace": "bar", "strategy": {"kind": "BestEffort"}}`)); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	var entry map[string]any
+	for _, line := range bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n")) {
+		var candidate map[string]any
+		if err := json.Unmarshal(line, &candidate); err != nil {
+			t.Fatalf("failed to decode log line %q: %v", line, err)
+		}
+		if candidate["msg"] == "presented challenge" {
+			entry = candidate
+		}
+	}
+	if entry == nil {
+		t.Fatalf("expected a presented challenge log, got %s", buf.String())
+	}
+
+	if len(mockApi.RecordSets) != 1 {
+		t.Fatalf("expected one recordset, got %d", len(mockApi.RecordSets))
+	}
+	expected := map[string]any{
+		"fqdn":        "cool.example.com",
+		"zoneId":      "12345",
+		"strategy":    "BestEffort",
+		"recordsetId": mockApi.RecordSets[0].ID,
+		"level":       "INFO",
+	}
+	for key, value := range expected {
+		if entry[key] != value {
+			t.Errorf("expected %s to be %v, got %v", key, value, entry[key])
+		}
+	}
+}
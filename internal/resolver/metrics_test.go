@@ -0,0 +1,87 @@
+package resolver
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/rikotsev/cert-manager-webhook-designate/internal/metrics"
+	mockresolver "github.com/rikotsev/cert-manager-webhook-designate/internal/resolver/mock"
+)
+
+func TestDesignateDnsResolver_OperationMetrics(t *testing.T) {
+	mockApi := mockresolver.CreateMockOpenstackApi(t)
+	mockApi.Zones = []mockresolver.MockZone{
+		{
+			ID:   "12345",
+			Name: "example.com.",
+		},
+	}
+	resolver := newTestResolver(t, mockApi)
+
+	presentSuccesses := metrics.PresentTotal.WithLabelValues(metrics.ResultSuccess)
+	presentErrors := metrics.PresentTotal.WithLabelValues(metrics.ResultError)
+	cleanUpSuccesses := metrics.CleanUpTotal.WithLabelValues(metrics.ResultSuccess)
+	initialPresentSuccesses, initialPresentErrors, initialCleanUpSuccesses := testutil.ToFloat64(presentSuccesses), testutil.ToFloat64(presentErrors), testutil.ToFloat64(cleanUpSuccesses)
+	initialPresentDurations, initialCleanUpDurations := observedDurations(t, metrics.OperationPresent), observedDurations(t, metrics.OperationCleanUp)
+
+	ch := newChallengeRequest("challenge", "cool.example.com", "example.com", `{
+		"secretName": "foo",
+		"secretNamespace": "bar",
+		"strategy": {
+			"kind": "BestEffort"
+		}
+	}`)
+	if err := resolver.Present(ch); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err := resolver.CleanUp(ch); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	mockApi.ErrorListingZones = true
+	if err := resolver.Present(ch); err == nil {
+		t.Fatal("expected an error, got none")
+	}
+
+	if got := testutil.ToFloat64(presentSuccesses) - initialPresentSuccesses; got != 1 {
+		t.Errorf("expected 1 successful present, got %v", got)
+	}
+	if got := testutil.ToFloat64(presentErrors) - initialPresentErrors; got != 1 {
+		t.Errorf("expected 1 failed present, got %v", got)
+	}
+	if got := testutil.ToFloat64(cleanUpSuccesses) - initialCleanUpSuccesses; got != 1 {
+		t.Errorf("expected 1 successful cleanup, got %v", got)
+	}
+	if got := observedDurations(t, metrics.OperationPresent) - initialPresentDurations; got != 2 {
+		t.Errorf("expected 2 observed present durations, got %d", got)
+	}
+	if got := observedDurations(t, metrics.OperationCleanUp) - initialCleanUpDurations; got != 1 {
+		t.Errorf("expected 1 observed cleanup duration, got %d", got)
+	}
+}
+
+// observedDurations scrapes the default registry for the number of durations observed for the operation.
+func observedDurations(t *testing.T, operation string) uint64 {
+	t.Helper()
+
+	families, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatalf("failed to gather metrics: %v", err)
+	}
+
+	for _, family := range families {
+		if family.GetName() != "designate_webhook_operation_duration_seconds" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "operation" && label.GetValue() == operation {
+					return metric.GetHistogram().GetSampleCount()
+				}
+			}
+		}
+	}
+
+	return 0
+}
@@ -1,35 +1,71 @@
 package mockresolver
 
 import (
+	"cmp"
 	"encoding/json"
 	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/gophercloud/gophercloud/v2"
 	"github.com/gophercloud/gophercloud/v2/openstack/dns/v2/recordsets"
 )
 
 type MockZone struct {
 	ID   string
 	Name string
+	// Type defaults to PRIMARY.
+	Type string
+	// Status defaults to ACTIVE.
+	Status string
+	// View is the view the zone is served in, see viewHeader. Zones without a view are only listed
+	// for requests without one.
+	View string
+	// ProjectID is the project owning the zone, defaults to the project tokens are issued for.
+	ProjectID string
 }
 
+// mockProjectID is the project tokens are issued for.
+const mockProjectID = "testTenantId"
+
 type MockRecordSet struct {
 	ID      string
 	ZoneID  string
 	Name    string
 	Type    string
 	Records []string
+	// TTL and Description are reported unless they are zero.
+	TTL         int
+	Description string
+	// CreatedAt is reported as created_at unless it is zero.
+	CreatedAt time.Time
+	// States are returned, one per request, when the recordset is fetched by ID. Once they are
+	// exhausted the recordset is ACTIVE with no pending action.
+	States []MockRecordSetState
+}
+
+type MockRecordSetState struct {
+	Status string
+	Action string
 }
 
 type ZoneUpdate struct {
 	ZoneID string
 	Opts   recordsets.CreateOpts
+	// View is the view the create was sent with.
+	View string
 }
 
+// viewHeader selects the zone view, like the resolver's zone view header.
+const viewHeader = "X-Designate-View"
+
 type RecordSetDelete struct {
 	ZoneID      string
 	RecordSetID string
@@ -43,6 +79,7 @@ type RecordSetPut struct {
 
 type OpenstackApiMock struct {
 	t                   *testing.T
+	mu                  sync.Mutex
 	Zones               []MockZone
 	RecordSets          []MockRecordSet
 	Updates             []ZoneUpdate
@@ -50,6 +87,52 @@ type OpenstackApiMock struct {
 	RecordSetPuts       []RecordSetPut
 	ErrorListingZones   bool
 	ErrorAuthenticating bool
+	// AuthenticationStatus is the status authentication requests fail with while ErrorAuthenticating
+	// is set, 401 Unauthorized if zero.
+	AuthenticationStatus int
+	// TokenRequests counts the authentication requests.
+	TokenRequests int
+	// TokenPaths holds the path of every authentication request.
+	TokenPaths []string
+	// TokenExpiresAt is the expiry of the issued tokens, an hour from the request if zero.
+	TokenExpiresAt time.Time
+	// DNSRegions are the regions of the DNS endpoints in the service catalog, RegionOne if empty.
+	DNSRegions    []string
+	ZoneListDelay time.Duration
+	ZoneListCalls int
+	// WriteDelay delays the response to every recordset create or update.
+	WriteDelay time.Duration
+	// ZoneGets counts the requests for a single zone by ID.
+	ZoneGets int
+	// ZoneListQueries holds the name filter of every zone listing, empty for unfiltered listings.
+	ZoneListQueries []string
+	// ZoneListHeaders holds the request headers of every zone listing.
+	ZoneListHeaders []http.Header
+	// ZoneListLimits holds the page size asked for by every zone listing, empty for the default. With
+	// a limit, zones are listed in pages linked by a marker like designate does.
+	ZoneListLimits []string
+	// WriteStates are assigned to every recordset that is created or updated.
+	WriteStates []MockRecordSetState
+	// RecordSetGets counts the requests fetching a single recordset by ID.
+	RecordSetGets int
+	// MaxZoneListsInFlight is the highest number of zone listings observed being served at once.
+	MaxZoneListsInFlight int
+	zoneListsInFlight    int
+	// ReadOnly rejects every recordset write like designate does during maintenance, while reads keep working.
+	ReadOnly bool
+	// ForbiddenZoneListing rejects zone listings like designate does when policy denies them.
+	ForbiddenZoneListing bool
+	// ForbiddenWrites rejects every recordset write like designate does when policy denies them.
+	ForbiddenWrites bool
+	// ConflictingRecords simulate challenges racing for the same recordset. Each recordset create or
+	// update takes the first one, adds it to the recordset, creating the recordset for a create, and
+	// is answered with 409 Conflict, until none are left.
+	ConflictingRecords []string
+	// RejectDuplicateRecordSets answers 409 Conflict to creating a recordset whose name and type are
+	// taken in the zone, like designate does.
+	RejectDuplicateRecordSets bool
+	// DNSVersions are the API versions advertised by the versions document, only v2.0 if empty.
+	DNSVersions []string
 }
 
 func (o *OpenstackApiMock) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -65,23 +148,23 @@ func (o *OpenstackApiMock) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		r.Method == http.MethodGet && r.URL.Path == "/dns/" {
 		slog.Info("matched versions mock response")
 		w.WriteHeader(http.StatusOK)
-		jsonResponse := `{
-				"versions": {
-					"values": [
-						{
-							"id": "v2.0",
-							"status": "supported",
-							"links": [
-								{
-									"href": "<URL>",
-									"rel": "self"
-								}
-							]
-						}
-					]
-				}
-			}`
-		_, err = w.Write([]byte(strings.Replace(jsonResponse, "<URL>", "http://"+r.Host, 1)))
+		versions := o.DNSVersions
+		if len(versions) == 0 {
+			versions = []string{"v2.0"}
+		}
+		values := make([]map[string]any, 0, len(versions))
+		for _, id := range versions {
+			values = append(values, map[string]any{
+				"id":     id,
+				"status": "supported",
+				"links":  []map[string]string{{"href": baseURL(r), "rel": "self"}},
+			})
+		}
+		jsonResponse, err := json.Marshal(map[string]any{"versions": map[string]any{"values": values}})
+		if err != nil {
+			o.t.Error("failed to encode versions response")
+		}
+		_, err = w.Write(jsonResponse)
 		if err != nil {
 			o.t.Error("failed to write versions response")
 		}
@@ -89,39 +172,98 @@ func (o *OpenstackApiMock) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// authenticate for version
-	if r.Method == http.MethodPost && r.URL.Path == "/tokens" {
+	// authenticate, the v2 path being the one advertised by the versions document
+	if r.Method == http.MethodPost && (r.URL.Path == "/tokens" || r.URL.Path == "/v2.0/tokens" || r.URL.Path == "/v3/auth/tokens") {
+		o.mu.Lock()
+		o.TokenRequests++
+		o.TokenPaths = append(o.TokenPaths, r.URL.Path)
+		o.mu.Unlock()
+
 		if o.ErrorAuthenticating {
 			slog.Info("simulating authentication error")
-			w.WriteHeader(http.StatusUnauthorized)
+			w.WriteHeader(cmp.Or(o.AuthenticationStatus, http.StatusUnauthorized))
 			return
 		}
 
-		slog.Info("matched /tokens mock response")
-		w.WriteHeader(http.StatusOK)
-		jsonResponse := `{
-				"access": {
-					"token": {
-						"id": "mock-token"
+		expires := o.TokenExpiresAt
+		if expires.IsZero() {
+			expires = time.Now().Add(time.Hour)
+		}
+		regions := o.DNSRegions
+		if len(regions) == 0 {
+			regions = []string{"RegionOne"}
+		}
+
+		var response map[string]any
+		if r.URL.Path == "/v3/auth/tokens" {
+			slog.Info("matched /v3/auth/tokens mock response")
+			var endpoints []map[string]string
+			for i, region := range regions {
+				endpoints = append(endpoints, map[string]string{
+					"id":        fmt.Sprintf("endpoint-%d", i),
+					"interface": "public",
+					"region":    region,
+					"region_id": region,
+					"url":       baseURL(r) + "/dns",
+				})
+			}
+			response = map[string]any{
+				"token": map[string]any{
+					"expires_at": expires.UTC().Format(gophercloud.RFC3339Milli),
+					"project":    map[string]any{"id": mockProjectID, "name": "testTenant"},
+					"catalog":    []map[string]any{{"name": "dns", "type": "dns", "endpoints": endpoints}},
+				},
+			}
+			w.Header().Set("X-Subject-Token", "mock-token")
+			w.WriteHeader(http.StatusCreated)
+		} else {
+			slog.Info("matched /tokens mock response")
+			var endpoints []map[string]string
+			for _, region := range regions {
+				endpoints = append(endpoints, map[string]string{
+					"tenantId":  mockProjectID,
+					"publicURL": baseURL(r) + "/dns",
+					"region":    region,
+					"versionId": "2.0",
+				})
+			}
+			response = map[string]any{
+				"access": map[string]any{
+					"token": map[string]any{
+						"id":      "mock-token",
+						"expires": expires.UTC().Format(gophercloud.RFC3339Milli),
+						"tenant":  map[string]any{"id": mockProjectID, "name": "testTenant"},
 					},
-					"serviceCatalog": [
-						{
-							"name": "dns",
-							"type": "dns",
-							"endpoints": [
-								{
-									"tenantId": "testTenantId",
-									"publicURL": "<URL>",
-									"region": "RegionOne",
-									"versionId": "2.0"
-								}
-							]
-						}
-					]
-				}
-			}`
-		_, err = w.Write([]byte(strings.Replace(jsonResponse, "<URL>", "http://"+r.Host+"/dns", 1)))
-		if err != nil {
-			o.t.Error("failed to write versions response")
+					"serviceCatalog": []map[string]any{{"name": "dns", "type": "dns", "endpoints": endpoints}},
+				},
+			}
+			w.WriteHeader(http.StatusOK)
+		}
+
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			o.t.Error("failed to write tokens response")
+		}
+		return
+	}
+
+	// get a single zone
+	if parts := strings.Split(r.URL.Path, "/"); r.Method == http.MethodGet && len(parts) == 5 && parts[3] == "zones" && parts[4] != "" {
+		slog.Info("matched get single zone mock response")
+
+		o.mu.Lock()
+		o.ZoneGets++
+		o.mu.Unlock()
+
+		idx := slices.IndexFunc(o.Zones, func(z MockZone) bool { return z.ID == parts[4] && z.View == r.Header.Get(viewHeader) })
+		if idx < 0 {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(zoneJSON(o.Zones[idx])); err != nil {
+			o.t.Error("failed to write zone response")
 		}
 		return
 	}
@@ -134,42 +276,69 @@ func (o *OpenstackApiMock) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
+		if o.ForbiddenZoneListing {
+			slog.Info("simulating forbidden zone listing")
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
 		slog.Info("matched /dns/v2/zones mock response")
+		o.mu.Lock()
+		o.ZoneListCalls++
+		o.ZoneListQueries = append(o.ZoneListQueries, r.URL.Query().Get("name"))
+		o.ZoneListHeaders = append(o.ZoneListHeaders, r.Header.Clone())
+		o.ZoneListLimits = append(o.ZoneListLimits, r.URL.Query().Get("limit"))
+		o.zoneListsInFlight++
+		o.MaxZoneListsInFlight = max(o.MaxZoneListsInFlight, o.zoneListsInFlight)
+		o.mu.Unlock()
+		time.Sleep(o.ZoneListDelay)
+		o.mu.Lock()
+		o.zoneListsInFlight--
+		o.mu.Unlock()
+
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
 
 		zoneName := r.URL.Query().Get("name")
 
 		var matchingZones []MockZone
-		if zoneName != "" {
-			for _, z := range o.Zones {
-				if z.Name == zoneName || z.Name == zoneName+"." {
-					matchingZones = append(matchingZones, z)
+		for _, z := range o.Zones {
+			if z.View != r.Header.Get(viewHeader) {
+				continue
+			}
+			if zoneName == "" || strings.EqualFold(z.Name, zoneName) || strings.EqualFold(z.Name, zoneName+".") {
+				matchingZones = append(matchingZones, z)
+			}
+		}
+
+		total := len(matchingZones)
+		links := map[string]string{"self": baseURL(r) + "/dns/v2/zones"}
+		if limit, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && limit > 0 {
+			if marker := r.URL.Query().Get("marker"); marker != "" {
+				for i, z := range matchingZones {
+					if z.ID == marker {
+						matchingZones = matchingZones[i+1:]
+						break
+					}
 				}
 			}
-		} else {
-			matchingZones = o.Zones
+			if len(matchingZones) > limit {
+				matchingZones = matchingZones[:limit]
+				next := r.URL.Query()
+				next.Set("marker", matchingZones[limit-1].ID)
+				links["next"] = baseURL(r) + r.URL.Path + "?" + next.Encode()
+			}
 		}
 
 		var enrichedZones []map[string]interface{}
 		for _, z := range matchingZones {
-			enrichedZones = append(enrichedZones, map[string]interface{}{
-				"id":          z.ID,
-				"name":        z.Name,
-				"email":       "admin@example.com",
-				"ttl":         3600,
-				"serial":      1,
-				"status":      "ACTIVE",
-				"action":      "NONE",
-				"description": "Mock Zone",
-				"type":        "PRIMARY",
-			})
+			enrichedZones = append(enrichedZones, zoneJSON(z))
 		}
 
 		resp := map[string]interface{}{
 			"zones":    enrichedZones,
-			"links":    map[string]string{"self": fmt.Sprintf("http://%s/dns/v2/zones", r.Host)},
-			"metadata": map[string]interface{}{"total_count": len(matchingZones)},
+			"links":    links,
+			"metadata": map[string]interface{}{"total_count": total},
 		}
 
 		if err := json.NewEncoder(w).Encode(resp); err != nil {
@@ -178,6 +347,43 @@ func (o *OpenstackApiMock) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if o.ForbiddenWrites && r.Method != http.MethodGet && strings.HasPrefix(r.URL.Path, "/dns/v2/zones") && strings.Contains(r.URL.Path, "/recordsets") {
+		slog.Info("simulating forbidden recordset write")
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	if o.ReadOnly && r.Method != http.MethodGet && strings.HasPrefix(r.URL.Path, "/dns/v2/zones") && strings.Contains(r.URL.Path, "/recordsets") {
+		slog.Info("simulating read-only maintenance mode")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		if _, err := w.Write([]byte(`{"code": 503, "type": "service_unavailable", "message": "Designate is in maintenance mode, changes are temporarily disabled"}`)); err != nil {
+			o.t.Errorf("failed to write maintenance response: %v", err)
+		}
+		return
+	}
+
+	if (r.Method == http.MethodPost || r.Method == http.MethodPut) && strings.HasPrefix(r.URL.Path, "/dns/v2/zones") && strings.Contains(r.URL.Path, "/recordsets") {
+		time.Sleep(o.WriteDelay)
+
+		o.mu.Lock()
+		if len(o.ConflictingRecords) > 0 {
+			slog.Info("simulating a conflicting recordset write")
+			racing := o.ConflictingRecords[0]
+			o.ConflictingRecords = o.ConflictingRecords[1:]
+			o.applyRacingWrite(r, content, racing)
+			o.mu.Unlock()
+
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusConflict)
+			if _, err := w.Write([]byte(`{"code": 409, "type": "duplicate_recordset", "message": "Duplicate RecordSet"}`)); err != nil {
+				o.t.Errorf("failed to write conflict response: %v", err)
+			}
+			return
+		}
+		o.mu.Unlock()
+	}
+
 	// create recordset
 	if r.Method == http.MethodPost && strings.HasPrefix(r.URL.Path, "/dns/v2/zones") && strings.Contains(r.URL.Path, "/recordsets") {
 		slog.Info("matched create recordset mock response")
@@ -195,15 +401,71 @@ func (o *OpenstackApiMock) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			o.t.Errorf("failed to unmarshal recordset update: %v", err)
 		}
 
-		o.Updates = append(o.Updates, ZoneUpdate{ZoneID: zoneID, Opts: opts})
+		o.mu.Lock()
+		o.Updates = append(o.Updates, ZoneUpdate{ZoneID: zoneID, Opts: opts, View: r.Header.Get(viewHeader)})
+		if o.RejectDuplicateRecordSets && slices.ContainsFunc(o.RecordSets, func(rs MockRecordSet) bool {
+			return rs.ZoneID == zoneID && rs.Name == opts.Name && rs.Type == opts.Type
+		}) {
+			o.mu.Unlock()
+
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusConflict)
+			if _, err := w.Write([]byte(`{"code": 409, "type": "duplicate_recordset", "message": "Duplicate RecordSet"}`)); err != nil {
+				o.t.Errorf("failed to write conflict response: %v", err)
+			}
+			return
+		}
+		created := MockRecordSet{
+			ID:      fmt.Sprintf("%s-created-%d", zoneID, len(o.Updates)),
+			ZoneID:  zoneID,
+			Name:    opts.Name,
+			Type:    opts.Type,
+			Records: opts.Records,
+			States:  slices.Clone(o.WriteStates),
+		}
+		o.RecordSets = append(o.RecordSets, created)
+		o.mu.Unlock()
 
+		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusAccepted)
-		if _, err := w.Write([]byte("{}")); err != nil {
+		if err := json.NewEncoder(w).Encode(recordSetJSON(created, MockRecordSetState{Status: "PENDING", Action: "CREATE"})); err != nil {
 			o.t.Errorf("failed to write recordset response: %v", err)
 		}
 		return
 	}
 
+	// get a single recordset
+	if parts := strings.Split(r.URL.Path, "/"); r.Method == http.MethodGet && len(parts) == 7 && parts[5] == "recordsets" && parts[6] != "" {
+		slog.Info("matched get single recordset mock response")
+		zoneID := parts[4]
+		recordSetID := parts[6]
+
+		o.mu.Lock()
+		o.RecordSetGets++
+		idx := slices.IndexFunc(o.RecordSets, func(rs MockRecordSet) bool {
+			return rs.ZoneID == zoneID && rs.ID == recordSetID
+		})
+		if idx < 0 {
+			o.mu.Unlock()
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		state := MockRecordSetState{Status: "ACTIVE", Action: "NONE"}
+		if len(o.RecordSets[idx].States) > 0 {
+			state = o.RecordSets[idx].States[0]
+			o.RecordSets[idx].States = o.RecordSets[idx].States[1:]
+		}
+		recordSet := o.RecordSets[idx]
+		o.mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(recordSetJSON(recordSet, state)); err != nil {
+			o.t.Error("failed to write recordset response")
+		}
+		return
+	}
+
 	if r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/dns/v2/zones") && strings.Contains(r.URL.Path, "/recordsets") {
 		slog.Info("matched get recordset mock response")
 
@@ -219,28 +481,26 @@ func (o *OpenstackApiMock) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 		var matchingRecordSets = make([]MockRecordSet, 0)
 
+		o.mu.Lock()
 		for idx, recordSet := range o.RecordSets {
-			if recordSet.Name == recordSetName && recordSet.Type == recordSetType && recordSet.ZoneID == zoneID {
+			// Like designate, the name filter ignores case and the trailing dot.
+			nameMatches := recordSetName == "" || strings.EqualFold(strings.TrimSuffix(recordSet.Name, "."), strings.TrimSuffix(recordSetName, "."))
+			if nameMatches && recordSet.Type == recordSetType && recordSet.ZoneID == zoneID {
 				matchingRecordSets = append(matchingRecordSets, o.RecordSets[idx])
 			}
 		}
+		o.mu.Unlock()
 
 		slog.Info("finished matching recordsets", "count", len(matchingRecordSets))
 
 		var enrichedRecordSets []map[string]interface{}
 		for _, rs := range matchingRecordSets {
-			enrichedRecordSets = append(enrichedRecordSets, map[string]interface{}{
-				"id":      rs.ID,
-				"name":    rs.Name,
-				"type":    rs.Type,
-				"records": rs.Records,
-				"zone_id": rs.ZoneID,
-			})
+			enrichedRecordSets = append(enrichedRecordSets, recordSetJSON(rs, MockRecordSetState{Status: "ACTIVE", Action: "NONE"}))
 		}
 
 		resp := map[string]interface{}{
 			"recordsets": enrichedRecordSets,
-			"links":      map[string]string{"self": fmt.Sprintf("http://%s%s", r.Host, r.URL.String())},
+			"links":      map[string]string{"self": baseURL(r) + r.URL.String()},
 			"metadata":   map[string]interface{}{"total_count": len(matchingRecordSets)},
 		}
 
@@ -264,11 +524,16 @@ func (o *OpenstackApiMock) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		zoneID := parts[4]
 		recordSetID := parts[6]
 
+		o.mu.Lock()
 		o.RecordSetDeletes = append(o.RecordSetDeletes, RecordSetDelete{
 			ZoneID:      zoneID,
 			RecordSetID: recordSetID,
 		})
-		w.WriteHeader(http.StatusOK)
+		o.RecordSets = slices.DeleteFunc(o.RecordSets, func(rs MockRecordSet) bool {
+			return rs.ZoneID == zoneID && rs.ID == recordSetID
+		})
+		o.mu.Unlock()
+		w.WriteHeader(http.StatusAccepted)
 		return
 	}
 
@@ -289,11 +554,19 @@ func (o *OpenstackApiMock) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			o.t.Errorf("failed to unmarshal recordset update: %v", err)
 		}
 
+		o.mu.Lock()
 		o.RecordSetPuts = append(o.RecordSetPuts, RecordSetPut{
 			ZoneID:      zoneID,
 			RecordSetID: recordSetID,
 			Opts:        opts,
 		})
+		for idx, rs := range o.RecordSets {
+			if rs.ZoneID == zoneID && rs.ID == recordSetID && opts.Records != nil {
+				o.RecordSets[idx].Records = opts.Records
+				o.RecordSets[idx].States = slices.Clone(o.WriteStates)
+			}
+		}
+		o.mu.Unlock()
 		w.WriteHeader(http.StatusOK)
 		if _, err := w.Write([]byte("{}")); err != nil {
 			o.t.Errorf("failed to write recordset response: %v", err)
@@ -302,6 +575,100 @@ func (o *OpenstackApiMock) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// applyRacingWrite adds the record of a racing challenge to the recordset targeted by the request,
+// creating the recordset for a create. The caller holds the lock.
+func (o *OpenstackApiMock) applyRacingWrite(r *http.Request, content []byte, record string) {
+	parts := strings.Split(r.URL.Path, "/")
+	zoneID := parts[4]
+
+	var idx int
+	if r.Method == http.MethodPost {
+		var opts recordsets.CreateOpts
+		if err := json.Unmarshal(content, &opts); err != nil {
+			o.t.Errorf("failed to unmarshal recordset create: %v", err)
+		}
+		idx = slices.IndexFunc(o.RecordSets, func(rs MockRecordSet) bool {
+			return rs.ZoneID == zoneID && strings.EqualFold(rs.Name, opts.Name) && rs.Type == opts.Type
+		})
+		if idx < 0 {
+			o.RecordSets = append(o.RecordSets, MockRecordSet{
+				ID:     fmt.Sprintf("%s-racing-%d", zoneID, len(o.RecordSets)),
+				ZoneID: zoneID,
+				Name:   opts.Name,
+				Type:   opts.Type,
+			})
+			idx = len(o.RecordSets) - 1
+		}
+	} else {
+		idx = slices.IndexFunc(o.RecordSets, func(rs MockRecordSet) bool {
+			return len(parts) > 6 && rs.ZoneID == zoneID && rs.ID == parts[6]
+		})
+		if idx < 0 {
+			return
+		}
+	}
+
+	o.RecordSets[idx].Records = append(slices.Clone(o.RecordSets[idx].Records), record)
+}
+
+func zoneJSON(z MockZone) map[string]interface{} {
+	zoneType := z.Type
+	if zoneType == "" {
+		zoneType = "PRIMARY"
+	}
+	status := z.Status
+	if status == "" {
+		status = "ACTIVE"
+	}
+	projectID := z.ProjectID
+	if projectID == "" {
+		projectID = mockProjectID
+	}
+
+	return map[string]interface{}{
+		"id":          z.ID,
+		"name":        z.Name,
+		"email":       "admin@example.com",
+		"ttl":         3600,
+		"serial":      1,
+		"status":      status,
+		"action":      "NONE",
+		"description": "Mock Zone",
+		"type":        zoneType,
+		"project_id":  projectID,
+	}
+}
+
+// baseURL is the URL of the mock as seen by the client, over TLS when served by a TLS server.
+func baseURL(r *http.Request) string {
+	if r.TLS != nil {
+		return "https://" + r.Host
+	}
+	return "http://" + r.Host
+}
+
+func recordSetJSON(rs MockRecordSet, state MockRecordSetState) map[string]interface{} {
+	result := map[string]interface{}{
+		"id":      rs.ID,
+		"name":    rs.Name,
+		"type":    rs.Type,
+		"records": rs.Records,
+		"zone_id": rs.ZoneID,
+		"status":  state.Status,
+		"action":  state.Action,
+	}
+	if rs.TTL != 0 {
+		result["ttl"] = rs.TTL
+	}
+	if rs.Description != "" {
+		result["description"] = rs.Description
+	}
+	if !rs.CreatedAt.IsZero() {
+		result["created_at"] = rs.CreatedAt.UTC().Format(gophercloud.RFC3339MilliNoZ)
+	}
+	return result
+}
+
 func CreateMockOpenstackApi(t *testing.T) *OpenstackApiMock {
 	return &OpenstackApiMock{
 		t: t,
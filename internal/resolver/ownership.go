@@ -0,0 +1,20 @@
+package resolver
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/cert-manager/cert-manager/pkg/acme/webhook/apis/acme/v1alpha1"
+)
+
+// ownershipMarkerPrefix starts every TXT value written by ownershipMarker.
+const ownershipMarkerPrefix = "cert-manager-webhook-designate-owner="
+
+// ownershipMarker derives the TXT value marking a recordset as ours. The issuer is identified by the
+// namespace the challenge is solved for and the credentials it uses, hashed so that nothing about
+// the issuer leaks into public DNS.
+func ownershipMarker(ch *v1alpha1.ChallengeRequest, cfg *ChallengeConfig) string {
+	sum := sha256.Sum256([]byte(ch.ResourceNamespace + "/" + cfg.SecretNamespace + "/" + cfg.SecretName))
+
+	return ownershipMarkerPrefix + hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,43 @@
+package resolver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/cert-manager/cert-manager/pkg/acme/webhook/apis/acme/v1alpha1"
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/gophercloud/gophercloud/v2/openstack/dns/v2/zones"
+)
+
+var ErrPreflightFailed = errors.New("the preflight checks of the challenge failed")
+var ErrZoneNotActive = errors.New("the selected zone is not ACTIVE")
+
+// preflight selects the zone of the challenge like matchRecordZone and then checks, before anything
+// is written, that the zone is ACTIVE and PRIMARY, that the record name lies within it and that the
+// credentials may create and delete recordsets in it. The first failing check is returned wrapped
+// in ErrPreflightFailed. The permission check writes a probe recordset like WithCapabilityProbe,
+// once per cloud and zone.
+func (d *designateDnsResolver) preflight(ctx context.Context, ch *v1alpha1.ChallengeRequest, cfg *ChallengeConfig, cloud string, designateClient *gophercloud.ServiceClient) (*zones.Zone, error) {
+	listCtx, cancelList := phaseContext(ctx, d.listTimeout)
+	zone, err := d.matchZone(listCtx, ch, cfg, cloud, designateClient)
+	cancelList()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrPreflightFailed, err)
+	}
+
+	if zone.Status != "" && !strings.EqualFold(zone.Status, zoneStatusActive) {
+		return nil, fmt.Errorf("%w: %w: %s is %s", ErrPreflightFailed, ErrZoneNotActive, enforceTrailingDot(zone.Name), zone.Status)
+	}
+
+	if err := checkRecordZone(ch, cfg, zone); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrPreflightFailed, err)
+	}
+
+	if err := d.probeWrite(ctx, cloud, designateClient, zone); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrPreflightFailed, err)
+	}
+
+	return zone, nil
+}
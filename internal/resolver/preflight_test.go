@@ -0,0 +1,101 @@
+package resolver
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	mockresolver "github.com/rikotsev/cert-manager-webhook-designate/internal/resolver/mock"
+)
+
+func TestDesignateDnsResolver_Preflight(t *testing.T) {
+	tcs := []struct {
+		name            string
+		strategy        string
+		zone            mockresolver.MockZone
+		forbidWrites    bool
+		expectedError   error
+		expectedMessage string
+	}{
+		{
+			name:     "all checks pass",
+			strategy: `{"kind": "ZoneID", "zoneId": "12345"}`,
+			zone:     mockresolver.MockZone{ID: "12345", Name: "example.com."},
+		},
+		{
+			name:            "zone does not exist",
+			strategy:        `{"kind": "ZoneID", "zoneId": "67890"}`,
+			zone:            mockresolver.MockZone{ID: "12345", Name: "example.com."},
+			expectedError:   ErrNoZones,
+			expectedMessage: "no zone with id 67890",
+		},
+		{
+			name:            "zone is not active",
+			strategy:        `{"kind": "ZoneID", "zoneId": "12345"}`,
+			zone:            mockresolver.MockZone{ID: "12345", Name: "example.com.", Status: "PENDING"},
+			expectedError:   ErrZoneNotActive,
+			expectedMessage: "example.com. is PENDING",
+		},
+		{
+			name:            "zone is not primary",
+			strategy:        `{"kind": "ZoneID", "zoneId": "12345"}`,
+			zone:            mockresolver.MockZone{ID: "12345", Name: "example.com.", Type: "SECONDARY"},
+			expectedError:   ErrZoneNotWritable,
+			expectedMessage: "example.com. is a SECONDARY zone",
+		},
+		{
+			name:            "name is not within the zone",
+			strategy:        `{"kind": "ZoneID", "zoneId": "12345"}`,
+			zone:            mockresolver.MockZone{ID: "12345", Name: "example.org."},
+			expectedError:   ErrZoneMismatch,
+			expectedMessage: "cool.example.com. is not within example.org.",
+		},
+		{
+			name:            "writes are not permitted",
+			strategy:        `{"kind": "ZoneID", "zoneId": "12345"}`,
+			zone:            mockresolver.MockZone{ID: "12345", Name: "example.com."},
+			forbidWrites:    true,
+			expectedError:   ErrMissingCapability,
+			expectedMessage: "creating recordsets in example.com.",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			mockApi := mockresolver.CreateMockOpenstackApi(t)
+			mockApi.Zones = []mockresolver.MockZone{tc.zone}
+			mockApi.ForbiddenWrites = tc.forbidWrites
+			resolver := newTestResolver(t, mockApi)
+
+			err := resolver.Present(newChallengeRequest("challenge", "cool.example.com", "example.com", `{
+				"secretName": "foo",
+				"secretNamespace": "bar",
+				"preflight": true,
+				"strategy": `+tc.strategy+`
+			}`))
+			if tc.expectedError != nil {
+				if !errors.Is(err, ErrPreflightFailed) || !errors.Is(err, tc.expectedError) {
+					t.Fatalf("expected errors %v and %v, got %v", ErrPreflightFailed, tc.expectedError, err)
+				}
+				if !strings.Contains(err.Error(), tc.expectedMessage) {
+					t.Errorf("expected error to contain %q, got %v", tc.expectedMessage, err)
+				}
+				if len(mockApi.RecordSets) != 0 {
+					t.Errorf("expected no recordset to be written, got %v", mockApi.RecordSets)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			// The probe recordset is created and removed before the challenge recordset.
+			if len(mockApi.Updates) != 2 || !strings.Contains(mockApi.Updates[0].Opts.Name, "."+capabilityProbeLabel+".") {
+				t.Fatalf("expected the probe and the challenge recordset to be created, got %v", mockApi.Updates)
+			}
+			if len(mockApi.RecordSetDeletes) != 1 || len(mockApi.RecordSets) != 1 {
+				t.Errorf("expected only the challenge recordset to remain, got %d deletes and %v", len(mockApi.RecordSetDeletes), mockApi.RecordSets)
+			}
+		})
+	}
+}
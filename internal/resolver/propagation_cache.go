@@ -0,0 +1,101 @@
+package resolver
+
+import (
+	"sync"
+	"time"
+
+	"github.com/rikotsev/cert-manager-webhook-designate/internal/metrics"
+	"k8s.io/klog/v2"
+)
+
+// WithPropagationCacheTTL remembers for the given time when a zone was last seen propagated to a
+// nameserver, so that the challenges of several SANs in the same zone do not all poll designate or
+// query the self-check nameservers. An observation only counts for writes that happened before it.
+// A TTL of zero or less disables the cache.
+func WithPropagationCacheTTL(ttl time.Duration) Option {
+	return func(d *designateDnsResolver) {
+		d.propagationCache.ttl = ttl
+	}
+}
+
+// designatePool stands in as the nameserver for the propagation wait, as designate only reports a
+// recordset settled once every nameserver of its pool serves it.
+const designatePool = "designate"
+
+// propagationKey identifies the propagation of a zone of a cloud to a nameserver. Designate pushes a
+// zone to its nameservers as a whole, so one name seen propagated tells the same of every name of the
+// zone written before.
+func propagationKey(cloud, zoneId, nameserver string) string {
+	return cloud + "|" + zoneId + "|" + nameserver
+}
+
+// propagationCache holds the time a zone was last observed propagated, by propagationKey. Lookups
+// are counted as hits or misses in metrics.CacheRequests.
+type propagationCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	settled map[string]time.Time
+	// now is replaceable in tests.
+	now func() time.Time
+}
+
+func (c *propagationCache) enabled() bool {
+	return c.ttl > 0
+}
+
+// settledSince reports whether the zone was observed propagated at or after writtenAt, within the TTL.
+func (c *propagationCache) settledSince(key string, writtenAt time.Time) bool {
+	if !c.enabled() {
+		return false
+	}
+
+	c.mu.Lock()
+	observedAt, ok := c.settled[key]
+	if ok && !c.clock().Before(observedAt.Add(c.ttl)) {
+		delete(c.settled, key)
+		ok = false
+	}
+	c.mu.Unlock()
+
+	if !ok || observedAt.Before(writtenAt) {
+		metrics.CacheRequests.WithLabelValues(metrics.CacheOperationPropagation, metrics.CacheResultMiss).Inc()
+		klog.V(4).InfoS("propagation cache miss", "key", key)
+		return false
+	}
+
+	metrics.CacheRequests.WithLabelValues(metrics.CacheOperationPropagation, metrics.CacheResultHit).Inc()
+	klog.V(4).InfoS("propagation cache hit", "key", key, "observedAt", observedAt)
+	return true
+}
+
+func (c *propagationCache) put(key string, observedAt time.Time) {
+	if !c.enabled() {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.settled == nil {
+		c.settled = make(map[string]time.Time)
+	}
+	if observedAt.After(c.settled[key]) {
+		c.settled[key] = observedAt
+	}
+}
+
+// clear drops every entry.
+func (c *propagationCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.settled = nil
+}
+
+func (c *propagationCache) clock() time.Time {
+	if c.now != nil {
+		return c.now()
+	}
+
+	return time.Now()
+}
@@ -0,0 +1,76 @@
+package resolver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	mockresolver "github.com/rikotsev/cert-manager-webhook-designate/internal/resolver/mock"
+)
+
+func TestDesignateDnsResolver_WaitForRecordSet_PropagationCache(t *testing.T) {
+	mockApi := mockresolver.CreateMockOpenstackApi(t)
+	mockApi.Zones = []mockresolver.MockZone{
+		{
+			ID:   "12345",
+			Name: "example.com.",
+		},
+	}
+	mockApi.RecordSets = []mockresolver.MockRecordSet{
+		{
+			ID:      "rs-1",
+			ZoneID:  "12345",
+			Name:    "cool.example.com.",
+			Type:    "TXT",
+			Records: []string{"\"challenge\""},
+		},
+		{
+			ID:      "rs-2",
+			ZoneID:  "12345",
+			Name:    "other.example.com.",
+			Type:    "TXT",
+			Records: []string{"\"challenge\""},
+		},
+	}
+	resolver := newTestResolver(t, mockApi)
+	WithPropagationCacheTTL(10 * time.Second)(resolver)
+
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	resolver.propagationCache.now = func() time.Time { return now }
+
+	designateClient, _, cloud, err := resolver.createDesignateClient(context.Background(), newChallengeRequest("challenge", "cool.example.com", "example.com", `{
+		"secretName": "foo",
+		"secretNamespace": "bar",
+		"strategy": {
+			"kind": "SOA"
+		}
+	}`))
+	if err != nil {
+		t.Fatalf("failed to create the designate client: %v", err)
+	}
+
+	steps := []struct {
+		name         string
+		recordSetId  string
+		advance      time.Duration
+		expectedGets int
+	}{
+		{name: "first check polls", recordSetId: "rs-1", expectedGets: 1},
+		{name: "repeated check within the window is cached", recordSetId: "rs-1", expectedGets: 1},
+		{name: "other name of the zone shares the observation", recordSetId: "rs-2", expectedGets: 1},
+		{name: "write after the observation polls again", recordSetId: "rs-2", advance: time.Second, expectedGets: 2},
+		{name: "check after the window polls again", recordSetId: "rs-1", advance: 10 * time.Second, expectedGets: 3},
+	}
+
+	for _, step := range steps {
+		now = now.Add(step.advance)
+
+		if err := resolver.waitForRecordSet(context.Background(), designateClient, cloud, "12345", step.recordSetId, recordSetWaitTimeout); err != nil {
+			t.Fatalf("%s: unexpected error: %v", step.name, err)
+		}
+
+		if mockApi.RecordSetGets != step.expectedGets {
+			t.Errorf("%s: expected %d recordset gets, got %d", step.name, step.expectedGets, mockApi.RecordSetGets)
+		}
+	}
+}
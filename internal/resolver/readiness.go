@@ -0,0 +1,132 @@
+package resolver
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gophercloud/gophercloud/v2"
+)
+
+const defaultReadinessFailureThreshold = 5
+
+// readinessRecovery is how long the resolver reports itself not ready after the last failure. A pod
+// that is not ready receives no challenges to recover with, so it becomes ready again on its own
+// and counts anew.
+const readinessRecovery = time.Minute
+
+var ErrNotReady = errors.New("the resolver keeps failing to initialize designate clients")
+var ErrNotInitialized = errors.New("the resolver has not been initialized")
+
+// ReadinessChecker is implemented by solvers that can report whether they are fit to serve challenges.
+type ReadinessChecker interface {
+	Ready() error
+}
+
+var _ ReadinessChecker = (*designateDnsResolver)(nil)
+
+// WithReadinessFailureThreshold sets how many consecutive challenges have to fail to initialize a
+// designate client, e.g. because of broken credentials, before the resolver reports itself not ready.
+// Only failures talking to keystone or its service catalog count, see isCloudFailure. A threshold
+// of zero or less never reports not ready.
+func WithReadinessFailureThreshold(threshold int) Option {
+	return func(d *designateDnsResolver) {
+		d.readiness.threshold = threshold
+	}
+}
+
+// Ready returns ErrNotInitialized until Initialize has built the kube client, ErrShuttingDown once
+// its stop channel is closed, and ErrNotReady while the last challenges all failed to reach the
+// cloud. It recovers from the latter as soon as a challenge gets past the initialization again, or
+// once readinessRecovery has passed since the last failure.
+func (d *designateDnsResolver) Ready() error {
+	if !d.initialized.Load() {
+		return ErrNotInitialized
+	}
+	if d.challenges.isStopping() {
+		return ErrShuttingDown
+	}
+
+	return d.readiness.ready()
+}
+
+// readinessTracker counts consecutive challenges that failed to authenticate or reach the cloud
+// during client initialization.
+type readinessTracker struct {
+	mu                  sync.Mutex
+	threshold           int
+	consecutiveFailures int
+	lastErr             error
+	lastFailure         time.Time
+	// now is replaceable in tests.
+	now func() time.Time
+}
+
+func (r *readinessTracker) record(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if isCloudFailure(err) {
+		r.consecutiveFailures++
+		r.lastErr = err
+		r.lastFailure = r.clock()
+		return
+	}
+	// Other initialization errors, e.g. a broken config or a missing secret, only concern the
+	// issuer of the challenge and tell nothing about the cloud.
+	if errors.Is(err, ErrFailedDesignateClientInitialization) {
+		return
+	}
+
+	r.consecutiveFailures = 0
+	r.lastErr = nil
+}
+
+func (r *readinessTracker) ready() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.threshold <= 0 || r.consecutiveFailures < r.threshold {
+		return nil
+	}
+
+	if !r.clock().Before(r.lastFailure.Add(readinessRecovery)) {
+		r.consecutiveFailures = 0
+		r.lastErr = nil
+		return nil
+	}
+
+	return fmt.Errorf("%w: %d consecutive failures, last: %w", ErrNotReady, r.consecutiveFailures, r.lastErr)
+}
+
+func (r *readinessTracker) clock() time.Time {
+	if r.now != nil {
+		return r.now()
+	}
+
+	return time.Now()
+}
+
+// isCloudFailure reports whether the client initialization failed reaching keystone or looking up
+// designate in its service catalog: a transport error, an untrusted certificate, a server error or a
+// catalog without a DNS endpoint. Rejected credentials, e.g. a 401 for the wrong password of an
+// issuer, only concern that issuer.
+func isCloudFailure(err error) bool {
+	if !errors.Is(err, ErrFailedDesignateClientInitialization) {
+		return false
+	}
+
+	var unexpected gophercloud.ErrUnexpectedResponseCode
+	if errors.As(err, &unexpected) {
+		return unexpected.Actual >= http.StatusInternalServerError
+	}
+
+	var certErr *tls.CertificateVerificationError
+	var endpointNotFound *gophercloud.ErrEndpointNotFound
+	var netErr net.Error
+	return errors.As(err, &certErr) || errors.As(err, &endpointNotFound) || errors.As(err, &netErr)
+}
@@ -0,0 +1,203 @@
+package resolver
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gophercloud/gophercloud/v2"
+	mockresolver "github.com/rikotsev/cert-manager-webhook-designate/internal/resolver/mock"
+)
+
+func TestDesignateDnsResolver_Ready(t *testing.T) {
+	mockApi := mockresolver.CreateMockOpenstackApi(t)
+	mockApi.Zones = []mockresolver.MockZone{
+		{
+			ID:   "12345",
+			Name: "example.com.",
+		},
+	}
+	mockApi.ErrorAuthenticating = true
+	mockApi.AuthenticationStatus = http.StatusServiceUnavailable
+	resolver := newTestResolver(t, mockApi)
+	WithReadinessFailureThreshold(3)(resolver)
+
+	ch := newChallengeRequest("challenge", "cool.example.com", "example.com", `{
+		"secretName": "foo",
+		"secretNamespace": "bar",
+		"strategy": {
+			"kind": "SOA"
+		}
+	}`)
+
+	for i := range 3 {
+		if err := resolver.Ready(); err != nil {
+			t.Fatalf("expected to be ready after %d failures, got %v", i, err)
+		}
+
+		if err := resolver.Present(ch); !errors.Is(err, ErrFailedDesignateClientInitialization) {
+			t.Fatalf("expected error %v, got %v", ErrFailedDesignateClientInitialization, err)
+		}
+	}
+
+	if err := resolver.Ready(); !errors.Is(err, ErrNotReady) {
+		t.Errorf("expected error %v after 3 failures, got %v", ErrNotReady, err)
+	}
+
+	mockApi.ErrorAuthenticating = false
+	if err := resolver.Present(ch); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if err := resolver.Ready(); err != nil {
+		t.Errorf("expected to be ready again after a success, got %v", err)
+	}
+}
+
+func TestDesignateDnsResolver_Ready_IgnoresIssuerErrors(t *testing.T) {
+	mockApi := mockresolver.CreateMockOpenstackApi(t)
+	mockApi.Zones = []mockresolver.MockZone{
+		{
+			ID:   "12345",
+			Name: "example.com.",
+		},
+	}
+	resolver := newTestResolver(t, mockApi)
+	WithReadinessFailureThreshold(2)(resolver)
+	WithIssuerNamespaceEnforcement(true)(resolver)
+
+	ch := newChallengeRequest("challenge", "cool.example.com", "example.com", `{
+		"secretName": "foo",
+		"secretNamespace": "bar",
+		"strategy": {
+			"kind": "SOA"
+		}
+	}`)
+	ch.ResourceNamespace = "bar"
+
+	issuerErrors := []struct {
+		name   string
+		config string
+		ns     string
+	}{
+		{name: "unparseable config", config: `{"strategy": 1}`, ns: "bar"},
+		{name: "missing secret", config: `{"secretName": "missing", "secretNamespace": "bar", "strategy": {"kind": "SOA"}}`, ns: "bar"},
+		{name: "namespace mismatch", config: `{"secretName": "foo", "secretNamespace": "bar", "strategy": {"kind": "SOA"}}`, ns: "other"},
+		{name: "zone mismatch", config: `{"secretName": "foo", "secretNamespace": "bar", "strategy": {"kind": "ZoneName", "zoneName": "example.org"}}`, ns: "bar"},
+	}
+	presentIssuerErrors := func() {
+		for _, tc := range issuerErrors {
+			failing := newChallengeRequest("challenge", "cool.example.com", "example.com", tc.config)
+			failing.ResourceNamespace = tc.ns
+			if err := resolver.Present(failing); !errors.Is(err, ErrFailedDesignateClientInitialization) {
+				t.Fatalf("%s: expected error %v, got %v", tc.name, ErrFailedDesignateClientInitialization, err)
+			}
+		}
+	}
+
+	presentIssuerErrors()
+	// A wrong password of an issuer is rejected by keystone with a 401.
+	mockApi.ErrorAuthenticating = true
+	for range 3 {
+		if err := resolver.Present(ch); !errors.Is(err, ErrFailedDesignateClientInitialization) {
+			t.Fatalf("expected error %v, got %v", ErrFailedDesignateClientInitialization, err)
+		}
+	}
+	if err := resolver.Ready(); err != nil {
+		t.Fatalf("expected issuer errors not to count, got %v", err)
+	}
+
+	mockApi.AuthenticationStatus = http.StatusBadGateway
+	for range 2 {
+		if err := resolver.Present(ch); !errors.Is(err, ErrFailedDesignateClientInitialization) {
+			t.Fatalf("expected error %v, got %v", ErrFailedDesignateClientInitialization, err)
+		}
+		presentIssuerErrors()
+	}
+
+	if err := resolver.Ready(); !errors.Is(err, ErrNotReady) {
+		t.Errorf("expected issuer errors not to reset the cloud failures, got %v", err)
+	}
+}
+
+func TestDesignateDnsResolver_Ready_Recovers(t *testing.T) {
+	mockApi := mockresolver.CreateMockOpenstackApi(t)
+	mockApi.ErrorAuthenticating = true
+	mockApi.AuthenticationStatus = http.StatusServiceUnavailable
+	resolver := newTestResolver(t, mockApi)
+	WithReadinessFailureThreshold(2)(resolver)
+
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	resolver.readiness.now = func() time.Time { return now }
+
+	ch := newChallengeRequest("challenge", "cool.example.com", "example.com", `{
+		"secretName": "foo",
+		"secretNamespace": "bar",
+		"strategy": {
+			"kind": "SOA"
+		}
+	}`)
+	for range 2 {
+		if err := resolver.Present(ch); !errors.Is(err, ErrFailedDesignateClientInitialization) {
+			t.Fatalf("expected error %v, got %v", ErrFailedDesignateClientInitialization, err)
+		}
+	}
+
+	now = now.Add(readinessRecovery - time.Second)
+	if err := resolver.Ready(); !errors.Is(err, ErrNotReady) {
+		t.Fatalf("expected error %v within the recovery time, got %v", ErrNotReady, err)
+	}
+
+	now = now.Add(time.Second)
+	if err := resolver.Ready(); err != nil {
+		t.Fatalf("expected to be ready again after the recovery time, got %v", err)
+	}
+
+	// The failures are counted anew.
+	if err := resolver.Present(ch); !errors.Is(err, ErrFailedDesignateClientInitialization) {
+		t.Fatalf("expected error %v, got %v", ErrFailedDesignateClientInitialization, err)
+	}
+	if err := resolver.Ready(); err != nil {
+		t.Errorf("expected a single new failure not to reach the threshold, got %v", err)
+	}
+}
+
+func TestIsCloudFailure(t *testing.T) {
+	tcs := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{name: "unauthorized", err: gophercloud.ErrUnexpectedResponseCode{Actual: http.StatusUnauthorized}, expected: false},
+		{name: "forbidden", err: gophercloud.ErrUnexpectedResponseCode{Actual: http.StatusForbidden}, expected: false},
+		{name: "server error", err: gophercloud.ErrUnexpectedResponseCode{Actual: http.StatusServiceUnavailable}, expected: true},
+		{name: "transport error", err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}, expected: true},
+		{name: "untrusted certificate", err: &tls.CertificateVerificationError{Err: errors.New("unknown authority")}, expected: true},
+		{name: "no DNS endpoint in the catalog", err: &gophercloud.ErrEndpointNotFound{}, expected: true},
+		{name: "config error", err: ErrMissingAuthValue, expected: false},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			err := fmt.Errorf("%w: %w", ErrFailedDesignateClientInitialization, tc.err)
+			if got := isCloudFailure(err); got != tc.expected {
+				t.Errorf("expected %t, got %t", tc.expected, got)
+			}
+		})
+	}
+
+	if isCloudFailure(gophercloud.ErrUnexpectedResponseCode{Actual: http.StatusServiceUnavailable}) {
+		t.Error("expected errors past the client initialization not to count")
+	}
+}
+
+func TestDesignateDnsResolver_Ready_BeforeInitialize(t *testing.T) {
+	resolver := New().(ReadinessChecker)
+	if err := resolver.Ready(); !errors.Is(err, ErrNotInitialized) {
+		t.Errorf("expected error %v before Initialize, got %v", ErrNotInitialized, err)
+	}
+}
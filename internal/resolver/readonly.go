@@ -0,0 +1,33 @@
+package resolver
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gophercloud/gophercloud/v2"
+)
+
+var ErrDesignateReadOnly = errors.New("designate is in read-only maintenance mode, records cannot be changed until it ends")
+
+// readOnlyMarkers are looked for, case-insensitively, in the body of a 503 response to a write.
+var readOnlyMarkers = []string{"maintenance", "read-only", "read only", "readonly"}
+
+// readOnlyAware marks errors of writes rejected because designate is in maintenance with
+// ErrDesignateReadOnly, so they are not mistaken for misconfiguration. Other errors are returned as is.
+func readOnlyAware(err error) error {
+	var unexpected gophercloud.ErrUnexpectedResponseCode
+	if !errors.As(err, &unexpected) || unexpected.Actual != http.StatusServiceUnavailable {
+		return err
+	}
+
+	body := strings.ToLower(string(unexpected.Body))
+	for _, marker := range readOnlyMarkers {
+		if strings.Contains(body, marker) {
+			return fmt.Errorf("%w: %w", ErrDesignateReadOnly, err)
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,162 @@
+package resolver
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/gophercloud/gophercloud/v2/openstack"
+	"github.com/gophercloud/gophercloud/v2/openstack/dns/v2/recordsets"
+	"github.com/gophercloud/gophercloud/v2/openstack/dns/v2/zones"
+	"k8s.io/klog/v2"
+)
+
+// challengeKeyPattern matches ACME DNS-01 challenge keys, the unpadded base64url encoding of a
+// SHA-256 digest.
+var challengeKeyPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{43}$`)
+
+// WithStartupReconciliation removes, when the webhook starts, challenge recordsets orphaned by an
+// earlier run that stopped mid-challenge: TXT recordsets carrying an ownership marker that were last
+// changed longer than olderThan ago. Only recordsets holding nothing but the marker and challenge
+// keys are deleted. As no issuer is known at startup, the zones are listed with the provider client
+// of WithProviderClient or else with ambient credentials. Zero disables the reconciliation.
+func WithStartupReconciliation(olderThan time.Duration) Option {
+	return func(d *designateDnsResolver) {
+		d.reconcileOlderThan = olderThan
+	}
+}
+
+// startReconciliation reconciles orphaned recordsets in the background until done or stopped.
+func (d *designateDnsResolver) startReconciliation(stopCh <-chan struct{}) {
+	if d.reconcileOlderThan <= 0 {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	d.reconciliation.Add(1)
+	go func() {
+		defer d.reconciliation.Done()
+		defer cancel()
+
+		go func() {
+			select {
+			case <-stopCh:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+
+		if err := d.reconcileOrphans(ctx); err != nil {
+			klog.ErrorS(err, "failed to reconcile orphaned challenge recordsets")
+		}
+	}()
+}
+
+// reconcileOrphans deletes the orphaned challenge recordsets in every primary zone visible to the
+// startup credentials.
+func (d *designateDnsResolver) reconcileOrphans(ctx context.Context) error {
+	designateClient, err := d.startupDesignateClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	cutoff := time.Now().Add(-d.reconcileOlderThan)
+	listed, deleted := 0, 0
+	_, err = d.eachZonePage(ctx, designateClient, zones.ListOpts{}, func(pageZones []zones.Zone) (bool, error) {
+		for _, zone := range pageZones {
+			listed++
+			if zone.Type != "" && !strings.EqualFold(zone.Type, zoneTypePrimary) {
+				continue
+			}
+
+			zoneDeleted, err := reconcileZone(ctx, designateClient, zone, cutoff)
+			deleted += zoneDeleted
+			if err != nil {
+				return false, err
+			}
+		}
+		return true, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	klog.V(2).InfoS("reconciled orphaned challenge recordsets", "zones", listed, "deleted", deleted)
+	return nil
+}
+
+// reconcileZone deletes the orphaned challenge recordsets of the zone and returns how many it deleted.
+func reconcileZone(ctx context.Context, designateClient *gophercloud.ServiceClient, zone zones.Zone, cutoff time.Time) (int, error) {
+	page, err := recordsets.ListByZone(designateClient, zone.ID, recordsets.ListOpts{Type: "TXT"}).AllPages(ctx)
+	if err != nil {
+		return 0, err
+	}
+	allRecordSets, err := recordsets.ExtractRecordSets(page)
+	if err != nil {
+		return 0, err
+	}
+
+	deleted := 0
+	for _, rs := range allRecordSets {
+		if !isOrphanedChallenge(rs, cutoff) {
+			continue
+		}
+
+		if err := recordsets.Delete(ctx, designateClient, zone.ID, rs.ID).ExtractErr(); err != nil {
+			return deleted, readOnlyAware(err)
+		}
+		klog.V(2).InfoS("deleted orphaned challenge recordset", "zone", zone.Name, "name", rs.Name, "recordsetId", rs.ID)
+		deleted++
+	}
+
+	return deleted, nil
+}
+
+// isOrphanedChallenge reports whether the recordset carries an ownership marker, holds nothing but
+// challenge keys besides it, if anything, and was last changed before the cutoff.
+func isOrphanedChallenge(rs recordsets.RecordSet, cutoff time.Time) bool {
+	changed := rs.UpdatedAt
+	if changed.IsZero() {
+		changed = rs.CreatedAt
+	}
+	if changed.IsZero() || !changed.Before(cutoff) {
+		return false
+	}
+
+	marked, foreign := false, false
+	for _, rec := range rs.Records {
+		switch value := stripQuotes(rec); {
+		case strings.HasPrefix(value, ownershipMarkerPrefix):
+			marked = true
+		case !challengeKeyPattern.MatchString(value):
+			foreign = true
+		}
+	}
+	if marked && foreign {
+		klog.V(4).InfoS("keeping stale marked recordset with foreign records", "name", rs.Name, "recordsetId", rs.ID)
+	}
+
+	return marked && !foreign
+}
+
+// startupDesignateClient returns the designate client for the startup reconciliation, which has no
+// challenge and therefore no credentials secret to go by.
+func (d *designateDnsResolver) startupDesignateClient(ctx context.Context) (*gophercloud.ServiceClient, error) {
+	if d.providerClient != nil {
+		return openstack.NewDNSV2(d.providerClient, d.endpointOpts)
+	}
+
+	authCfg, err := ambientAuthConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := d.tokenCache.authenticatedClient(ctx, authCfg, authCfg.cloudIdentity(), false)
+	if err != nil {
+		return nil, err
+	}
+
+	return newDesignateClient(client, authCfg)
+}
@@ -0,0 +1,69 @@
+package resolver
+
+import (
+	"context"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/gophercloud/gophercloud/v2/openstack"
+	mockresolver "github.com/rikotsev/cert-manager-webhook-designate/internal/resolver/mock"
+	"k8s.io/client-go/rest"
+)
+
+func TestDesignateDnsResolver_StartupReconciliation(t *testing.T) {
+	const (
+		marker = ownershipMarkerPrefix + "0123456789abcdef"
+		key    = "LoqXcYV8q5ONbJQxbmR7SCTNo3tiAXDfowyjxAjEuX0"
+	)
+	stale, fresh := time.Now().Add(-2*time.Hour), time.Now().Add(-5*time.Minute)
+
+	mockApi := mockresolver.CreateMockOpenstackApi(t)
+	mockApi.Zones = []mockresolver.MockZone{
+		{ID: "12345", Name: "example.com."},
+		{ID: "67890", Name: "example.org.", Type: "SECONDARY"},
+	}
+	mockApi.RecordSets = []mockresolver.MockRecordSet{
+		{ID: "stale-marked", ZoneID: "12345", Name: "_acme-challenge.a.example.com.", Type: "TXT", Records: []string{key, marker}, CreatedAt: stale},
+		{ID: "stale-marker-only", ZoneID: "12345", Name: "_acme-challenge.b.example.com.", Type: "TXT", Records: []string{`"` + marker + `"`}, CreatedAt: stale},
+		{ID: "fresh-marked", ZoneID: "12345", Name: "_acme-challenge.c.example.com.", Type: "TXT", Records: []string{key, marker}, CreatedAt: fresh},
+		{ID: "stale-unmarked", ZoneID: "12345", Name: "_acme-challenge.d.example.com.", Type: "TXT", Records: []string{key}, CreatedAt: stale},
+		{ID: "stale-marked-foreign", ZoneID: "12345", Name: "_acme-challenge.e.example.com.", Type: "TXT", Records: []string{key, marker, "verification=1"}, CreatedAt: stale},
+		{ID: "stale-marked-secondary", ZoneID: "67890", Name: "_acme-challenge.example.org.", Type: "TXT", Records: []string{key, marker}, CreatedAt: stale},
+	}
+	openstackMock := httptest.NewServer(mockApi)
+	defer openstackMock.Close()
+
+	providerClient, err := openstack.AuthenticatedClient(context.Background(), gophercloud.AuthOptions{
+		IdentityEndpoint: openstackMock.URL,
+		Username:         "john-doe",
+		Password:         "secretpass",
+		TenantID:         "testTenantId",
+	})
+	if err != nil {
+		t.Fatalf("failed to authenticate against the mock: %v", err)
+	}
+
+	resolver := New(
+		WithProviderClient(providerClient, gophercloud.EndpointOpts{Region: "RegionOne"}),
+		WithStartupReconciliation(time.Hour),
+	).(*designateDnsResolver)
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	if err := resolver.Initialize(&rest.Config{Host: "https://kubernetes.invalid"}, stopCh); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	resolver.reconciliation.Wait()
+
+	var deleted []string
+	for _, del := range mockApi.RecordSetDeletes {
+		deleted = append(deleted, del.RecordSetID)
+	}
+	slices.Sort(deleted)
+	if expected := []string{"stale-marked", "stale-marker-only"}; !slices.Equal(deleted, expected) {
+		t.Errorf("expected the recordsets %v to be deleted, got %v", expected, deleted)
+	}
+}
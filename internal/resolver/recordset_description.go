@@ -0,0 +1,99 @@
+package resolver
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/cert-manager/cert-manager/pkg/acme/webhook/apis/acme/v1alpha1"
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/gophercloud/gophercloud/v2/openstack/dns/v2/recordsets"
+	"github.com/gophercloud/gophercloud/v2/openstack/utils"
+	"k8s.io/klog/v2"
+)
+
+// descriptionMinorVersion is the minor version of the DNS v2 API from which recordset descriptions
+// are accepted. Older clouds reject writes carrying one.
+const descriptionMinorVersion = 1
+
+// managedByDescription starts the description of the recordsets the webhook creates unless the
+// config sets one, telling them apart from recordsets created by hand.
+const managedByDescription = "managed-by: cert-manager-webhook-designate"
+
+// managedDescription is the description of a recordset created for the challenge without a
+// configured description.
+func managedDescription(ch *v1alpha1.ChallengeRequest) string {
+	if ch.UID == "" {
+		return managedByDescription
+	}
+
+	return fmt.Sprintf("%s, challenge %s", managedByDescription, ch.UID)
+}
+
+// isManagedRecordSet reports whether the recordset was created by the webhook for the issuer of
+// the challenge: its description is the managed or the configured one or, with the ownership marker,
+// it carries the marker.
+func isManagedRecordSet(ch *v1alpha1.ChallengeRequest, cfg *ChallengeConfig, rs recordsets.RecordSet) bool {
+	if strings.HasPrefix(rs.Description, managedByDescription) {
+		return true
+	}
+	if cfg.RecordSetDescription != "" && rs.Description == cfg.RecordSetDescription {
+		return true
+	}
+
+	return cfg.OwnershipMarker && slices.ContainsFunc(rs.Records, func(rec string) bool {
+		return sameRecordValue(rec, ownershipMarker(ch, cfg))
+	})
+}
+
+// createDescription returns the description of a recordset created for the challenge, see
+// recordSetDescription.
+func (d *designateDnsResolver) createDescription(ctx context.Context, ch *v1alpha1.ChallengeRequest, cfg *ChallengeConfig, cloud string, designateClient *gophercloud.ServiceClient) string {
+	description := cfg.RecordSetDescription
+	if description == "" {
+		description = managedDescription(ch)
+	}
+
+	return d.recordSetDescription(ctx, description, cloud, designateClient)
+}
+
+// recordSetDescription returns the description if the cloud accepts it, and an empty description
+// otherwise, so that older clouds get recordsets without one.
+func (d *designateDnsResolver) recordSetDescription(ctx context.Context, description, cloud string, designateClient *gophercloud.ServiceClient) string {
+	if description == "" {
+		return ""
+	}
+
+	if !d.supportsDescription(ctx, cloud, designateClient) {
+		klog.V(2).InfoS("omitting the recordset description, the DNS API does not support it", "cloud", cloud)
+		return ""
+	}
+
+	return description
+}
+
+// supportsDescription reports whether the DNS API of the cloud advertises a version accepting
+// recordset descriptions. The answer is remembered per cloud; failed discoveries are retried.
+func (d *designateDnsResolver) supportsDescription(ctx context.Context, cloud string, designateClient *gophercloud.ServiceClient) bool {
+	if supported, ok := d.descriptionSupport.Load(cloud); ok {
+		return supported.(bool)
+	}
+
+	versions, err := utils.GetServiceVersions(ctx, designateClient.ProviderClient, designateClient.Endpoint, true)
+	if err != nil {
+		klog.V(2).InfoS("failed to discover the DNS API versions", "cloud", cloud, "err", err)
+		return false
+	}
+
+	supported := false
+	for _, version := range versions {
+		if version.Major > 2 || (version.Major == 2 && version.Minor >= descriptionMinorVersion) {
+			supported = true
+			break
+		}
+	}
+
+	d.descriptionSupport.Store(cloud, supported)
+	return supported
+}
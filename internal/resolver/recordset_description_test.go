@@ -0,0 +1,194 @@
+package resolver
+
+import (
+	"fmt"
+	"testing"
+
+	mockresolver "github.com/rikotsev/cert-manager-webhook-designate/internal/resolver/mock"
+)
+
+func TestDesignateDnsResolver_RecordSetDescription(t *testing.T) {
+	tcs := []struct {
+		name                string
+		dnsVersions         []string
+		expectedDescription string
+	}{
+		{
+			name:                "supported version",
+			dnsVersions:         []string{"v2.0", "v2.1"},
+			expectedDescription: "issued by prod",
+		},
+		{
+			name:        "unsupported version",
+			dnsVersions: []string{"v2.0"},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			mockApi := mockresolver.CreateMockOpenstackApi(t)
+			mockApi.Zones = []mockresolver.MockZone{
+				{
+					ID:   "12345",
+					Name: "example.com.",
+				},
+			}
+			mockApi.DNSVersions = tc.dnsVersions
+			resolver := newTestResolver(t, mockApi)
+			config := `{
+				"secretName": "foo",
+				"secretNamespace": "bar",
+				"recordSetDescription": "issued by prod",
+				"strategy": {"kind": "BestEffort"}
+			}`
+
+			for _, key := range []string{"first", "second"} {
+				if err := resolver.Present(newChallengeRequest(key, "cool.example.com", "example.com", config)); err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+			}
+
+			if len(mockApi.Updates) != 1 || len(mockApi.RecordSetPuts) != 1 {
+				t.Fatalf("expected one create and one update, got %d creates and %d updates", len(mockApi.Updates), len(mockApi.RecordSetPuts))
+			}
+			if got := mockApi.Updates[0].Opts.Description; got != tc.expectedDescription {
+				t.Errorf("expected the create to carry the description %q, got %q", tc.expectedDescription, got)
+			}
+
+			put := mockApi.RecordSetPuts[0].Opts.Description
+			if tc.expectedDescription == "" && put != nil {
+				t.Errorf("expected the update to carry no description, got %q", *put)
+			}
+			if tc.expectedDescription != "" && (put == nil || *put != tc.expectedDescription) {
+				t.Errorf("expected the update to carry the description %q, got %v", tc.expectedDescription, put)
+			}
+		})
+	}
+}
+
+func TestDesignateDnsResolver_RecordSetDescription_Managed(t *testing.T) {
+	mockApi := mockresolver.CreateMockOpenstackApi(t)
+	mockApi.Zones = []mockresolver.MockZone{
+		{
+			ID:   "12345",
+			Name: "example.com.",
+		},
+	}
+	mockApi.DNSVersions = []string{"v2.0", "v2.1"}
+	resolver := newTestResolver(t, mockApi)
+
+	ch := newChallengeRequest("challenge", "cool.example.com", "example.com", `{
+		"secretName": "foo",
+		"secretNamespace": "bar",
+		"strategy": {"kind": "BestEffort"}
+	}`)
+	ch.UID = "challenge-uid"
+	if err := resolver.Present(ch); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(mockApi.Updates) != 1 {
+		t.Fatalf("expected one create, got %d", len(mockApi.Updates))
+	}
+	expected := "managed-by: cert-manager-webhook-designate, challenge challenge-uid"
+	if got := mockApi.Updates[0].Opts.Description; got != expected {
+		t.Errorf("expected the create to carry the description %q, got %q", expected, got)
+	}
+}
+
+func TestDesignateDnsResolver_CleanUp_SafeCleanup(t *testing.T) {
+	tcs := []struct {
+		name            string
+		safeCleanup     bool
+		description     string
+		ownershipMarker bool
+		expectedDeletes int
+	}{
+		{
+			name:            "unmanaged recordset without safeCleanup",
+			expectedDeletes: 1,
+		},
+		{
+			name:            "unmanaged recordset",
+			safeCleanup:     true,
+			expectedDeletes: 0,
+		},
+		{
+			name:            "recordset described as managed",
+			safeCleanup:     true,
+			description:     "managed-by: cert-manager-webhook-designate, challenge challenge-uid",
+			expectedDeletes: 1,
+		},
+		{
+			name:            "recordset with the configured description",
+			safeCleanup:     true,
+			description:     "issued by prod",
+			expectedDeletes: 1,
+		},
+		{
+			name:            "recordset with another description",
+			safeCleanup:     true,
+			description:     "added by hand",
+			expectedDeletes: 0,
+		},
+		{
+			name:            "recordset with the ownership marker",
+			safeCleanup:     true,
+			ownershipMarker: true,
+			expectedDeletes: 1,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			config := fmt.Sprintf(`{
+				"secretName": "foo",
+				"secretNamespace": "bar",
+				"recordSetDescription": "issued by prod",
+				"safeCleanup": %t,
+				"ownershipMarker": %t,
+				"strategy": {"kind": "BestEffort"}
+			}`, tc.safeCleanup, tc.ownershipMarker)
+			ch := newChallengeRequest("challenge", "cool.example.com", "example.com", config)
+
+			records := []string{`"challenge"`}
+			if tc.ownershipMarker {
+				cfg, err := ParseConfig(ch.Config)
+				if err != nil {
+					t.Fatalf("expected no error parsing the config, got %v", err)
+				}
+				records = append(records, ownershipMarker(ch, cfg))
+			}
+
+			mockApi := mockresolver.CreateMockOpenstackApi(t)
+			mockApi.Zones = []mockresolver.MockZone{
+				{
+					ID:   "12345",
+					Name: "example.com.",
+				},
+			}
+			mockApi.RecordSets = []mockresolver.MockRecordSet{
+				{
+					ID:          "rs-1",
+					ZoneID:      "12345",
+					Name:        "cool.example.com.",
+					Type:        "TXT",
+					Records:     records,
+					Description: tc.description,
+				},
+			}
+			resolver := newTestResolver(t, mockApi)
+
+			if err := resolver.CleanUp(ch); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if len(mockApi.RecordSetDeletes) != tc.expectedDeletes {
+				t.Errorf("expected %d deletes, got %d", tc.expectedDeletes, len(mockApi.RecordSetDeletes))
+			}
+			if len(mockApi.RecordSetPuts) != 0 {
+				t.Errorf("expected no updates, got %d", len(mockApi.RecordSetPuts))
+			}
+		})
+	}
+}
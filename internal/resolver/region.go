@@ -0,0 +1,191 @@
+package resolver
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/gophercloud/gophercloud/v2"
+	tokens2 "github.com/gophercloud/gophercloud/v2/openstack/identity/v2/tokens"
+	tokens3 "github.com/gophercloud/gophercloud/v2/openstack/identity/v3/tokens"
+	"k8s.io/klog/v2"
+)
+
+var ErrUnknownRegion = errors.New("the configured region is not in the service catalog")
+var ErrAmbiguousRegion = errors.New("no region is configured and the service catalog has several DNS regions")
+
+// dnsServiceType is the service catalog type of designate endpoints.
+const dnsServiceType = "dns"
+
+// RegionValidation controls what happens when a credential's region is not found in its service catalog.
+type RegionValidation string
+
+const (
+	// RegionValidationOff uses the configured region as is.
+	RegionValidationOff RegionValidation = ""
+	// RegionValidationWarn logs unknown regions, suggesting the closest catalog region, and carries on.
+	RegionValidationWarn RegionValidation = "warn"
+	// RegionValidationError fails the challenge with ErrUnknownRegion for unknown regions.
+	RegionValidationError RegionValidation = "error"
+)
+
+// WithRegionValidation checks, on first use of a credentials secret, that its region is present in the
+// authenticated service catalog. In both the warn and the error mode, regions differing from a catalog
+// region only in case or surrounding whitespace are normalized to the catalog spelling.
+func WithRegionValidation(mode RegionValidation) Option {
+	return func(d *designateDnsResolver) {
+		d.regionValidation = mode
+	}
+}
+
+// resolveRegion returns the region to build the designate client for. Without a configured region
+// it is the only region of the DNS endpoints in the catalog. The outcome of a successful check is
+// remembered per cloud, so the catalog is only inspected on the first use of credentials.
+func (d *designateDnsResolver) resolveRegion(cloud string, providerClient *gophercloud.ProviderClient, region string) (string, error) {
+	if d.regionValidation == RegionValidationOff && strings.TrimSpace(region) != "" {
+		return region, nil
+	}
+
+	if resolved, ok := d.validatedRegions.Load(cloud + "|" + region); ok {
+		return resolved.(string), nil
+	}
+
+	if strings.TrimSpace(region) == "" {
+		regions, err := catalogRegions(providerClient.GetAuthResult(), dnsServiceType)
+		if err != nil {
+			return "", err
+		}
+		resolved, err := defaultRegion(regions)
+		if err != nil {
+			return "", err
+		}
+
+		d.validatedRegions.Store(cloud+"|"+region, resolved)
+		return resolved, nil
+	}
+
+	regions, err := catalogRegions(providerClient.GetAuthResult(), "")
+	if err != nil {
+		return "", err
+	}
+
+	resolved, err := matchRegion(region, regions)
+	if err != nil {
+		if d.regionValidation == RegionValidationError {
+			return "", err
+		}
+		klog.Warningf("configured region %s is not in the service catalog: %v", region, err)
+		resolved = region
+	}
+
+	d.validatedRegions.Store(cloud+"|"+region, resolved)
+	return resolved, nil
+}
+
+// matchRegion finds region among the catalog regions, ignoring case and surrounding whitespace.
+// If it is missing the error suggests the closest catalog region.
+func matchRegion(region string, regions []string) (string, error) {
+	normalized := strings.TrimSpace(region)
+	for _, candidate := range regions {
+		if strings.EqualFold(candidate, normalized) {
+			return candidate, nil
+		}
+	}
+
+	if len(regions) == 0 {
+		return "", fmt.Errorf("%w: %q, the catalog has no regions", ErrUnknownRegion, region)
+	}
+
+	return "", fmt.Errorf("%w: %q, did you mean %q?", ErrUnknownRegion, region, closestString(normalized, regions))
+}
+
+// defaultRegion returns the region to use when none is configured, given the DNS regions of the
+// catalog. It is empty when the DNS endpoints have no region.
+func defaultRegion(regions []string) (string, error) {
+	if len(regions) > 1 {
+		return "", fmt.Errorf("%w, set one of %s", ErrAmbiguousRegion, strings.Join(regions, ", "))
+	}
+	if len(regions) == 0 {
+		return "", nil
+	}
+
+	return regions[0], nil
+}
+
+// catalogRegions lists the distinct, sorted regions of the endpoints in the catalog returned
+// together with the token, of every service or only of the given service type.
+func catalogRegions(authResult gophercloud.AuthResult, serviceType string) ([]string, error) {
+	var regions []string
+
+	switch result := authResult.(type) {
+	case tokens2.CreateResult:
+		catalog, err := result.ExtractServiceCatalog()
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range catalog.Entries {
+			if serviceType != "" && entry.Type != serviceType {
+				continue
+			}
+			for _, endpoint := range entry.Endpoints {
+				regions = append(regions, endpoint.Region)
+			}
+		}
+	case tokens3.CreateResult:
+		catalog, err := result.ExtractServiceCatalog()
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range catalog.Entries {
+			if serviceType != "" && entry.Type != serviceType {
+				continue
+			}
+			for _, endpoint := range entry.Endpoints {
+				regions = append(regions, endpoint.Region)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("cannot read the service catalog from a %T authentication result", authResult)
+	}
+
+	slices.Sort(regions)
+	return slices.DeleteFunc(slices.Compact(regions), func(region string) bool { return region == "" }), nil
+}
+
+// closestString returns the candidate with the smallest case-insensitive edit distance to s.
+// The candidates must not be empty.
+func closestString(s string, candidates []string) string {
+	closest := candidates[0]
+	for _, candidate := range candidates[1:] {
+		if levenshtein(strings.ToLower(s), strings.ToLower(candidate)) < levenshtein(strings.ToLower(s), strings.ToLower(closest)) {
+			closest = candidate
+		}
+	}
+
+	return closest
+}
+
+// levenshtein returns the edit distance between a and b.
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(rb)]
+}
@@ -0,0 +1,234 @@
+package resolver
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	mockresolver "github.com/rikotsev/cert-manager-webhook-designate/internal/resolver/mock"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+)
+
+func TestMatchRegion(t *testing.T) {
+	tcs := []struct {
+		name          string
+		region        string
+		regions       []string
+		expected      string
+		expectedError string
+	}{
+		{
+			name:     "exact match",
+			region:   "RegionOne",
+			regions:  []string{"RegionOne", "RegionTwo"},
+			expected: "RegionOne",
+		},
+		{
+			name:     "normalized case and whitespace",
+			region:   " regionone\n",
+			regions:  []string{"RegionOne", "RegionTwo"},
+			expected: "RegionOne",
+		},
+		{
+			name:          "misspelled suggests closest",
+			region:        "RegoinTwo",
+			regions:       []string{"RegionOne", "RegionTwo"},
+			expectedError: `did you mean "RegionTwo"?`,
+		},
+		{
+			name:          "empty catalog",
+			region:        "RegionOne",
+			expectedError: "the catalog has no regions",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := matchRegion(tc.region, tc.regions)
+			if tc.expectedError != "" {
+				if !errors.Is(err, ErrUnknownRegion) || !strings.Contains(err.Error(), tc.expectedError) {
+					t.Fatalf("expected error %v containing %q, got %v", ErrUnknownRegion, tc.expectedError, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if actual != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestDesignateDnsResolver_Present_RegionValidation(t *testing.T) {
+	tcs := []struct {
+		name          string
+		mode          RegionValidation
+		region        string
+		expectedError error
+		expectWarning bool
+	}{
+		{
+			name:          "misspelled region fails in error mode",
+			mode:          RegionValidationError,
+			region:        "RegoinOne",
+			expectedError: ErrUnknownRegion,
+		},
+		{
+			name:   "misspelled region is only reported in warn mode",
+			mode:   RegionValidationWarn,
+			region: "RegoinOne",
+			// the client still cannot be built without an endpoint in that region
+			expectedError: ErrFailedDesignateClientInitialization,
+			expectWarning: true,
+		},
+		{
+			name:   "differently cased region is normalized",
+			mode:   RegionValidationError,
+			region: "regionone",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			mockApi := mockresolver.CreateMockOpenstackApi(t)
+			mockApi.Zones = []mockresolver.MockZone{
+				{
+					ID:   "12345",
+					Name: "example.com.",
+				},
+			}
+			resolver := newTestResolver(t, mockApi)
+			WithRegionValidation(tc.mode)(resolver)
+			setSecretValue(t, resolver, "region", tc.region)
+			warnings := captureWarnings(t)
+
+			err := resolver.Present(newChallengeRequest("challenge", "cool.example.com", "example.com", `{
+				"secretName": "foo",
+				"secretNamespace": "bar",
+				"strategy": {
+					"kind": "SOA"
+				}
+			}`))
+			warned := strings.Contains(strings.Join(warnings(), "\n"), "is not in the service catalog")
+			if warned != tc.expectWarning {
+				t.Errorf("expected a warning about the region: %t, got %q", tc.expectWarning, warnings())
+			}
+			if tc.expectedError == nil {
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				return
+			}
+
+			if !errors.Is(err, tc.expectedError) {
+				t.Fatalf("expected error %v, got %v", tc.expectedError, err)
+			}
+			if strings.Contains(err.Error(), ErrUnknownRegion.Error()) && !strings.Contains(err.Error(), `did you mean "RegionOne"?`) {
+				t.Errorf("expected the error to suggest RegionOne, got %v", err)
+			}
+		})
+	}
+}
+
+// captureWarnings collects the lines klog logs at warning level or above until the test ends. The
+// returned function flushes klog and returns the lines collected so far.
+func captureWarnings(t *testing.T) func() []string {
+	t.Helper()
+
+	var logs bytes.Buffer
+	klog.LogToStderr(false)
+	klog.SetOutput(&logs)
+	t.Cleanup(func() {
+		klog.SetOutput(os.Stderr)
+		klog.LogToStderr(true)
+	})
+
+	return func() []string {
+		klog.Flush()
+
+		var warnings []string
+		for _, line := range strings.Split(logs.String(), "\n") {
+			if strings.HasPrefix(line, "W") || strings.HasPrefix(line, "E") {
+				warnings = append(warnings, line)
+			}
+		}
+		return warnings
+	}
+}
+
+// setSecretValue sets key of the test secret "bar/foo" to value.
+func setSecretValue(t *testing.T, resolver *designateDnsResolver, key, value string) {
+	t.Helper()
+
+	secrets := resolver.configProvider.client.CoreV1().Secrets("bar")
+	secret, err := secrets.Get(context.Background(), "foo", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("failed to get the test secret: %v", err)
+	}
+
+	secret.Data[key] = []byte(value)
+	if _, err := secrets.Update(context.Background(), secret, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("failed to update the test secret: %v", err)
+	}
+}
+
+func TestDesignateDnsResolver_Present_DefaultRegion(t *testing.T) {
+	tcs := []struct {
+		name          string
+		regions       []string
+		expectedError string
+	}{
+		{
+			name:    "single region catalog",
+			regions: []string{"RegionOne"},
+		},
+		{
+			name:          "multi region catalog",
+			regions:       []string{"RegionOne", "RegionTwo"},
+			expectedError: "set one of RegionOne, RegionTwo",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			mockApi := mockresolver.CreateMockOpenstackApi(t)
+			mockApi.Zones = []mockresolver.MockZone{
+				{
+					ID:   "12345",
+					Name: "example.com.",
+				},
+			}
+			mockApi.DNSRegions = tc.regions
+			resolver := newTestResolver(t, mockApi)
+			setSecretValue(t, resolver, "region", "")
+
+			err := resolver.Present(newChallengeRequest("challenge", "cool.example.com", "example.com", `{
+				"secretName": "foo",
+				"secretNamespace": "bar",
+				"strategy": {
+					"kind": "SOA"
+				}
+			}`))
+			if tc.expectedError == "" {
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				if len(mockApi.RecordSets) != 1 {
+					t.Errorf("expected the challenge recordset to be created, got %d recordsets", len(mockApi.RecordSets))
+				}
+				return
+			}
+
+			if !errors.Is(err, ErrAmbiguousRegion) || !strings.Contains(err.Error(), tc.expectedError) {
+				t.Fatalf("expected error %v containing %q, got %v", ErrAmbiguousRegion, tc.expectedError, err)
+			}
+		})
+	}
+}
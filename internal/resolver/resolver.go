@@ -4,8 +4,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/http"
+	"os"
+	"regexp"
 	"slices"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/cert-manager/cert-manager/pkg/acme/webhook"
 	"github.com/cert-manager/cert-manager/pkg/acme/webhook/apis/acme/v1alpha1"
@@ -13,6 +19,11 @@ import (
 	"github.com/gophercloud/gophercloud/v2/openstack"
 	"github.com/gophercloud/gophercloud/v2/openstack/dns/v2/recordsets"
 	"github.com/gophercloud/gophercloud/v2/openstack/dns/v2/zones"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rikotsev/cert-manager-webhook-designate/internal/metrics"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/sync/singleflight"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/klog/v2"
 
@@ -21,221 +32,910 @@ import (
 
 const Name = "openstack-designate"
 
+const zoneTypePrimary = "PRIMARY"
+
+const zoneStatusActive = "ACTIVE"
+
+// zoneViewHeader carries the zone view on every designate request, see ChallengeConfig.ZoneView.
+const zoneViewHeader = "X-Designate-View"
+
+// allProjectsHeader makes designate list and act on the zones of all projects, see ChallengeConfig.AllProjects.
+const allProjectsHeader = "X-Auth-All-Projects"
+
 var ErrFailedDesignateClientInitialization = errors.New("failed to initialize the designate client")
 var ErrNoZones = errors.New("there are no zones in designate to match from for the challenge")
+var ErrSecretNamespaceMismatch = errors.New("the secret namespace does not match the issuer namespace")
+var ErrZoneMismatch = errors.New("the challenge FQDN is not within the configured zone")
+var ErrEmptyChallengeKey = errors.New("the challenge key is empty")
+var ErrWriteModeInapplicable = errors.New("the write mode does not allow the change")
+var ErrZoneNotWritable = errors.New("the selected zone cannot be written to")
+var ErrAmbiguousZone = errors.New("several zones have the same name")
 
 type designateDnsResolver struct {
 	configProvider *authConfigProvider
+	// zoneLists deduplicates concurrent, identical zone listings so that a burst of
+	// challenges for the same cloud only paginates the zones once.
+	zoneLists singleflight.Group
+	// zoneCache keeps zone listings around for a while, see WithZoneCacheTTL.
+	zoneCache zoneCache
+	// zoneListPageSize is the number of zones asked for per page, see WithZoneListPageSize.
+	zoneListPageSize int
+	// tokenCache keeps authenticated clients around until their token expires, see WithTokenCache.
+	tokenCache tokenCache
+	// enforceIssuerNamespace rejects challenges whose secret lives outside the issuer's namespace.
+	enforceIssuerNamespace bool
+	// secretEnvFallback lets keys missing from a secret fall back to the environment, see WithSecretEnvFallback.
+	secretEnvFallback bool
+	// challengeSlots bounds the number of Present/CleanUp calls processed at once. Nil means unbounded.
+	challengeSlots chan struct{}
+	// providerClient, when set, is used for every challenge instead of authenticating with the
+	// credentials secret referenced by the challenge config.
+	providerClient *gophercloud.ProviderClient
+	endpointOpts   gophercloud.EndpointOpts
+	readiness      readinessTracker
+	// initialized is set once Initialize has built the kube client, see Ready.
+	initialized atomic.Bool
+	// regionValidation checks credential regions against the service catalog, see WithRegionValidation.
+	regionValidation RegionValidation
+	// validatedRegions maps clouds and their configured region to the region resolved on first use.
+	validatedRegions sync.Map
+	// capabilityProbe checks the permissions of credentials on first use, see WithCapabilityProbe.
+	capabilityProbe bool
+	// probedCapabilities holds the probes that passed, by cloud and, for writes, zone.
+	probedCapabilities sync.Map
+	// capabilityProbes deduplicates concurrent probes of the same capability, e.g. of the SANs of a
+	// certificate using a zone for the first time.
+	capabilityProbes singleflight.Group
+	// descriptionSupport holds, by cloud, whether the DNS API accepts recordset descriptions.
+	descriptionSupport sync.Map
+	// cleanUpWindow delays CleanUps to remove keys for the same recordset together, see WithCleanUpCoalescing.
+	cleanUpWindow  time.Duration
+	cleanUpBatches cleanUpBatches
+	// unknownSecretKeys is handed to the auth config provider, see WithUnknownSecretKeys.
+	unknownSecretKeys UnknownSecretKeys
+	// timingLogs logs the duration of every challenge, see WithTimingLogs.
+	timingLogs bool
+	// tracer starts the span of every challenge, see WithTracerProvider. Nil records nothing.
+	tracer trace.Tracer
+	// challengeEvents records Kubernetes events for every challenge, see WithChallengeEvents.
+	challengeEvents bool
+	// events is built by Initialize if challengeEvents is set, nil otherwise.
+	events *eventRecorder
+	// clientCreationRetries bounds the retries of transient client creation failures, see WithClientCreationRetries.
+	clientCreationRetries int
+	// operationTimeout bounds the OpenStack calls of a challenge, see WithOperationTimeout.
+	operationTimeout time.Duration
+	// listTimeout and mutateTimeout bound the phases of a challenge, see WithListTimeout and WithMutateTimeout.
+	listTimeout   time.Duration
+	mutateTimeout time.Duration
+	// propagationCache remembers settled recordsets, see WithPropagationCacheTTL.
+	propagationCache propagationCache
+	// decisions remembers the last decision if enabled, see WithDecisionRecording.
+	decisions *decisionRecorder
+	// reconcileOlderThan is the age of orphaned recordsets removed on startup, see WithStartupReconciliation.
+	reconcileOlderThan time.Duration
+	// reconciliation tracks the startup reconciliation running in the background.
+	reconciliation sync.WaitGroup
+	// recordNameFunc derives the record name from the challenge, see WithRecordNameFunc.
+	recordNameFunc func(ch *v1alpha1.ChallengeRequest) string
+	// lookupTXT queries a self-check nameserver, replaceable in tests. Nil means lookupTXT.
+	lookupTXT func(ctx context.Context, nameserver, name string) ([]string, error)
+	// challenges tracks the Present and CleanUp calls in flight, drained on shutdown.
+	challenges challengeTracker
+	// stopCh is the stop channel handed to Initialize, closing it stops the resolver.
+	stopCh <-chan struct{}
+	// stopped is closed once the resolver has stopped, see Stopped.
+	stopped chan struct{}
+}
+
+// Option configures optional behavior of the resolver returned by New.
+type Option func(*designateDnsResolver)
+
+// WithIssuerNamespaceEnforcement requires the credentials secret to live in the namespace of the
+// issuer solving the challenge. For a ClusterIssuer that is cert-manager's cluster resource namespace.
+func WithIssuerNamespaceEnforcement(enabled bool) Option {
+	return func(d *designateDnsResolver) {
+		d.enforceIssuerNamespace = enabled
+	}
+}
+
+// WithSecretEnvFallback lets keys missing from the credentials secret of an issuer allowing ambient
+// credentials fall back to the OS_* environment variables of the webhook. Secrets setting their own
+// identityEndpoint or region never fall back. Off by default.
+func WithSecretEnvFallback(enabled bool) Option {
+	return func(d *designateDnsResolver) {
+		d.secretEnvFallback = enabled
+	}
 }
 
 var _ webhook.Solver = (*designateDnsResolver)(nil)
 
+// WithMaxConcurrentChallenges caps the number of Present and CleanUp calls that talk to OpenStack
+// at the same time. Calls over the limit wait for a free slot. A limit of zero or less disables the cap.
+func WithMaxConcurrentChallenges(limit int) Option {
+	return func(d *designateDnsResolver) {
+		if limit <= 0 {
+			d.challengeSlots = nil
+			return
+		}
+		d.challengeSlots = make(chan struct{}, limit)
+	}
+}
+
+// WithProviderClient makes the resolver build its designate client from an already authenticated
+// provider client instead of the credentials secret referenced by each challenge. The remaining
+// challenge config, e.g. the strategy, still applies.
+func WithProviderClient(providerClient *gophercloud.ProviderClient, endpointOpts gophercloud.EndpointOpts) Option {
+	return func(d *designateDnsResolver) {
+		d.providerClient = providerClient
+		d.endpointOpts = endpointOpts
+	}
+}
+
+// WithUnknownSecretKeys makes the resolver warn about or reject credentials secrets containing keys
+// it does not recognize, which are most likely typos such as usrname.
+func WithUnknownSecretKeys(mode UnknownSecretKeys) Option {
+	return func(d *designateDnsResolver) {
+		d.unknownSecretKeys = mode
+	}
+}
+
+// WithRecordNameFunc derives the name of the TXT recordset holding a challenge with fn instead of
+// taking the challenge's resolved FQDN, for delegation schemes the issuer config cannot express. The
+// name is used for every lookup and write of the challenge. A recordNameOverride in the issuer config
+// still takes precedence, and recordTrailingDot still applies to the returned name.
+func WithRecordNameFunc(fn func(ch *v1alpha1.ChallengeRequest) string) Option {
+	return func(d *designateDnsResolver) {
+		d.recordNameFunc = fn
+	}
+}
+
 func (d *designateDnsResolver) Name() string {
 	return Name
 }
 
-func (d *designateDnsResolver) Present(ch *v1alpha1.ChallengeRequest) error {
-	designateClient, cfg, err := d.createDesignateClient(ch)
+func (d *designateDnsResolver) Present(ch *v1alpha1.ChallengeRequest) (err error) {
+	defer observeOperation(metrics.OperationPresent, metrics.PresentTotal, time.Now(), &err)
+	done, err := d.challenges.start()
+	if err != nil {
+		return err
+	}
+	defer done()
+	timer := d.newPhaseTimer()
+	defer d.acquireChallengeSlot()()
+
+	ctx, cancel := d.operationContext()
+	defer cancel()
+
+	decision := Decision{Operation: DecisionOperationPresent, FQDN: ch.ResolvedFQDN, Action: DecisionActionNoop}
+	err = d.present(ctx, ch, timer, &decision)
+	d.readiness.record(err)
+	timer.log("present", ch, err)
+	d.events.presented(ch, decision, err)
+	if err == nil {
+		d.decisions.record(decision)
+	}
+	return err
+}
+
+// present writes the challenge key and fills in the decision as it goes.
+func (d *designateDnsResolver) present(ctx context.Context, ch *v1alpha1.ChallengeRequest, timer *phaseTimer, decision *Decision) (err error) {
+	ctx, span := d.startOperationSpan(ctx, spanPresent, ch.ResolvedFQDN)
+	defer func() { endSpan(span, err) }()
+
+	if strings.TrimSpace(stripQuotes(ch.Key)) == "" {
+		return fmt.Errorf("%w: %s", ErrEmptyChallengeKey, ch.ResolvedFQDN)
+	}
+
+	designateClient, cfg, cloud, err := d.createDesignateClientWithRetries(ctx, ch)
+	timer.phase("auth")
 	if err != nil {
 		return fmt.Errorf("%w: %w", ErrFailedDesignateClientInitialization, err)
 	}
 
-	var zoneId string
+	if err := d.probeListCapability(ctx, cfg, cloud, designateClient); err != nil {
+		return err
+	}
 
-	switch cfg.Strategy.Kind {
-	case StrategyKindSOA:
-		zoneId, err = exactMatchZoneByName(ch.ResolvedZone, designateClient)
-	case StrategyKindZoneName:
-		zoneId, err = exactMatchZoneByName(*cfg.Strategy.ZoneName, designateClient)
-	case StrategyKindBestEffort:
-		zoneId, err = bestEffortMatchZone(ch.ResolvedFQDN, designateClient)
+	var zone *zones.Zone
+	if cfg.Preflight {
+		zone, err = d.preflight(ctx, ch, cfg, cloud, designateClient)
+	} else {
+		listCtx, cancelList := phaseContext(ctx, d.listTimeout)
+		zone, err = d.matchRecordZone(listCtx, ch, cfg, cloud, designateClient)
+		cancelList()
 	}
+	timer.phase("match")
 	if err != nil {
 		return err
 	}
+	defer timer.phase("mutate")
+	span.SetAttributes(attrZoneID.String(zone.ID), attrStrategy.String(cfg.Strategy.Kind))
 
-	allRecordSets, err := findRecordSetsForChallenge(ch, designateClient, zoneId)
+	if err := d.probeWriteCapability(ctx, cloud, designateClient, zone); err != nil {
+		return err
+	}
+	zoneId := zone.ID
+	decision.ZoneID, decision.ZoneName = zone.ID, zone.Name
+	decision.CredentialSource = d.credentialSource(cfg)
+
+	mutateCtx, cancelMutate := phaseContext(ctx, d.mutateTimeout)
+	defer cancelMutate()
+	var written string
+	err = retryOnConflict(mutateCtx, ch.ResolvedFQDN, func() error {
+		var err error
+		written, err = d.writeChallenge(mutateCtx, ch, cfg, cloud, designateClient, zoneId, decision)
+		return err
+	})
 	if err != nil {
 		return err
 	}
 
+	if written != "" && cfg.WaitForPropagation {
+		if err := d.waitForRecordSet(ctx, designateClient, cloud, zoneId, written, cfg.waitTimeout()); err != nil {
+			return err
+		}
+	}
+
+	if len(cfg.selfCheckNameservers) > 0 {
+		if err := d.selfCheck(ctx, cfg, cloud, zoneId, cfg.recordName(ch), ch.Key); err != nil {
+			return err
+		}
+	}
+
+	logger.Info("presented challenge",
+		"fqdn", ch.ResolvedFQDN,
+		"zoneId", zoneId,
+		"strategy", cfg.Strategy.Kind,
+		"recordsetId", written,
+		"action", decision.Action,
+		"credentialSource", decision.CredentialSource,
+	)
+	return nil
+}
+
+// writeChallenge reads the challenge recordset and creates it, or adds the wanted records to it. It
+// returns the ID of the written recordset, or nothing if all records were present already.
+func (d *designateDnsResolver) writeChallenge(ctx context.Context, ch *v1alpha1.ChallengeRequest, cfg *ChallengeConfig, cloud string, designateClient *gophercloud.ServiceClient, zoneId string, decision *Decision) (string, error) {
+	allRecordSets, err := findRecordSetsForChallenge(ctx, ch, cfg, designateClient, zoneId)
+	if err != nil {
+		return "", err
+	}
+
+	wantedRecords := []string{chunkTXTValue(ch.Key)}
+	if cfg.OwnershipMarker {
+		wantedRecords = append(wantedRecords, ownershipMarker(ch, cfg))
+	}
+	for _, base := range cfg.BaseRecords {
+		wantedRecords = append(wantedRecords, chunkTXTValue(base))
+	}
+
 	if len(allRecordSets) == 0 {
-		result := recordsets.Create(context.TODO(), designateClient, zoneId, recordsets.CreateOpts{
-			Name:    enforceTrailingDot(ch.ResolvedFQDN),
-			Type:    "TXT",
-			Records: []string{ch.Key},
+		if cfg.WriteMode == WriteModeUpdateOnly {
+			return "", fmt.Errorf("%w: recordset %s does not exist and writeMode is %s", ErrWriteModeInapplicable, cfg.recordName(ch), cfg.WriteMode)
+		}
+
+		description := d.createDescription(ctx, ch, cfg, cloud, designateClient)
+		createCtx, span := startSpan(ctx, spanCreateRecordSet, attrZoneID.String(zoneId))
+		result := recordsets.Create(createCtx, designateClient, zoneId, recordsets.CreateOpts{
+			Name:        cfg.recordName(ch),
+			Type:        "TXT",
+			Records:     wantedRecords,
+			Description: description,
 		})
-		if result.Err != nil {
-			return result.Err
+		created, err := result.Extract()
+		endSpan(span, err)
+		if err != nil {
+			return "", writeError(err, zoneId)
 		}
+		decision.Action = DecisionActionCreated
 
-		return nil
+		return created.ID, nil
 	}
 
-	if slices.Contains(allRecordSets[0].Records, ch.Key) {
-		return nil
+	records := allRecordSets[0].Records
+	for _, wanted := range wantedRecords {
+		if !slices.ContainsFunc(records, func(rec string) bool { return sameRecordValue(rec, wanted) }) {
+			records = append(records, wanted)
+		}
 	}
 
-	allRecordSets[0].Records = append(allRecordSets[0].Records, ch.Key)
+	if len(records) == len(allRecordSets[0].Records) {
+		return "", nil
+	}
 
-	result := recordsets.Update(context.TODO(), designateClient, zoneId, allRecordSets[0].ID, recordsets.UpdateOpts{
-		Records: allRecordSets[0].Records,
-	})
-	return result.Err
+	if cfg.WriteMode == WriteModeCreateOnly {
+		return "", fmt.Errorf("%w: recordset %s already exists and writeMode is %s", ErrWriteModeInapplicable, cfg.recordName(ch), cfg.WriteMode)
+	}
+
+	opts := updateOpts(allRecordSets[0], records)
+	if description := d.recordSetDescription(ctx, cfg.RecordSetDescription, cloud, designateClient); description != "" {
+		opts.Description = &description
+	}
+	updateCtx, span := startSpan(ctx, spanUpdateRecordSet, attrZoneID.String(zoneId), attrRecordSetID.String(allRecordSets[0].ID))
+	result := recordsets.Update(updateCtx, designateClient, zoneId, allRecordSets[0].ID, opts)
+	endSpan(span, result.Err)
+	if result.Err != nil {
+		return "", writeError(result.Err, zoneId)
+	}
+	decision.Action = DecisionActionUpdated
+
+	return allRecordSets[0].ID, nil
 }
 
-func (d *designateDnsResolver) CleanUp(ch *v1alpha1.ChallengeRequest) error {
-	designateClient, cfg, err := d.createDesignateClient(ch)
-	if err != nil {
-		return fmt.Errorf("%w: %w", ErrFailedDesignateClientInitialization, err)
+// updateOpts returns the options replacing the records of rs. The TTL and description of rs are sent
+// along, as some designate versions treat an update as a full replace and would reset them otherwise.
+func updateOpts(rs recordsets.RecordSet, records []string) recordsets.UpdateOpts {
+	opts := recordsets.UpdateOpts{
+		Records: records,
+	}
+	if rs.TTL > 0 {
+		opts.TTL = &rs.TTL
+	}
+	if rs.Description != "" {
+		opts.Description = &rs.Description
 	}
 
-	var zoneId string
+	return opts
+}
 
-	switch cfg.Strategy.Kind {
-	case StrategyKindSOA:
-		zoneId, err = exactMatchZoneByName(ch.ResolvedZone, designateClient)
-	case StrategyKindZoneName:
-		zoneId, err = exactMatchZoneByName(*cfg.Strategy.ZoneName, designateClient)
-	case StrategyKindBestEffort:
-		zoneId, err = bestEffortMatchZone(ch.ResolvedFQDN, designateClient)
+func (d *designateDnsResolver) CleanUp(ch *v1alpha1.ChallengeRequest) (err error) {
+	defer observeOperation(metrics.OperationCleanUp, metrics.CleanUpTotal, time.Now(), &err)
+	done, err := d.challenges.start()
+	if err != nil {
+		return err
 	}
+	defer done()
+	timer := d.newPhaseTimer()
+	summary, err := d.coalescedCleanUp(ch, timer)
+	d.readiness.record(err)
+	timer.log("cleanup", ch, err)
+	d.events.cleanedUp(ch, summary, err)
 	if err != nil {
 		return err
 	}
 
-	allRecordSets, err := findRecordSetsForChallenge(ch, designateClient, zoneId)
+	d.decisions.record(Decision{
+		Operation:        DecisionOperationCleanUp,
+		FQDN:             ch.ResolvedFQDN,
+		ZoneID:           summary.zoneId,
+		ZoneName:         summary.zoneName,
+		Action:           string(summary.action),
+		CredentialSource: summary.credentialSource,
+	})
+
+	logger.Info("cleaned up challenge",
+		"fqdn", ch.ResolvedFQDN,
+		"zoneId", summary.zoneId,
+		"strategy", summary.strategy,
+		"recordsetId", summary.recordSetId,
+		"action", summary.action,
+		"remainingRecords", summary.remainingRecords,
+		"credentialSource", summary.credentialSource,
+	)
+
+	if summary.foreignRecords > 0 {
+		// The values stay out of the log, they may belong to another system sharing the name.
+		klog.Warningf("%d foreign records remain in recordset %s of %s after removing the challenge key", summary.foreignRecords, summary.recordSetId, ch.ResolvedFQDN)
+	}
+
+	return nil
+}
+
+// observeOperation counts the outcome of a challenge operation and observes its duration, meant to
+// be deferred with a pointer to the operation's error.
+func observeOperation(operation string, total *prometheus.CounterVec, started time.Time, err *error) {
+	result := metrics.ResultSuccess
+	if *err != nil {
+		result = metrics.ResultError
+	}
+
+	total.WithLabelValues(result).Inc()
+	metrics.OperationDuration.WithLabelValues(operation).Observe(time.Since(started).Seconds())
+}
+
+// acquireChallengeSlot blocks until the challenge may proceed and returns the function releasing its slot.
+func (d *designateDnsResolver) acquireChallengeSlot() func() {
+	if d.challengeSlots == nil {
+		return func() {}
+	}
+
+	d.challengeSlots <- struct{}{}
+	return func() { <-d.challengeSlots }
+}
+
+type cleanupAction string
+
+const (
+	cleanupActionDeleted cleanupAction = "deleted"
+	cleanupActionUpdated cleanupAction = "updated"
+	cleanupActionNoop    cleanupAction = "noop"
+)
+
+// cleanupSummary describes the final state of the challenge recordsets after a CleanUp. With
+// duplicate recordsets the counts add up, and the action and ID are those of the recordset that
+// changed, preferring an update. It only carries counts so that it is safe to log.
+type cleanupSummary struct {
+	action           cleanupAction
+	zoneName         string
+	zoneId           string
+	recordSetId      string
+	remainingRecords int
+	// foreignRecords counts the remaining records that were not written by this webhook for the
+	// cleaned up challenges, i.e. everything but the ownership marker.
+	foreignRecords   int
+	strategy         string
+	credentialSource string
+}
+
+// cleanUp removes the given challenge keys from the recordset of the challenge.
+func (d *designateDnsResolver) cleanUp(ctx context.Context, ch *v1alpha1.ChallengeRequest, keys []string, timer *phaseTimer) (_ *cleanupSummary, err error) {
+	ctx, span := d.startOperationSpan(ctx, spanCleanUp, ch.ResolvedFQDN)
+	defer func() { endSpan(span, err) }()
+
+	designateClient, cfg, cloud, err := d.createDesignateClientWithRetries(ctx, ch)
+	timer.phase("auth")
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("%w: %w", ErrFailedDesignateClientInitialization, err)
+	}
+
+	listCtx, cancelList := phaseContext(ctx, d.listTimeout)
+	zone, err := d.matchRecordZone(listCtx, ch, cfg, cloud, designateClient)
+	cancelList()
+	timer.phase("match")
+	if err != nil {
+		return nil, err
+	}
+	defer timer.phase("mutate")
+	zoneId := zone.ID
+	span.SetAttributes(attrZoneID.String(zoneId), attrStrategy.String(cfg.Strategy.Kind))
+
+	ctx, cancelMutate := phaseContext(ctx, d.mutateTimeout)
+	defer cancelMutate()
+
+	summary := &cleanupSummary{action: cleanupActionNoop, zoneId: zoneId, zoneName: zone.Name, strategy: cfg.Strategy.Kind, credentialSource: d.credentialSource(cfg)}
+
+	allRecordSets, err := findRecordSetsForChallenge(ctx, ch, cfg, designateClient, zoneId)
+	if err != nil {
+		return nil, err
 	}
 
 	if len(allRecordSets) == 0 {
-		klog.V(4).Infof("No recordsets found for challenge %s", ch.ResolvedFQDN)
-		return nil
+		klog.V(4).Infof("No recordsets found for challenge %s", cfg.recordName(ch))
+		return summary, nil
 	}
 
-	if len(allRecordSets[0].Records) == 1 && allRecordSets[0].Records[0] == ch.Key {
-		err = recordsets.Delete(context.TODO(), designateClient, zoneId, allRecordSets[0].ID).ExtractErr()
+	// Duplicates, e.g. created out of band, may hold the key as well. Each is cleaned up on its own.
+	for _, rs := range allRecordSets {
+		action, remaining, err := cleanUpRecordSet(ctx, ch, cfg, keys, designateClient, zoneId, rs)
 		if err != nil {
-			return err
+			return nil, err
+		}
+
+		if summary.recordSetId == "" || (action != cleanupActionNoop && summary.action == cleanupActionNoop) {
+			summary.recordSetId = rs.ID
+		}
+		// An update leaves records behind, which is what the summary has to tell above a deletion.
+		if action == cleanupActionUpdated || (action == cleanupActionDeleted && summary.action == cleanupActionNoop) {
+			summary.action = action
+		}
+		summary.remainingRecords += len(remaining)
+		if action != cleanupActionUpdated {
+			continue
+		}
+		for _, rec := range remaining {
+			if cfg.isBaseRecord(rec) {
+				continue
+			}
+			if !cfg.OwnershipMarker || !sameRecordValue(rec, ownershipMarker(ch, cfg)) {
+				summary.foreignRecords++
+			}
 		}
-		return nil
 	}
 
+	return summary, nil
+}
+
+// cleanUpRecordSet removes the keys from the recordset, deleting it if nothing else remains. It
+// returns what it did and the records left in the recordset.
+func cleanUpRecordSet(ctx context.Context, ch *v1alpha1.ChallengeRequest, cfg *ChallengeConfig, keys []string, designateClient *gophercloud.ServiceClient, zoneId string, rs recordsets.RecordSet) (cleanupAction, []string, error) {
 	cleanedUpRecords := make([]string, 0)
-	for _, rec := range allRecordSets[0].Records {
-		if rec != ch.Key {
+	for _, rec := range rs.Records {
+		if cfg.isBaseRecord(rec) || !slices.ContainsFunc(keys, func(key string) bool { return sameRecordValue(rec, key) }) {
 			cleanedUpRecords = append(cleanedUpRecords, rec)
 		}
 	}
 
-	result := recordsets.Update(context.TODO(), designateClient, zoneId, allRecordSets[0].ID, recordsets.UpdateOpts{
-		Records: cleanedUpRecords,
-	})
-	return result.Err
+	if len(cleanedUpRecords) == len(rs.Records) {
+		return cleanupActionNoop, rs.Records, nil
+	}
+
+	// Our ownership marker only has to outlive the last record it accompanies.
+	if cfg.OwnershipMarker {
+		marker := ownershipMarker(ch, cfg)
+		if !slices.ContainsFunc(cleanedUpRecords, func(rec string) bool { return !sameRecordValue(rec, marker) }) {
+			cleanedUpRecords = cleanedUpRecords[:0]
+		}
+	}
+
+	if len(cleanedUpRecords) == 0 {
+		if cfg.SafeCleanup && !isManagedRecordSet(ch, cfg, rs) {
+			klog.Warningf("keeping recordset %s of %s, safeCleanup is set and it is not marked as managed by the webhook", rs.ID, ch.ResolvedFQDN)
+			return cleanupActionNoop, rs.Records, nil
+		}
+
+		deleteCtx, span := startSpan(ctx, spanDeleteRecordSet, attrZoneID.String(zoneId), attrRecordSetID.String(rs.ID))
+		err := recordsets.Delete(deleteCtx, designateClient, zoneId, rs.ID).ExtractErr()
+		endSpan(span, err)
+		if err != nil {
+			return "", nil, writeError(err, zoneId)
+		}
+
+		return cleanupActionDeleted, nil, nil
+	}
+
+	updateCtx, span := startSpan(ctx, spanUpdateRecordSet, attrZoneID.String(zoneId), attrRecordSetID.String(rs.ID))
+	result := recordsets.Update(updateCtx, designateClient, zoneId, rs.ID, updateOpts(rs, cleanedUpRecords))
+	endSpan(span, result.Err)
+	if result.Err != nil {
+		return "", nil, writeError(result.Err, zoneId)
+	}
+
+	return cleanupActionUpdated, cleanedUpRecords, nil
 }
 
-func (d *designateDnsResolver) Initialize(kubeClientConfig *rest.Config, _ <-chan struct{}) error {
+func (d *designateDnsResolver) Initialize(kubeClientConfig *rest.Config, stopCh <-chan struct{}) error {
 	client, err := kubernetes.NewForConfig(kubeClientConfig)
 	if err != nil {
 		return err
 	}
 
-	d.configProvider = &authConfigProvider{client: client}
+	d.configProvider = &authConfigProvider{client: client, unknownKeys: d.unknownSecretKeys}
+	if d.secretEnvFallback {
+		d.configProvider.lookupEnv = os.LookupEnv
+	}
+	if d.challengeEvents {
+		dynamicClient, err := dynamic.NewForConfig(kubeClientConfig)
+		if err != nil {
+			return err
+		}
+		d.events = newEventRecorder(client, dynamicClient)
+	}
+	d.initialized.Store(true)
+	d.stopCh = stopCh
+	d.startReconciliation(stopCh)
+	d.watchShutdown(stopCh)
 
 	klog.V(2).Info(fmt.Sprintf("ACME DNS resolver - %s - initialized!", Name))
 
 	return nil
 }
 
-func (d *designateDnsResolver) createDesignateClient(ch *v1alpha1.ChallengeRequest) (*gophercloud.ServiceClient, *ChallengeConfig, error) {
-	ctx := context.TODO()
+// Credential sources, telling which credentials a challenge was solved with.
+const (
+	// CredentialSourceSecret is the credentials secret referenced by the issuer config.
+	CredentialSourceSecret = "secret"
+	// CredentialSourceAmbient are the OS_* environment variables of the webhook.
+	CredentialSourceAmbient = "ambient"
+	// CredentialSourceProviderClient is the client injected with WithProviderClient.
+	CredentialSourceProviderClient = "provider-client"
+)
+
+// credentialSource returns where the credentials of a challenge with the given config come from.
+func (d *designateDnsResolver) credentialSource(cfg *ChallengeConfig) string {
+	switch {
+	case d.providerClient != nil:
+		return CredentialSourceProviderClient
+	case cfg.hasSecret():
+		return CredentialSourceSecret
+	default:
+		return CredentialSourceAmbient
+	}
+}
 
+// createDesignateClient returns the designate client for the challenge together with its parsed
+// config and the identity of the cloud the client talks to, which scopes every cache.
+func (d *designateDnsResolver) createDesignateClient(ctx context.Context, ch *v1alpha1.ChallengeRequest) (*gophercloud.ServiceClient, *ChallengeConfig, string, error) {
 	cfg, err := ParseConfig(ch.Config)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, "", err
+	}
+	cfg.recordNameFunc = d.recordNameFunc
+
+	if d.enforceIssuerNamespace && cfg.hasSecret() && cfg.SecretNamespace != ch.ResourceNamespace {
+		return nil, cfg, "", fmt.Errorf("%w: %s != %s", ErrSecretNamespaceMismatch, cfg.SecretNamespace, ch.ResourceNamespace)
+	}
+
+	if _, ok := cfg.Strategy.zoneNameFor(cfg.recordName(ch)); cfg.Strategy.Kind == StrategyKindZoneName && !ok {
+		return nil, cfg, "", fmt.Errorf("%w: %s is not within %s", ErrZoneMismatch, cfg.recordName(ch), strings.Join(cfg.Strategy.ZoneName, ", "))
+	}
+
+	logger.Info("resolved challenge credentials", "fqdn", ch.ResolvedFQDN, "strategy", cfg.Strategy.Kind, "source", d.credentialSource(cfg), "secretNamespace", cfg.SecretNamespace, "secretName", cfg.SecretName)
+
+	if d.providerClient != nil {
+		designateClient, err := openstack.NewDNSV2(d.providerClient, d.endpointOpts)
+		if err != nil {
+			return nil, cfg, "", err
+		}
+		return withRequestHeaders(designateClient, cfg, providerCloudIdentity(d.providerClient, d.endpointOpts))
+	}
+
+	var authCfg *AuthConfig
+	switch {
+	case cfg.hasSecret():
+		authCfg, err = d.configProvider.Get(ctx, cfg.SecretNamespace, cfg.SecretName, ch.AllowAmbientCredentials)
+	case ch.AllowAmbientCredentials:
+		authCfg, err = ambientAuthConfig()
+	default:
+		err = fmt.Errorf("%w: %s, ambient credentials are not allowed for this issuer", ErrMissingRequiredField, "secretName")
+	}
+	if err != nil {
+		return nil, cfg, "", err
+	}
+	if cfg.Region != "" {
+		authCfg.endpointOpts.Region = cfg.Region
+	}
+	authCfg.httpTimeout = cfg.requestTimeout()
+	cloud := authCfg.cloudIdentity()
+
+	if cfg.InsecureSkipVerify {
+		klog.Warningf("TLS certificate verification is disabled for %s, insecureSkipVerify must not be used in production", authCfg.authOpts.IdentityEndpoint)
 	}
 
-	authCfg, err := d.configProvider.Get(ctx, cfg.SecretNamespace, cfg.SecretName)
+	client, err := d.tokenCache.authenticatedClient(ctx, authCfg, cloud, cfg.InsecureSkipVerify)
 	if err != nil {
-		return nil, cfg, err
+		return nil, cfg, "", err
 	}
 
-	client, err := openstack.AuthenticatedClient(ctx, authCfg.authOpts)
+	authCfg.endpointOpts.Region, err = d.resolveRegion(cloud, client, authCfg.endpointOpts.Region)
 	if err != nil {
-		return nil, cfg, err
+		return nil, cfg, "", err
 	}
 
-	designateClient, err := openstack.NewDNSV2(client, authCfg.endpointOpts)
+	designateClient, err := newDesignateClient(client, authCfg)
 	if err != nil {
-		return nil, cfg, err
+		return nil, cfg, "", err
 	}
-	return designateClient, cfg, nil
+	return withRequestHeaders(designateClient, cfg, cloud)
 }
 
-func exactMatchZoneByName(zoneName string, designateClient *gophercloud.ServiceClient) (string, error) {
-	zoneName = enforceTrailingDot(zoneName)
-	page, err := zones.List(designateClient, zones.ListOpts{
-		Name: zoneName,
-	}).AllPages(context.TODO())
+// withRequestHeaders sends the configured zone view and all projects headers with every request of
+// the client. Both become part of the cloud identity, as they change the zones listed, which must
+// not share cache entries.
+func withRequestHeaders(designateClient *gophercloud.ServiceClient, cfg *ChallengeConfig, cloud string) (*gophercloud.ServiceClient, *ChallengeConfig, string, error) {
+	headers := make(map[string]string)
+	if cfg.ZoneView != "" {
+		headers[zoneViewHeader] = cfg.ZoneView
+		cloud += "|view=" + cfg.ZoneView
+	}
+	if cfg.AllProjects {
+		headers[allProjectsHeader] = "true"
+		cloud += "|all-projects"
+	}
+
+	if len(headers) > 0 {
+		designateClient.MoreHeaders = headers
+	}
+	return designateClient, cfg, cloud, nil
+}
+
+// matchRecordZone selects the zone for the challenge record and makes sure the record name lies within it.
+func (d *designateDnsResolver) matchRecordZone(ctx context.Context, ch *v1alpha1.ChallengeRequest, cfg *ChallengeConfig, cloud string, designateClient *gophercloud.ServiceClient) (*zones.Zone, error) {
+	zone, err := d.matchZone(ctx, ch, cfg, cloud, designateClient)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	allZones, err := zones.ExtractZones(page)
+	if err := checkRecordZone(ch, cfg, zone); err != nil {
+		return nil, err
+	}
+
+	return zone, nil
+}
+
+// checkRecordZone makes sure the record name lies within the zone and the zone accepts recordsets.
+func checkRecordZone(ch *v1alpha1.ChallengeRequest, cfg *ChallengeConfig, zone *zones.Zone) error {
+	if !isWithinZone(cfg.recordName(ch), zone.Name) {
+		return fmt.Errorf("%w: %s is not within %s", ErrZoneMismatch, cfg.recordName(ch), enforceTrailingDot(zone.Name))
+	}
+
+	// Only primary zones accept recordset changes; designate rejects them on secondary zones with
+	// an error that does not hint at the zone type.
+	if zone.Type != "" && !strings.EqualFold(zone.Type, zoneTypePrimary) {
+		return fmt.Errorf("%w: %s is a %s zone", ErrZoneNotWritable, enforceTrailingDot(zone.Name), zone.Type)
+	}
+
+	return nil
+}
+
+func (d *designateDnsResolver) matchZone(ctx context.Context, ch *v1alpha1.ChallengeRequest, cfg *ChallengeConfig, cloud string, designateClient *gophercloud.ServiceClient) (*zones.Zone, error) {
+	switch cfg.Strategy.Kind {
+	case StrategyKindSOA:
+		return d.exactMatchZoneByName(ctx, cfg, cloud, ch.ResolvedZone, designateClient)
+	case StrategyKindZoneName:
+		zoneName, _ := cfg.Strategy.zoneNameFor(cfg.recordName(ch))
+		zone, err := d.exactMatchZoneByName(ctx, cfg, cloud, zoneName, designateClient)
+		if errors.Is(err, ErrNoZones) && cfg.Strategy.ZoneNameFallback {
+			klog.V(2).InfoS("zone not found by name, falling back to its closest parent zone", "zoneName", zoneName)
+			return d.bestEffortMatchZone(ctx, cfg, cloud, zoneName, designateClient)
+		}
+		return zone, err
+	case StrategyKindBestEffort:
+		return d.bestEffortMatchZone(ctx, cfg, cloud, cfg.recordName(ch), designateClient)
+	case StrategyKindAuto:
+		zone, err := d.exactMatchZoneByName(ctx, cfg, cloud, ch.ResolvedZone, designateClient)
+		if errors.Is(err, ErrNoZones) {
+			klog.V(2).InfoS("no zone named like the resolved zone, falling back to the best effort match", "resolvedZone", ch.ResolvedZone)
+			return d.bestEffortMatchZone(ctx, cfg, cloud, cfg.recordName(ch), designateClient)
+		}
+		return zone, err
+	case StrategyKindZoneID:
+		return getZoneByID(ctx, *cfg.Strategy.ZoneID, designateClient)
+	case StrategyKindRegex:
+		return d.regexMatchZone(ctx, cfg, cloud, cfg.recordName(ch), cfg.Strategy.zoneRegexp, designateClient)
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrInvalidStrategy, cfg.Strategy.Kind)
+}
+
+// getZoneByID fetches the zone directly, without listing zones.
+func getZoneByID(ctx context.Context, zoneId string, designateClient *gophercloud.ServiceClient) (*zones.Zone, error) {
+	zone, err := zones.Get(ctx, designateClient, zoneId).Extract()
+	if gophercloud.ResponseCodeIs(err, http.StatusNotFound) {
+		return nil, fmt.Errorf("%w: no zone with id %s", ErrNoZones, zoneId)
+	}
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	if len(allZones) == 0 {
-		return "", ErrNoZones
+
+	return zone, nil
+}
+
+// listZones lists the zones visible in the given cloud. Concurrent calls for the same cloud and
+// options share a single in-flight listing, see doShared, and the result is served from the zone
+// cache while it is fresh. Since both need the whole listing, every zone is held in memory, however
+// small the pages are, see listZonesContaining for the listing without the cache.
+func (d *designateDnsResolver) listZones(ctx context.Context, cloud string, designateClient *gophercloud.ServiceClient, opts zones.ListOpts) (_ []zones.Zone, err error) {
+	ctx, span := startSpan(ctx, spanListZones)
+	defer func() { endSpan(span, err) }()
+
+	key := cloud + "|" + opts.Name
+
+	if cached, ok := d.zoneCache.get(key); ok {
+		return cached, nil
 	}
 
-	zoneId := allZones[0].ID
-	return zoneId, nil
+	result, err := d.doShared(ctx, &d.zoneLists, key, d.listTimeout, func(ctx context.Context) (any, error) {
+		var allZones []zones.Zone
+		_, err := d.eachZonePage(ctx, designateClient, opts, func(pageZones []zones.Zone) (bool, error) {
+			allZones = append(allZones, pageZones...)
+			return true, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		d.zoneCache.put(key, allZones)
+		return allZones, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result.([]zones.Zone), nil
 }
 
-func bestEffortMatchZone(fqdn string, designateClient *gophercloud.ServiceClient) (string, error) {
-	fqdn = enforceTrailingDot(fqdn)
-	page, err := zones.List(designateClient, zones.ListOpts{}).AllPages(context.TODO())
+// exactMatchZoneByName returns the zone with the given name. Listings spanning several projects may
+// return more than one, which fails with ErrAmbiguousZone if the config asks for it and otherwise
+// picks the first one listed.
+func (d *designateDnsResolver) exactMatchZoneByName(ctx context.Context, cfg *ChallengeConfig, cloud, zoneName string, designateClient *gophercloud.ServiceClient) (*zones.Zone, error) {
+	zoneName = canonicalName(zoneName)
+	allZones, err := d.listScopedZones(ctx, cfg, cloud, designateClient, zones.ListOpts{
+		Name: zoneName,
+	})
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+	if len(allZones) == 0 {
+		return nil, ErrNoZones
+	}
+
+	if len(allZones) > 1 {
+		ids := make([]string, 0, len(allZones))
+		for _, zone := range allZones {
+			ids = append(ids, zone.ID)
+		}
+		if cfg.FailOnAmbiguousZone {
+			return nil, fmt.Errorf("%w: %s has the ids %s", ErrAmbiguousZone, zoneName, strings.Join(ids, ", "))
+		}
+		klog.Warningf("%d zones are named %s, using the first one %s of %s", len(allZones), zoneName, allZones[0].ID, strings.Join(ids, ", "))
+	}
+
+	return &allZones[0], nil
+}
+
+func (d *designateDnsResolver) bestEffortMatchZone(ctx context.Context, cfg *ChallengeConfig, cloud, fqdn string, designateClient *gophercloud.ServiceClient) (*zones.Zone, error) {
+	if cfg.Strategy.WalkLabels {
+		return d.walkLabelsMatchZone(ctx, cfg, cloud, fqdn, designateClient)
 	}
 
-	allZones, err := zones.ExtractZones(page)
+	fqdn = enforceTrailingDot(fqdn)
+	allZones, err := d.listZonesContaining(ctx, cfg, cloud, fqdn, designateClient, func(z zones.Zone) bool {
+		return strings.EqualFold(z.Status, zoneStatusActive)
+	})
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	if len(allZones) == 0 {
-		return "", ErrNoZones
+		return nil, ErrNoZones
 	}
 
 	var matchedZone *zones.Zone
 
 	for i, z := range allZones {
-		if strings.HasSuffix(fqdn, z.Name) {
+		// Zones being created, migrated or in error do not accept recordsets, and may be stale
+		// duplicates of an active zone.
+		if !strings.EqualFold(z.Status, zoneStatusActive) {
+			klog.V(4).InfoS("skipping zone that is not active", "zone", z.Name, "id", z.ID, "status", z.Status)
+			continue
+		}
+
+		if isWithinZone(fqdn, z.Name) {
 			if matchedZone == nil {
 				matchedZone = &allZones[i]
 				continue
 			}
 
-			if len(z.Name) > len(matchedZone.Name) {
+			// Equally long matches, e.g. zones of the same name, are settled by the lowest ID, so
+			// the choice does not depend on the order designate lists them in.
+			if len(z.Name) > len(matchedZone.Name) || (len(z.Name) == len(matchedZone.Name) && z.ID < matchedZone.ID) {
 				matchedZone = &allZones[i]
 			}
 		}
 	}
 
 	if matchedZone == nil {
-		return "", ErrNoZones
+		return nil, ErrNoZones
 	}
 
-	return matchedZone.ID, nil
+	return matchedZone, nil
 }
 
-func findRecordSetsForChallenge(ch *v1alpha1.ChallengeRequest, designateClient *gophercloud.ServiceClient, zoneId string) ([]recordsets.RecordSet, error) {
+// regexMatchZone selects among the active zones whose name matches the pattern the longest one
+// containing the record name. Ties are settled by the lowest ID, like in bestEffortMatchZone.
+func (d *designateDnsResolver) regexMatchZone(ctx context.Context, cfg *ChallengeConfig, cloud, recordName string, pattern *regexp.Regexp, designateClient *gophercloud.ServiceClient) (*zones.Zone, error) {
+	allZones, err := d.listZonesContaining(ctx, cfg, cloud, recordName, designateClient, func(z zones.Zone) bool {
+		return strings.EqualFold(z.Status, zoneStatusActive) && pattern.MatchString(z.Name)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var matchedZone *zones.Zone
+	for i, z := range allZones {
+		if !strings.EqualFold(z.Status, zoneStatusActive) || !pattern.MatchString(z.Name) || !isWithinZone(recordName, z.Name) {
+			continue
+		}
+
+		if matchedZone == nil || len(z.Name) > len(matchedZone.Name) || (len(z.Name) == len(matchedZone.Name) && z.ID < matchedZone.ID) {
+			matchedZone = &allZones[i]
+		}
+	}
+
+	if matchedZone == nil {
+		return nil, fmt.Errorf("%w: none matches %s and contains %s", ErrNoZones, pattern, recordName)
+	}
+
+	return matchedZone, nil
+}
+
+func findRecordSetsForChallenge(ctx context.Context, ch *v1alpha1.ChallengeRequest, cfg *ChallengeConfig, designateClient *gophercloud.ServiceClient, zoneId string) ([]recordsets.RecordSet, error) {
+	listCtx, span := startSpan(ctx, spanListRecordSets, attrZoneID.String(zoneId))
 	allRecordsPages, err := recordsets.ListByZone(designateClient, zoneId, recordsets.ListOpts{
-		Name: enforceTrailingDot(ch.ResolvedFQDN),
+		Name: cfg.recordName(ch),
 		Type: "TXT",
-	}).AllPages(context.TODO())
+	}).AllPages(listCtx)
+	endSpan(span, err)
 	if err != nil {
 		return nil, err
 	}
@@ -244,17 +944,102 @@ func findRecordSetsForChallenge(ch *v1alpha1.ChallengeRequest, designateClient *
 	if err != nil {
 		return nil, err
 	}
+
+	// The name filter is applied by designate, which may return names with or without the trailing
+	// dot regardless of how the filter was sent. Only recordsets of the challenge name are kept.
+	name := canonicalName(cfg.recordName(ch))
+	allRecordSets = slices.DeleteFunc(allRecordSets, func(rs recordsets.RecordSet) bool {
+		return canonicalName(rs.Name) != name
+	})
+
+	if cfg.MergeDuplicateRecordSets {
+		return mergeDuplicateRecordSets(ctx, designateClient, zoneId, allRecordSets)
+	}
 	return allRecordSets, nil
 }
 
+// isWithinZone reports whether name is the zone itself or one of its subdomains. DNS names compare
+// case-insensitively.
+func isWithinZone(name, zone string) bool {
+	name, zone = canonicalName(name), canonicalName(zone)
+	if name == "" || zone == "" {
+		return false
+	}
+	if zone == "." {
+		return true
+	}
+
+	return name == zone || strings.HasSuffix(name, "."+zone)
+}
+
+// txtChunkSize is the length limit of a single character string of a TXT record.
+const txtChunkSize = 255
+
+// sameRecordValue compares two TXT values ignoring the surrounding quotes, which some clouds
+// add to stored records and others don't, and the split into character strings.
+func sameRecordValue(a, b string) bool {
+	return joinTXTChunks(a) == joinTXTChunks(b)
+}
+
+// chunkTXTValue splits values longer than a TXT character string may be into quoted strings of at
+// most txtChunkSize bytes, like designate and BIND represent long TXT records. Shorter values are
+// returned as they are.
+func chunkTXTValue(input string) string {
+	value := stripQuotes(input)
+	if len(value) <= txtChunkSize {
+		return input
+	}
+
+	chunks := make([]string, 0, len(value)/txtChunkSize+1)
+	for len(value) > txtChunkSize {
+		chunks = append(chunks, `"`+value[:txtChunkSize]+`"`)
+		value = value[txtChunkSize:]
+	}
+	chunks = append(chunks, `"`+value+`"`)
+
+	return strings.Join(chunks, " ")
+}
+
+// joinTXTChunks reassembles a value split by chunkTXTValue, dropping the quotes.
+func joinTXTChunks(input string) string {
+	value := stripQuotes(input)
+	if !strings.HasPrefix(input, `"`) || !strings.HasSuffix(input, `"`) {
+		return value
+	}
+
+	return strings.ReplaceAll(value, `" "`, "")
+}
+
+func stripQuotes(input string) string {
+	return strings.TrimSuffix(strings.TrimPrefix(input, `"`), `"`)
+}
+
+// enforceTrailingDot fully qualifies the name with exactly one trailing dot, dropping surrounding
+// whitespace and keeping the case. Empty names stay empty instead of turning into the root ".".
 func enforceTrailingDot(input string) string {
-	if !strings.HasSuffix(input, ".") {
-		input = input + "."
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return ""
 	}
 
-	return input
+	return strings.TrimRight(input, ".") + "."
+}
+
+// canonicalName is the form DNS names are compared in, regardless of how they are configured, sent
+// to or returned by designate: fully qualified as by enforceTrailingDot and lower case.
+func canonicalName(name string) string {
+	return strings.ToLower(enforceTrailingDot(name))
 }
 
-func New() webhook.Solver {
-	return &designateDnsResolver{}
+func New(opts ...Option) webhook.Solver {
+	d := &designateDnsResolver{
+		readiness:        readinessTracker{threshold: defaultReadinessFailureThreshold},
+		operationTimeout: defaultOperationTimeout,
+		tokenCache:       tokenCache{enabled: true, skew: defaultTokenExpirySkew},
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+
+	return d
 }
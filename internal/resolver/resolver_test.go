@@ -1,17 +1,29 @@
 package resolver
 
 import (
+	"bytes"
+	"cmp"
+	"context"
 	"errors"
+	"fmt"
 	"net/http/httptest"
+	"os"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/cert-manager/cert-manager/pkg/acme/webhook/apis/acme/v1alpha1"
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/gophercloud/gophercloud/v2/openstack"
 	"github.com/gophercloud/gophercloud/v2/openstack/dns/v2/recordsets"
 	mockresolver "github.com/rikotsev/cert-manager-webhook-designate/internal/resolver/mock"
 	corev1 "k8s.io/api/core/v1"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/klog/v2"
 )
 
 func TestDesignateDnsResolver_Present(t *testing.T) {
@@ -520,6 +532,7 @@ func TestDesignateDnsResolver_Present(t *testing.T) {
 					secretCopy.Data = make(map[string][]byte)
 				}
 				secretCopy.Data["identityEndpoint"] = []byte(openstackMock.URL)
+				secretCopy.Data["insecureAllowHTTP"] = []byte("true")
 				tc.secret = secretCopy
 			}
 
@@ -735,6 +748,165 @@ func TestDesignateDnsResolver_CleanUp(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "cleanup challenge - stored single record is unquoted, key is quoted",
+			zones: []mockresolver.MockZone{
+				{
+					ID:   "12345",
+					Name: "example.com.",
+				},
+			},
+			recordSets: []mockresolver.MockRecordSet{
+				{
+					ID:     "12345-1",
+					ZoneID: "12345",
+					Name:   "cool.example.com.",
+					Type:   "TXT",
+					Records: []string{
+						"challenge",
+					},
+				},
+			},
+			secret: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "foo",
+					Namespace: "bar",
+				},
+				Data: map[string][]byte{
+					"tenantName": []byte("testTenant"),
+					"tenantId":   []byte("testTenantId"),
+					"domainName": []byte("testDomainName"),
+					"domainId":   []byte("testDomainId"),
+					"username":   []byte("john-doe"),
+					"password":   []byte("secretpass"),
+					"region":     []byte("RegionOne"),
+				},
+			},
+			challengeRequest: &v1alpha1.ChallengeRequest{
+				Key:          `"challenge"`,
+				ResolvedFQDN: "cool.example.com",
+				ResolvedZone: "example.com",
+				Config: &apiextensionsv1.JSON{Raw: []byte(`{
+					"secretName": "foo",
+					"secretNamespace": "bar",
+					"strategy": {
+						"kind": "SOA"
+					}
+				}`)},
+			},
+			expectedRecordSetDelete: &mockresolver.RecordSetDelete{
+				ZoneID:      "12345",
+				RecordSetID: "12345-1",
+			},
+		},
+		{
+			name: "cleanup challenge - stored single record is quoted, key is unquoted",
+			zones: []mockresolver.MockZone{
+				{
+					ID:   "12345",
+					Name: "example.com.",
+				},
+			},
+			recordSets: []mockresolver.MockRecordSet{
+				{
+					ID:     "12345-1",
+					ZoneID: "12345",
+					Name:   "cool.example.com.",
+					Type:   "TXT",
+					Records: []string{
+						`"challenge"`,
+					},
+				},
+			},
+			secret: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "foo",
+					Namespace: "bar",
+				},
+				Data: map[string][]byte{
+					"tenantName": []byte("testTenant"),
+					"tenantId":   []byte("testTenantId"),
+					"domainName": []byte("testDomainName"),
+					"domainId":   []byte("testDomainId"),
+					"username":   []byte("john-doe"),
+					"password":   []byte("secretpass"),
+					"region":     []byte("RegionOne"),
+				},
+			},
+			challengeRequest: &v1alpha1.ChallengeRequest{
+				Key:          "challenge",
+				ResolvedFQDN: "cool.example.com",
+				ResolvedZone: "example.com",
+				Config: &apiextensionsv1.JSON{Raw: []byte(`{
+					"secretName": "foo",
+					"secretNamespace": "bar",
+					"strategy": {
+						"kind": "SOA"
+					}
+				}`)},
+			},
+			expectedRecordSetDelete: &mockresolver.RecordSetDelete{
+				ZoneID:      "12345",
+				RecordSetID: "12345-1",
+			},
+		},
+		{
+			name: "cleanup challenge - remove quoted record among others",
+			zones: []mockresolver.MockZone{
+				{
+					ID:   "12345",
+					Name: "example.com.",
+				},
+			},
+			recordSets: []mockresolver.MockRecordSet{
+				{
+					ID:     "12345-1",
+					ZoneID: "12345",
+					Name:   "cool.example.com.",
+					Type:   "TXT",
+					Records: []string{
+						`"challenge"`,
+						"another-record",
+					},
+				},
+			},
+			secret: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "foo",
+					Namespace: "bar",
+				},
+				Data: map[string][]byte{
+					"tenantName": []byte("testTenant"),
+					"tenantId":   []byte("testTenantId"),
+					"domainName": []byte("testDomainName"),
+					"domainId":   []byte("testDomainId"),
+					"username":   []byte("john-doe"),
+					"password":   []byte("secretpass"),
+					"region":     []byte("RegionOne"),
+				},
+			},
+			challengeRequest: &v1alpha1.ChallengeRequest{
+				Key:          "challenge",
+				ResolvedFQDN: "cool.example.com",
+				ResolvedZone: "example.com",
+				Config: &apiextensionsv1.JSON{Raw: []byte(`{
+					"secretName": "foo",
+					"secretNamespace": "bar",
+					"strategy": {
+						"kind": "SOA"
+					}
+				}`)},
+			},
+			expectedRecordSetPut: &mockresolver.RecordSetPut{
+				ZoneID:      "12345",
+				RecordSetID: "12345-1",
+				Opts: recordsets.UpdateOpts{
+					Records: []string{
+						"another-record",
+					},
+				},
+			},
+		},
 		{
 			name: "cleanup challenge with SOA strategy - no recordset to found",
 			zones: []mockresolver.MockZone{
@@ -800,6 +972,7 @@ func TestDesignateDnsResolver_CleanUp(t *testing.T) {
 					secretCopy.Data = make(map[string][]byte)
 				}
 				secretCopy.Data["identityEndpoint"] = []byte(openstackMock.URL)
+				secretCopy.Data["insecureAllowHTTP"] = []byte("true")
 				tc.secret = secretCopy
 			}
 
@@ -867,3 +1040,1848 @@ func TestDesignateDnsResolver_CleanUp(t *testing.T) {
 		})
 	}
 }
+
+func TestDesignateDnsResolver_Present_SharesConcurrentZoneListings(t *testing.T) {
+	mockApi := mockresolver.CreateMockOpenstackApi(t)
+	mockApi.Zones = []mockresolver.MockZone{
+		{
+			ID:   "12345",
+			Name: "example.com.",
+		},
+	}
+	mockApi.ZoneListDelay = 500 * time.Millisecond
+	resolver := newTestResolver(t, mockApi)
+	// Without the zone cache every challenge lists the zones containing its own name instead.
+	WithZoneCacheTTL(time.Minute)(resolver)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 5)
+	for i := range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- resolver.Present(newChallengeRequest(fmt.Sprintf("challenge-%d", i), fmt.Sprintf("cool-%d.example.com", i), "example.com", `{
+				"secretName": "foo",
+				"secretNamespace": "bar",
+				"strategy": {
+					"kind": "BestEffort"
+				}
+			}`))
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+	}
+
+	if mockApi.ZoneListCalls != 1 {
+		t.Errorf("expected 1 zone listing, got %d", mockApi.ZoneListCalls)
+	}
+
+	if len(mockApi.Updates) != 5 {
+		t.Errorf("expected 5 updates, got %d", len(mockApi.Updates))
+	}
+}
+
+// newTestResolver starts a mock openstack API and returns a resolver whose credentials secret
+// "bar/foo" points at it.
+func newTestResolver(t *testing.T, mockApi *mockresolver.OpenstackApiMock) *designateDnsResolver {
+	t.Helper()
+
+	openstackMock := httptest.NewServer(mockApi)
+	t.Cleanup(openstackMock.Close)
+
+	resolver := new(designateDnsResolver)
+	resolver.configProvider = &authConfigProvider{
+		client: fake.NewClientset(dummySecret("foo", "bar", map[string]string{
+			"tenantName":        "testTenant",
+			"tenantId":          "testTenantId",
+			"domainName":        "testDomainName",
+			"domainId":          "testDomainId",
+			"username":          "john-doe",
+			"password":          "secretpass",
+			"region":            "RegionOne",
+			"identityEndpoint":  openstackMock.URL,
+			"insecureAllowHTTP": "true",
+		})),
+	}
+	resolver.initialized.Store(true)
+
+	return resolver
+}
+
+func newChallengeRequest(key, fqdn, zone, config string) *v1alpha1.ChallengeRequest {
+	return &v1alpha1.ChallengeRequest{
+		Key:          key,
+		ResolvedFQDN: fqdn,
+		ResolvedZone: zone,
+		Config:       &apiextensionsv1.JSON{Raw: []byte(config)},
+	}
+}
+
+func TestDesignateDnsResolver_Present_IssuerNamespaceEnforcement(t *testing.T) {
+	tcs := []struct {
+		name              string
+		enforce           bool
+		resourceNamespace string
+		expectedError     error
+	}{
+		{
+			name:              "enforced - matching namespace",
+			enforce:           true,
+			resourceNamespace: "bar",
+			expectedError:     nil,
+		},
+		{
+			name:              "enforced - mismatching namespace",
+			enforce:           true,
+			resourceNamespace: "other",
+			expectedError:     ErrSecretNamespaceMismatch,
+		},
+		{
+			name:              "not enforced - mismatching namespace",
+			enforce:           false,
+			resourceNamespace: "other",
+			expectedError:     nil,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			mockApi := mockresolver.CreateMockOpenstackApi(t)
+			mockApi.Zones = []mockresolver.MockZone{
+				{
+					ID:   "12345",
+					Name: "example.com.",
+				},
+			}
+			resolver := newTestResolver(t, mockApi)
+			WithIssuerNamespaceEnforcement(tc.enforce)(resolver)
+
+			ch := newChallengeRequest("challenge", "cool.example.com", "example.com", `{
+				"secretName": "foo",
+				"secretNamespace": "bar",
+				"strategy": {
+					"kind": "SOA"
+				}
+			}`)
+			ch.ResourceNamespace = tc.resourceNamespace
+
+			err := resolver.Present(ch)
+			if !errors.Is(err, tc.expectedError) {
+				t.Errorf("expected error %v, got %v", tc.expectedError, err)
+				return
+			}
+
+			if tc.expectedError != nil && len(mockApi.Updates) != 0 {
+				t.Errorf("expected no updates, got %d", len(mockApi.Updates))
+			}
+		})
+	}
+}
+
+func TestDesignateDnsResolver_CleanUp_Summary(t *testing.T) {
+	tcs := []struct {
+		name                     string
+		records                  []string
+		expectedAction           cleanupAction
+		expectedRemainingRecords int
+	}{
+		{
+			name:                     "deleted",
+			records:                  []string{"challenge"},
+			expectedAction:           cleanupActionDeleted,
+			expectedRemainingRecords: 0,
+		},
+		{
+			name:                     "updated",
+			records:                  []string{"challenge", "another-record", "third-record"},
+			expectedAction:           cleanupActionUpdated,
+			expectedRemainingRecords: 2,
+		},
+		{
+			name:                     "noop - no recordset",
+			records:                  nil,
+			expectedAction:           cleanupActionNoop,
+			expectedRemainingRecords: 0,
+		},
+		{
+			name:                     "noop - challenge already removed",
+			records:                  []string{"another-record"},
+			expectedAction:           cleanupActionNoop,
+			expectedRemainingRecords: 1,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			mockApi := mockresolver.CreateMockOpenstackApi(t)
+			mockApi.Zones = []mockresolver.MockZone{
+				{
+					ID:   "12345",
+					Name: "example.com.",
+				},
+			}
+			if tc.records != nil {
+				mockApi.RecordSets = []mockresolver.MockRecordSet{
+					{
+						ID:      "12345-1",
+						ZoneID:  "12345",
+						Name:    "cool.example.com.",
+						Type:    "TXT",
+						Records: tc.records,
+					},
+				}
+			}
+			resolver := newTestResolver(t, mockApi)
+
+			summary, err := resolver.cleanUp(context.Background(), newChallengeRequest("challenge", "cool.example.com", "example.com", `{
+				"secretName": "foo",
+				"secretNamespace": "bar",
+				"strategy": {
+					"kind": "SOA"
+				}
+			}`), []string{"challenge"}, nil)
+			if err != nil {
+				t.Errorf("expected no error, got %v", err)
+				return
+			}
+
+			if summary.action != tc.expectedAction {
+				t.Errorf("expected action %s, got %s", tc.expectedAction, summary.action)
+			}
+
+			if summary.remainingRecords != tc.expectedRemainingRecords {
+				t.Errorf("expected %d remaining records, got %d", tc.expectedRemainingRecords, summary.remainingRecords)
+			}
+
+			if summary.action == cleanupActionNoop && len(mockApi.RecordSetPuts)+len(mockApi.RecordSetDeletes) != 0 {
+				t.Errorf("expected no writes for a noop cleanup")
+			}
+		})
+	}
+}
+
+func TestDesignateDnsResolver_CleanUp_DuplicateRecordSets(t *testing.T) {
+	mockApi := mockresolver.CreateMockOpenstackApi(t)
+	mockApi.Zones = []mockresolver.MockZone{
+		{
+			ID:   "12345",
+			Name: "example.com.",
+		},
+	}
+	mockApi.RecordSets = []mockresolver.MockRecordSet{
+		{
+			ID:      "12345-1",
+			ZoneID:  "12345",
+			Name:    "cool.example.com.",
+			Type:    "TXT",
+			Records: []string{"challenge", "another-record"},
+		},
+		{
+			ID:      "12345-2",
+			ZoneID:  "12345",
+			Name:    "cool.example.com.",
+			Type:    "TXT",
+			Records: []string{"challenge"},
+		},
+	}
+	resolver := newTestResolver(t, mockApi)
+
+	summary, err := resolver.cleanUp(context.Background(), newChallengeRequest("challenge", "cool.example.com", "example.com", `{
+		"secretName": "foo",
+		"secretNamespace": "bar",
+		"strategy": {
+			"kind": "SOA"
+		}
+	}`), []string{"challenge"}, nil)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(mockApi.RecordSetPuts) != 1 || mockApi.RecordSetPuts[0].RecordSetID != "12345-1" || !slices.Equal(mockApi.RecordSetPuts[0].Opts.Records, []string{"another-record"}) {
+		t.Errorf("expected the challenge to be removed from 12345-1, got %v", mockApi.RecordSetPuts)
+	}
+	if len(mockApi.RecordSetDeletes) != 1 || mockApi.RecordSetDeletes[0].RecordSetID != "12345-2" {
+		t.Errorf("expected 12345-2 to be deleted, got %v", mockApi.RecordSetDeletes)
+	}
+	if summary.action != cleanupActionUpdated || summary.recordSetId != "12345-1" || summary.remainingRecords != 1 {
+		t.Errorf("expected an update of 12345-1 leaving 1 record, got %+v", summary)
+	}
+}
+
+func TestDesignateDnsResolver_CleanUp_ForeignRecordsWarning(t *testing.T) {
+	tcs := []struct {
+		name            string
+		records         []string
+		ownershipMarker bool
+		expectedWarning string
+	}{
+		{
+			name:            "foreign records remain",
+			records:         []string{"\"challenge\"", "\"another-record\"", "\"third-record\""},
+			expectedWarning: "2 foreign records remain in recordset rs-1 of cool.example.com",
+		},
+		{
+			name:            "ownership marker is not foreign",
+			records:         []string{"\"challenge\"", "\"another-record\""},
+			ownershipMarker: true,
+			expectedWarning: "1 foreign records remain in recordset rs-1 of cool.example.com",
+		},
+		{
+			name:    "recordset deleted",
+			records: []string{"\"challenge\""},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			klog.LogToStderr(false)
+			klog.SetOutput(&logs)
+			t.Cleanup(func() {
+				klog.SetOutput(os.Stderr)
+				klog.LogToStderr(true)
+			})
+
+			ch := newChallengeRequest("challenge", "cool.example.com", "example.com", fmt.Sprintf(`{
+				"secretName": "foo",
+				"secretNamespace": "bar",
+				"ownershipMarker": %t,
+				"strategy": {
+					"kind": "SOA"
+				}
+			}`, tc.ownershipMarker))
+			records := tc.records
+			if tc.ownershipMarker {
+				cfg, err := ParseConfig(ch.Config)
+				if err != nil {
+					t.Fatalf("failed to parse config: %v", err)
+				}
+				records = append(records, ownershipMarker(ch, cfg))
+			}
+
+			mockApi := mockresolver.CreateMockOpenstackApi(t)
+			mockApi.Zones = []mockresolver.MockZone{
+				{
+					ID:   "12345",
+					Name: "example.com.",
+				},
+			}
+			mockApi.RecordSets = []mockresolver.MockRecordSet{
+				{
+					ID:      "rs-1",
+					ZoneID:  "12345",
+					Name:    "cool.example.com.",
+					Type:    "TXT",
+					Records: records,
+				},
+			}
+			resolver := newTestResolver(t, mockApi)
+
+			if err := resolver.CleanUp(ch); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			klog.Flush()
+
+			if tc.expectedWarning == "" {
+				if strings.Contains(logs.String(), "foreign records") {
+					t.Errorf("expected no warning, got %s", logs.String())
+				}
+				return
+			}
+
+			if !strings.Contains(logs.String(), tc.expectedWarning) {
+				t.Errorf("expected warning %q, got %s", tc.expectedWarning, logs.String())
+			}
+			if strings.Contains(logs.String(), "another-record") {
+				t.Errorf("expected foreign values to be redacted, got %s", logs.String())
+			}
+		})
+	}
+}
+
+func TestDesignateDnsResolver_Present_MaxConcurrentChallenges(t *testing.T) {
+	mockApi := mockresolver.CreateMockOpenstackApi(t)
+	mockApi.ZoneListDelay = 200 * time.Millisecond
+	for i := range 6 {
+		mockApi.Zones = append(mockApi.Zones, mockresolver.MockZone{
+			ID:   fmt.Sprintf("zone-%d", i),
+			Name: fmt.Sprintf("example%d.com.", i),
+		})
+	}
+	resolver := newTestResolver(t, mockApi)
+	WithMaxConcurrentChallenges(2)(resolver)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 6)
+	for i := range 6 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			zone := fmt.Sprintf("example%d.com", i)
+			errs <- resolver.Present(newChallengeRequest("challenge", "cool."+zone, zone, `{
+				"secretName": "foo",
+				"secretNamespace": "bar",
+				"strategy": {
+					"kind": "SOA"
+				}
+			}`))
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+	}
+
+	if mockApi.MaxZoneListsInFlight > 2 {
+		t.Errorf("expected at most 2 concurrent challenges, observed %d", mockApi.MaxZoneListsInFlight)
+	}
+
+	if len(mockApi.Updates) != 6 {
+		t.Errorf("expected 6 updates, got %d", len(mockApi.Updates))
+	}
+}
+
+func TestDesignateDnsResolver_Present_ZoneNameFallback(t *testing.T) {
+	tcs := []struct {
+		name           string
+		fallback       bool
+		expectedError  error
+		expectedZoneID string
+	}{
+		{
+			name:           "fallback enabled - parent zone matches",
+			fallback:       true,
+			expectedError:  nil,
+			expectedZoneID: "12345",
+		},
+		{
+			name:          "fallback disabled - no zone",
+			fallback:      false,
+			expectedError: ErrNoZones,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			mockApi := mockresolver.CreateMockOpenstackApi(t)
+			mockApi.Zones = []mockresolver.MockZone{
+				{
+					ID:   "12345",
+					Name: "example.com.",
+				},
+				{
+					ID:   "67890",
+					Name: "other.com.",
+				},
+			}
+			resolver := newTestResolver(t, mockApi)
+
+			err := resolver.Present(newChallengeRequest("challenge", "cool.sub.example.com", "example.com", fmt.Sprintf(`{
+				"secretName": "foo",
+				"secretNamespace": "bar",
+				"strategy": {
+					"kind": "ZoneName",
+					"zoneName": "sub.example.com.",
+					"zoneNameFallback": %t
+				}
+			}`, tc.fallback)))
+			if !errors.Is(err, tc.expectedError) {
+				t.Errorf("expected error %v, got %v", tc.expectedError, err)
+				return
+			}
+
+			if tc.expectedError != nil {
+				return
+			}
+
+			if len(mockApi.Updates) != 1 {
+				t.Errorf("expected 1 update, got %d", len(mockApi.Updates))
+				return
+			}
+
+			if mockApi.Updates[0].ZoneID != tc.expectedZoneID {
+				t.Errorf("expected zone ID %s, got %s", tc.expectedZoneID, mockApi.Updates[0].ZoneID)
+			}
+		})
+	}
+}
+
+func TestDesignateDnsResolver_Present_AutoStrategy(t *testing.T) {
+	tcs := []struct {
+		name               string
+		fqdn               string
+		resolvedZone       string
+		expectedError      error
+		expectedZoneID     string
+		expectedZoneListed int
+	}{
+		{
+			name:               "SOA hit",
+			fqdn:               "cool.sub.example.com",
+			resolvedZone:       "sub.example.com",
+			expectedZoneID:     "67890",
+			expectedZoneListed: 1,
+		},
+		{
+			name:               "SOA miss then BestEffort hit",
+			fqdn:               "cool.delegated.example.com",
+			resolvedZone:       "delegated.example.com",
+			expectedZoneID:     "12345",
+			expectedZoneListed: 2,
+		},
+		{
+			name:               "both miss",
+			fqdn:               "cool.example.org",
+			resolvedZone:       "example.org",
+			expectedError:      ErrNoZones,
+			expectedZoneListed: 2,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			mockApi := mockresolver.CreateMockOpenstackApi(t)
+			mockApi.Zones = []mockresolver.MockZone{
+				{
+					ID:   "12345",
+					Name: "example.com.",
+				},
+				{
+					ID:   "67890",
+					Name: "sub.example.com.",
+				},
+			}
+			resolver := newTestResolver(t, mockApi)
+
+			err := resolver.Present(newChallengeRequest("challenge", tc.fqdn, tc.resolvedZone, `{
+				"secretName": "foo",
+				"secretNamespace": "bar",
+				"strategy": {
+					"kind": "Auto"
+				}
+			}`))
+			if len(mockApi.ZoneListQueries) != tc.expectedZoneListed {
+				t.Errorf("expected %d zone listings, got %v", tc.expectedZoneListed, mockApi.ZoneListQueries)
+			}
+			if !errors.Is(err, tc.expectedError) {
+				t.Fatalf("expected error %v, got %v", tc.expectedError, err)
+			}
+			if tc.expectedError != nil {
+				if len(mockApi.Updates) != 0 {
+					t.Errorf("expected no recordset to be created, got %v", mockApi.Updates)
+				}
+				return
+			}
+
+			if len(mockApi.Updates) != 1 || mockApi.Updates[0].ZoneID != tc.expectedZoneID {
+				t.Errorf("expected 1 create in zone %s, got %v", tc.expectedZoneID, mockApi.Updates)
+			}
+		})
+	}
+}
+
+func TestDesignateDnsResolver_Present_AmbiguousZone(t *testing.T) {
+	tcs := []struct {
+		name            string
+		zones           []mockresolver.MockZone
+		failOnAmbiguous bool
+		expectedError   error
+		expectedZoneID  string
+		expectedWarning string
+	}{
+		{
+			name:           "single match",
+			zones:          []mockresolver.MockZone{{ID: "12345", Name: "example.com."}},
+			expectedZoneID: "12345",
+		},
+		{
+			name:            "multiple matches warn",
+			zones:           []mockresolver.MockZone{{ID: "12345", Name: "example.com."}, {ID: "67890", Name: "example.com."}},
+			expectedZoneID:  "12345",
+			expectedWarning: "2 zones are named example.com., using the first one 12345 of 12345, 67890",
+		},
+		{
+			name:            "multiple matches fail",
+			zones:           []mockresolver.MockZone{{ID: "12345", Name: "example.com."}, {ID: "67890", Name: "example.com."}},
+			failOnAmbiguous: true,
+			expectedError:   ErrAmbiguousZone,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			klog.LogToStderr(false)
+			klog.SetOutput(&logs)
+			t.Cleanup(func() {
+				klog.SetOutput(os.Stderr)
+				klog.LogToStderr(true)
+			})
+
+			mockApi := mockresolver.CreateMockOpenstackApi(t)
+			mockApi.Zones = tc.zones
+			resolver := newTestResolver(t, mockApi)
+
+			err := resolver.Present(newChallengeRequest("challenge", "cool.example.com", "example.com", fmt.Sprintf(`{
+				"secretName": "foo",
+				"secretNamespace": "bar",
+				"failOnAmbiguousZone": %t,
+				"strategy": {
+					"kind": "SOA"
+				}
+			}`, tc.failOnAmbiguous)))
+			klog.Flush()
+			if !errors.Is(err, tc.expectedError) {
+				t.Fatalf("expected error %v, got %v", tc.expectedError, err)
+			}
+			if tc.expectedError != nil {
+				if len(mockApi.Updates) != 0 {
+					t.Errorf("expected no recordset to be created, got %v", mockApi.Updates)
+				}
+				return
+			}
+
+			if len(mockApi.Updates) != 1 || mockApi.Updates[0].ZoneID != tc.expectedZoneID {
+				t.Errorf("expected 1 create in zone %s, got %v", tc.expectedZoneID, mockApi.Updates)
+			}
+			if tc.expectedWarning != "" && !strings.Contains(logs.String(), tc.expectedWarning) {
+				t.Errorf("expected warning %q, got %s", tc.expectedWarning, logs.String())
+			}
+			if tc.expectedWarning == "" && strings.Contains(logs.String(), "zones are named") {
+				t.Errorf("expected no warning, got %s", logs.String())
+			}
+		})
+	}
+}
+
+func TestDesignateDnsResolver_OwnershipMarker(t *testing.T) {
+	mockApi := mockresolver.CreateMockOpenstackApi(t)
+	mockApi.Zones = []mockresolver.MockZone{
+		{
+			ID:   "12345",
+			Name: "example.com.",
+		},
+	}
+	resolver := newTestResolver(t, mockApi)
+
+	config := `{
+		"secretName": "foo",
+		"secretNamespace": "bar",
+		"ownershipMarker": true,
+		"strategy": {
+			"kind": "SOA"
+		}
+	}`
+	first := newChallengeRequest("challenge-1", "cool.example.com", "example.com", config)
+	second := newChallengeRequest("challenge-2", "cool.example.com", "example.com", config)
+	cfg, err := ParseConfig(first.Config)
+	if err != nil {
+		t.Fatalf("failed to parse config: %v", err)
+	}
+	marker := ownershipMarker(first, cfg)
+
+	for _, ch := range []*v1alpha1.ChallengeRequest{first, second} {
+		if err := resolver.Present(ch); err != nil {
+			t.Fatalf("expected no error presenting %s, got %v", ch.Key, err)
+		}
+	}
+
+	if len(mockApi.RecordSets) != 1 {
+		t.Fatalf("expected 1 recordset, got %d", len(mockApi.RecordSets))
+	}
+
+	expectedRecords := []string{"challenge-1", marker, "challenge-2"}
+	if !slices.Equal(mockApi.RecordSets[0].Records, expectedRecords) {
+		t.Errorf("expected records %v, got %v", expectedRecords, mockApi.RecordSets[0].Records)
+	}
+
+	if err := resolver.CleanUp(first); err != nil {
+		t.Fatalf("expected no error cleaning up, got %v", err)
+	}
+
+	expectedRecords = []string{marker, "challenge-2"}
+	if !slices.Equal(mockApi.RecordSets[0].Records, expectedRecords) {
+		t.Errorf("expected records %v, got %v", expectedRecords, mockApi.RecordSets[0].Records)
+	}
+
+	if err := resolver.CleanUp(second); err != nil {
+		t.Fatalf("expected no error cleaning up, got %v", err)
+	}
+
+	if len(mockApi.RecordSets) != 0 {
+		t.Errorf("expected the recordset with the marker to be deleted, got %v", mockApi.RecordSets)
+	}
+}
+
+func TestDesignateDnsResolver_Present_WithProviderClient(t *testing.T) {
+	mockApi := mockresolver.CreateMockOpenstackApi(t)
+	mockApi.Zones = []mockresolver.MockZone{
+		{
+			ID:   "12345",
+			Name: "example.com.",
+		},
+	}
+	openstackMock := httptest.NewServer(mockApi)
+	defer openstackMock.Close()
+
+	providerClient, err := openstack.AuthenticatedClient(context.Background(), gophercloud.AuthOptions{
+		IdentityEndpoint: openstackMock.URL,
+		Username:         "john-doe",
+		Password:         "secretpass",
+		TenantID:         "testTenantId",
+	})
+	if err != nil {
+		t.Fatalf("failed to authenticate against the mock: %v", err)
+	}
+
+	// No credentials secret exists, the injected provider client has to be used.
+	resolver := New(WithProviderClient(providerClient, gophercloud.EndpointOpts{Region: "RegionOne"}), WithDecisionRecording(true)).(*designateDnsResolver)
+	resolver.configProvider = &authConfigProvider{client: fake.NewClientset()}
+
+	err = resolver.Present(newChallengeRequest("challenge", "cool.example.com", "example.com", `{
+		"secretName": "foo",
+		"secretNamespace": "bar",
+		"strategy": {
+			"kind": "SOA"
+		}
+	}`))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(mockApi.Updates) != 1 || mockApi.Updates[0].ZoneID != "12345" {
+		t.Errorf("expected 1 update in zone 12345, got %v", mockApi.Updates)
+	}
+	if decision, _ := resolver.LastDecision(); decision.CredentialSource != CredentialSourceProviderClient {
+		t.Errorf("expected credential source %s, got %q", CredentialSourceProviderClient, decision.CredentialSource)
+	}
+}
+
+func TestDesignateDnsResolver_Present_ZoneNameOutsideOfFQDN(t *testing.T) {
+	mockApi := mockresolver.CreateMockOpenstackApi(t)
+	mockApi.Zones = []mockresolver.MockZone{
+		{
+			ID:   "12345",
+			Name: "example.org.",
+		},
+	}
+	resolver := newTestResolver(t, mockApi)
+
+	err := resolver.Present(newChallengeRequest("challenge", "cool.example.com", "example.com", `{
+		"secretName": "foo",
+		"secretNamespace": "bar",
+		"strategy": {
+			"kind": "ZoneName",
+			"zoneName": "example.org."
+		}
+	}`))
+	if !errors.Is(err, ErrZoneMismatch) {
+		t.Errorf("expected error %v, got %v", ErrZoneMismatch, err)
+	}
+
+	if mockApi.ZoneListCalls != 0 || len(mockApi.Updates) != 0 {
+		t.Errorf("expected no API calls, got %d zone listings and %d updates", mockApi.ZoneListCalls, len(mockApi.Updates))
+	}
+}
+
+func TestDesignateDnsResolver_ZoneID(t *testing.T) {
+	mockApi := mockresolver.CreateMockOpenstackApi(t)
+	mockApi.Zones = []mockresolver.MockZone{
+		{
+			ID:   "12345",
+			Name: "example.com.",
+		},
+		{
+			ID:   "67890",
+			Name: "cool.example.com.",
+		},
+	}
+	resolver := newTestResolver(t, mockApi)
+
+	config := `{
+		"secretName": "foo",
+		"secretNamespace": "bar",
+		"strategy": {
+			"kind": "ZoneID",
+			"zoneId": "12345"
+		}
+	}`
+
+	if err := resolver.Present(newChallengeRequest("challenge", "_acme-challenge.cool.example.com", "example.com", config)); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(mockApi.Updates) != 1 || mockApi.Updates[0].ZoneID != "12345" {
+		t.Errorf("expected 1 update in zone 12345, got %v", mockApi.Updates)
+	}
+
+	if err := resolver.CleanUp(newChallengeRequest("challenge", "_acme-challenge.cool.example.com", "example.com", config)); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(mockApi.RecordSetDeletes) != 1 {
+		t.Errorf("expected 1 recordset delete, got %d", len(mockApi.RecordSetDeletes))
+	}
+
+	if mockApi.ZoneListCalls != 0 {
+		t.Errorf("expected no zone listing, got %d", mockApi.ZoneListCalls)
+	}
+
+	if mockApi.ZoneGets != 2 {
+		t.Errorf("expected 2 zone gets, got %d", mockApi.ZoneGets)
+	}
+
+	err := resolver.Present(newChallengeRequest("challenge", "_acme-challenge.cool.example.com", "example.com", `{
+		"secretName": "foo",
+		"secretNamespace": "bar",
+		"strategy": {
+			"kind": "ZoneID",
+			"zoneId": "unknown"
+		}
+	}`))
+	if !errors.Is(err, ErrNoZones) {
+		t.Errorf("expected error %v, got %v", ErrNoZones, err)
+	}
+}
+
+func TestDesignateDnsResolver_RecordNameOverride(t *testing.T) {
+	tcs := []struct {
+		name          string
+		strategy      string
+		expectedZone  string
+		expectedError error
+	}{
+		{
+			name:         "best effort picks the zone of the delegated name",
+			strategy:     `{"kind": "BestEffort"}`,
+			expectedZone: "67890",
+		},
+		{
+			name:         "zone name of the delegation zone",
+			strategy:     `{"kind": "ZoneName", "zoneName": "acme.delegated.net."}`,
+			expectedZone: "67890",
+		},
+		{
+			name:          "soa zone does not contain the delegated name",
+			strategy:      `{"kind": "SOA"}`,
+			expectedError: ErrZoneMismatch,
+		},
+		{
+			name:          "zone name does not contain the delegated name",
+			strategy:      `{"kind": "ZoneName", "zoneName": "example.com."}`,
+			expectedError: ErrZoneMismatch,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			mockApi := mockresolver.CreateMockOpenstackApi(t)
+			mockApi.Zones = []mockresolver.MockZone{
+				{
+					ID:   "12345",
+					Name: "example.com.",
+				},
+				{
+					ID:   "67890",
+					Name: "acme.delegated.net.",
+				},
+			}
+			resolver := newTestResolver(t, mockApi)
+
+			ch := newChallengeRequest("challenge", "_acme-challenge.example.com", "example.com", fmt.Sprintf(`{
+				"secretName": "foo",
+				"secretNamespace": "bar",
+				"recordNameOverride": "example-com.acme.delegated.net",
+				"strategy": %s
+			}`, tc.strategy))
+
+			err := resolver.Present(ch)
+			if tc.expectedError != nil {
+				if !errors.Is(err, tc.expectedError) {
+					t.Errorf("expected error %v, got %v", tc.expectedError, err)
+				}
+				if len(mockApi.Updates) != 0 {
+					t.Errorf("expected no recordset to be created, got %v", mockApi.Updates)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if len(mockApi.Updates) != 1 {
+				t.Fatalf("expected 1 created recordset, got %d", len(mockApi.Updates))
+			}
+			if mockApi.Updates[0].ZoneID != tc.expectedZone || mockApi.Updates[0].Opts.Name != "example-com.acme.delegated.net." {
+				t.Errorf("expected recordset example-com.acme.delegated.net. in zone %s, got %s in zone %s", tc.expectedZone, mockApi.Updates[0].Opts.Name, mockApi.Updates[0].ZoneID)
+			}
+
+			if err := resolver.CleanUp(ch); err != nil {
+				t.Fatalf("expected no error cleaning up, got %v", err)
+			}
+			if len(mockApi.RecordSetDeletes) != 1 || len(mockApi.RecordSets) != 0 {
+				t.Errorf("expected the delegated recordset to be deleted, got %d deletes and %v left", len(mockApi.RecordSetDeletes), mockApi.RecordSets)
+			}
+		})
+	}
+}
+
+func TestDesignateDnsResolver_RecordNameFunc(t *testing.T) {
+	mockApi := mockresolver.CreateMockOpenstackApi(t)
+	mockApi.Zones = []mockresolver.MockZone{
+		{
+			ID:   "12345",
+			Name: "example.com.",
+		},
+		{
+			ID:   "67890",
+			Name: "acme.delegated.net.",
+		},
+	}
+	resolver := newTestResolver(t, mockApi)
+	WithRecordNameFunc(func(ch *v1alpha1.ChallengeRequest) string {
+		return strings.ReplaceAll(strings.TrimPrefix(strings.TrimSuffix(ch.ResolvedFQDN, "."), "_acme-challenge."), ".", "-") + ".acme.delegated.net"
+	})(resolver)
+	config := `{
+		"secretName": "foo",
+		"secretNamespace": "bar",
+		"strategy": {"kind": "BestEffort"}
+	}`
+	first := newChallengeRequest("first", "_acme-challenge.example.com", "example.com", config)
+	second := newChallengeRequest("second", "_acme-challenge.example.com", "example.com", config)
+
+	for _, ch := range []*v1alpha1.ChallengeRequest{first, second} {
+		if err := resolver.Present(ch); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+	if len(mockApi.Updates) != 1 || len(mockApi.RecordSetPuts) != 1 {
+		t.Fatalf("expected one create and one update, got %d creates and %d updates", len(mockApi.Updates), len(mockApi.RecordSetPuts))
+	}
+	if mockApi.Updates[0].ZoneID != "67890" || mockApi.Updates[0].Opts.Name != "example-com.acme.delegated.net." {
+		t.Errorf("expected recordset example-com.acme.delegated.net. in zone 67890, got %s in zone %s", mockApi.Updates[0].Opts.Name, mockApi.Updates[0].ZoneID)
+	}
+	if mockApi.RecordSetPuts[0].ZoneID != "67890" {
+		t.Errorf("expected the update in zone 67890, got %s", mockApi.RecordSetPuts[0].ZoneID)
+	}
+
+	for _, ch := range []*v1alpha1.ChallengeRequest{first, second} {
+		if err := resolver.CleanUp(ch); err != nil {
+			t.Fatalf("expected no error cleaning up, got %v", err)
+		}
+	}
+	if len(mockApi.RecordSetPuts) != 2 || len(mockApi.RecordSetDeletes) != 1 || len(mockApi.RecordSets) != 0 {
+		t.Errorf("expected the derived recordset to be updated and then deleted, got %d updates, %d deletes and %v left", len(mockApi.RecordSetPuts), len(mockApi.RecordSetDeletes), mockApi.RecordSets)
+	}
+}
+
+func TestDesignateDnsResolver_SecondaryZone(t *testing.T) {
+	tcs := []struct {
+		name     string
+		strategy string
+		records  []string
+	}{
+		{
+			name:     "SOA",
+			strategy: `{"kind": "SOA"}`,
+		},
+		{
+			name:     "BestEffort",
+			strategy: `{"kind": "BestEffort"}`,
+		},
+		{
+			name:     "ZoneName",
+			strategy: `{"kind": "ZoneName", "zoneName": "example.com."}`,
+		},
+		{
+			name:     "ZoneName with an existing recordset",
+			strategy: `{"kind": "ZoneName", "zoneName": "example.com."}`,
+			records:  []string{"\"other\""},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			mockApi := mockresolver.CreateMockOpenstackApi(t)
+			mockApi.Zones = []mockresolver.MockZone{
+				{
+					ID:   "12345",
+					Name: "example.com.",
+					Type: "SECONDARY",
+				},
+			}
+			if tc.records != nil {
+				mockApi.RecordSets = []mockresolver.MockRecordSet{
+					{
+						ID:      "rs-1",
+						ZoneID:  "12345",
+						Name:    "cool.example.com.",
+						Type:    "TXT",
+						Records: tc.records,
+					},
+				}
+			}
+			resolver := newTestResolver(t, mockApi)
+
+			ch := newChallengeRequest("challenge", "cool.example.com", "example.com", fmt.Sprintf(`{
+				"secretName": "foo",
+				"secretNamespace": "bar",
+				"strategy": %s
+			}`, tc.strategy))
+
+			if err := resolver.Present(ch); !errors.Is(err, ErrZoneNotWritable) {
+				t.Errorf("expected error %v from Present, got %v", ErrZoneNotWritable, err)
+			}
+			if err := resolver.CleanUp(ch); !errors.Is(err, ErrZoneNotWritable) {
+				t.Errorf("expected error %v from CleanUp, got %v", ErrZoneNotWritable, err)
+			}
+
+			if len(mockApi.Updates) != 0 || len(mockApi.RecordSetPuts) != 0 || len(mockApi.RecordSetDeletes) != 0 {
+				t.Errorf("expected no writes, got %d creates, %d updates and %d deletes", len(mockApi.Updates), len(mockApi.RecordSetPuts), len(mockApi.RecordSetDeletes))
+			}
+		})
+	}
+}
+
+func TestDesignateDnsResolver_Present_SkipsInactiveZones(t *testing.T) {
+	tcs := []struct {
+		name  string
+		zones []mockresolver.MockZone
+	}{
+		{
+			name: "longer suffix match is pending",
+			zones: []mockresolver.MockZone{
+				{ID: "12345", Name: "example.com."},
+				{ID: "67890", Name: "cool.example.com.", Status: "PENDING"},
+			},
+		},
+		{
+			name: "stale duplicate during a migration",
+			zones: []mockresolver.MockZone{
+				{ID: "67890", Name: "example.com.", Status: "ERROR"},
+				{ID: "12345", Name: "example.com."},
+			},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			mockApi := mockresolver.CreateMockOpenstackApi(t)
+			mockApi.Zones = tc.zones
+			resolver := newTestResolver(t, mockApi)
+
+			err := resolver.Present(newChallengeRequest("challenge", "_acme-challenge.cool.example.com", "example.com", `{
+				"secretName": "foo",
+				"secretNamespace": "bar",
+				"strategy": {
+					"kind": "BestEffort"
+				}
+			}`))
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if len(mockApi.Updates) != 1 || mockApi.Updates[0].ZoneID != "12345" {
+				t.Errorf("expected 1 update in the active zone 12345, got %v", mockApi.Updates)
+			}
+		})
+	}
+}
+
+func TestDesignateDnsResolver_Present_MixedCaseZoneNames(t *testing.T) {
+	tcs := []struct {
+		name     string
+		fqdn     string
+		zone     string
+		strategy string
+	}{
+		{
+			name:     "BestEffort with a mixed case zone",
+			fqdn:     "cool.example.com",
+			strategy: `{"kind": "BestEffort"}`,
+		},
+		{
+			name:     "BestEffort with a mixed case FQDN",
+			fqdn:     "Cool.EXAMPLE.com.",
+			strategy: `{"kind": "BestEffort"}`,
+		},
+		{
+			name:     "SOA with a mixed case resolved zone",
+			fqdn:     "cool.example.com",
+			zone:     "EXAMPLE.com",
+			strategy: `{"kind": "SOA"}`,
+		},
+		{
+			name:     "ZoneName in lower case",
+			fqdn:     "cool.example.com",
+			strategy: `{"kind": "ZoneName", "zoneName": "example.com"}`,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			mockApi := mockresolver.CreateMockOpenstackApi(t)
+			mockApi.Zones = []mockresolver.MockZone{
+				{ID: "12345", Name: "Example.COM."},
+				{ID: "67890", Name: "example.org."},
+			}
+			resolver := newTestResolver(t, mockApi)
+
+			err := resolver.Present(newChallengeRequest("challenge", tc.fqdn, cmp.Or(tc.zone, "example.com"), fmt.Sprintf(`{
+				"secretName": "foo",
+				"secretNamespace": "bar",
+				"strategy": %s
+			}`, tc.strategy)))
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if len(mockApi.Updates) != 1 || mockApi.Updates[0].ZoneID != "12345" {
+				t.Errorf("expected 1 update in zone 12345, got %v", mockApi.Updates)
+			}
+		})
+	}
+}
+
+func TestDesignateDnsResolver_Present_ZoneNameList(t *testing.T) {
+	tcs := []struct {
+		name          string
+		fqdn          string
+		expectedZone  string
+		expectedError error
+	}{
+		{name: "first zone", fqdn: "cool.example.com", expectedZone: "12345"},
+		{name: "second zone", fqdn: "cool.example.org", expectedZone: "67890"},
+		{name: "longest of nested zones", fqdn: "cool.sub.example.net", expectedZone: "24680"},
+		{name: "no zone", fqdn: "cool.example.io", expectedError: ErrZoneMismatch},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			mockApi := mockresolver.CreateMockOpenstackApi(t)
+			mockApi.Zones = []mockresolver.MockZone{
+				{ID: "12345", Name: "example.com."},
+				{ID: "67890", Name: "example.org."},
+				{ID: "13579", Name: "example.net."},
+				{ID: "24680", Name: "sub.example.net."},
+			}
+			resolver := newTestResolver(t, mockApi)
+
+			err := resolver.Present(newChallengeRequest("challenge", tc.fqdn, "", `{
+				"secretName": "foo",
+				"secretNamespace": "bar",
+				"strategy": {
+					"kind": "ZoneName",
+					"zoneName": ["example.com.", "example.org", "example.net.", "sub.example.net."]
+				}
+			}`))
+			if !errors.Is(err, tc.expectedError) {
+				t.Fatalf("expected error %v, got %v", tc.expectedError, err)
+			}
+			if tc.expectedError != nil {
+				return
+			}
+
+			if len(mockApi.Updates) != 1 || mockApi.Updates[0].ZoneID != tc.expectedZone {
+				t.Errorf("expected 1 update in zone %s, got %v", tc.expectedZone, mockApi.Updates)
+			}
+		})
+	}
+}
+
+func TestDesignateDnsResolver_Present_Regex(t *testing.T) {
+	mockApi := mockresolver.CreateMockOpenstackApi(t)
+	mockApi.Zones = []mockresolver.MockZone{
+		{ID: "12345", Name: "example.com."},
+		{ID: "67890", Name: "staging.example.com."},
+		{ID: "13579", Name: "team.staging.example.com."},
+		{ID: "24680", Name: "prod.example.com."},
+	}
+	resolver := newTestResolver(t, mockApi)
+
+	config := `{
+		"secretName": "foo",
+		"secretNamespace": "bar",
+		"strategy": {
+			"kind": "Regex",
+			"zonePattern": "^.*\\.staging\\.example\\.com\\.$"
+		}
+	}`
+
+	if err := resolver.Present(newChallengeRequest("challenge", "cool.team.staging.example.com", "", config)); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(mockApi.Updates) != 1 || mockApi.Updates[0].ZoneID != "13579" {
+		t.Errorf("expected 1 update in zone 13579, got %v", mockApi.Updates)
+	}
+
+	// prod.example.com. contains the name, but does not match the pattern
+	err := resolver.Present(newChallengeRequest("challenge", "cool.prod.example.com", "", config))
+	if !errors.Is(err, ErrNoZones) {
+		t.Errorf("expected error %v, got %v", ErrNoZones, err)
+	}
+}
+
+func TestDesignateDnsResolver_Present_EqualMatchesTieBreak(t *testing.T) {
+	// Both orders of the listing must select the zone with the lowest ID.
+	for _, zones := range [][]mockresolver.MockZone{
+		{{ID: "bbbbb", Name: "example.com."}, {ID: "aaaaa", Name: "example.com."}},
+		{{ID: "aaaaa", Name: "example.com."}, {ID: "bbbbb", Name: "example.com."}},
+	} {
+		mockApi := mockresolver.CreateMockOpenstackApi(t)
+		mockApi.Zones = zones
+		resolver := newTestResolver(t, mockApi)
+
+		err := resolver.Present(newChallengeRequest("challenge", "cool.example.com", "example.com", `{
+			"secretName": "foo",
+			"secretNamespace": "bar",
+			"strategy": {
+				"kind": "BestEffort"
+			}
+		}`))
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if len(mockApi.Updates) != 1 || mockApi.Updates[0].ZoneID != "aaaaa" {
+			t.Errorf("expected 1 update in zone aaaaa for listing order %v, got %v", zones, mockApi.Updates)
+		}
+	}
+}
+
+func TestDesignateDnsResolver_ZoneView(t *testing.T) {
+	mockApi := mockresolver.CreateMockOpenstackApi(t)
+	mockApi.Zones = []mockresolver.MockZone{
+		{ID: "default", Name: "example.com."},
+		{ID: "internal", Name: "example.com.", View: "internal"},
+		{ID: "external", Name: "example.com.", View: "external"},
+	}
+	resolver := newTestResolver(t, mockApi)
+	// Views must not share zone listings.
+	WithZoneCacheTTL(time.Minute)(resolver)
+
+	tcs := []struct {
+		name         string
+		zoneView     string
+		expectedZone string
+	}{
+		{
+			name:         "external view",
+			zoneView:     "external",
+			expectedZone: "external",
+		},
+		{
+			name:         "internal view",
+			zoneView:     "internal",
+			expectedZone: "internal",
+		},
+		{
+			name:         "no view",
+			expectedZone: "default",
+		},
+	}
+
+	for i, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			config := `{"secretName": "foo", "secretNamespace": "bar", "strategy": {"kind": "BestEffort"}}`
+			if tc.zoneView != "" {
+				config = fmt.Sprintf(`{"secretName": "foo", "secretNamespace": "bar", "zoneView": %q, "strategy": {"kind": "BestEffort"}}`, tc.zoneView)
+			}
+
+			err := resolver.Present(newChallengeRequest("challenge", "cool.example.com", "example.com", config))
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if len(mockApi.Updates) != i+1 {
+				t.Fatalf("expected %d creates, got %d", i+1, len(mockApi.Updates))
+			}
+			if update := mockApi.Updates[i]; update.ZoneID != tc.expectedZone || update.View != tc.zoneView {
+				t.Errorf("expected a create in zone %s, got one in zone %s with view %q", tc.expectedZone, update.ZoneID, update.View)
+			}
+		})
+	}
+}
+
+func TestDesignateDnsResolver_AllProjects(t *testing.T) {
+	tcs := []struct {
+		name           string
+		allProjects    bool
+		expectedHeader string
+	}{
+		{
+			name:           "enabled",
+			allProjects:    true,
+			expectedHeader: "true",
+		},
+		{
+			name: "disabled",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			mockApi := mockresolver.CreateMockOpenstackApi(t)
+			mockApi.Zones = []mockresolver.MockZone{
+				{ID: "12345", Name: "example.com.", ProjectID: "tenantProject"},
+			}
+			resolver := newTestResolver(t, mockApi)
+
+			err := resolver.Present(newChallengeRequest("challenge", "cool.example.com", "example.com", fmt.Sprintf(`{
+				"secretName": "foo",
+				"secretNamespace": "bar",
+				"allProjects": %t,
+				"strategy": {"kind": "BestEffort"}
+			}`, tc.allProjects)))
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if len(mockApi.ZoneListHeaders) == 0 {
+				t.Fatal("expected the zones to be listed")
+			}
+			for _, headers := range mockApi.ZoneListHeaders {
+				if got := headers.Get(allProjectsHeader); got != tc.expectedHeader {
+					t.Errorf("expected header %s to be %q, got %q", allProjectsHeader, tc.expectedHeader, got)
+				}
+			}
+			if len(mockApi.Updates) != 1 || mockApi.Updates[0].ZoneID != "12345" {
+				t.Errorf("expected 1 create in zone 12345, got %v", mockApi.Updates)
+			}
+		})
+	}
+}
+
+func TestDesignateDnsResolver_RecordTrailingDot(t *testing.T) {
+	tcs := []struct {
+		name               string
+		recordTrailingDot  string
+		expectedRecordName string
+	}{
+		{
+			name:               "default",
+			expectedRecordName: "cool.example.com.",
+		},
+		{
+			name:               "enabled",
+			recordTrailingDot:  `"recordTrailingDot": true,`,
+			expectedRecordName: "cool.example.com.",
+		},
+		{
+			name:               "disabled",
+			recordTrailingDot:  `"recordTrailingDot": false,`,
+			expectedRecordName: "cool.example.com",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			mockApi := mockresolver.CreateMockOpenstackApi(t)
+			mockApi.Zones = []mockresolver.MockZone{
+				{
+					ID:   "12345",
+					Name: "example.com.",
+				},
+			}
+			resolver := newTestResolver(t, mockApi)
+
+			ch := newChallengeRequest("challenge", "cool.example.com", "example.com", fmt.Sprintf(`{
+				"secretName": "foo",
+				"secretNamespace": "bar",
+				%s
+				"strategy": {
+					"kind": "ZoneName",
+					"zoneName": "example.com"
+				}
+			}`, tc.recordTrailingDot))
+
+			if err := resolver.Present(ch); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if !slices.Equal(mockApi.ZoneListQueries, []string{"example.com."}) {
+				t.Errorf("expected the zone to be queried as example.com., got %v", mockApi.ZoneListQueries)
+			}
+			if len(mockApi.Updates) != 1 || mockApi.Updates[0].Opts.Name != tc.expectedRecordName {
+				t.Fatalf("expected the recordset to be created as %s, got %v", tc.expectedRecordName, mockApi.Updates)
+			}
+
+			// the recordset is found again under the same name
+			if err := resolver.CleanUp(ch); err != nil {
+				t.Fatalf("expected no error cleaning up, got %v", err)
+			}
+			if len(mockApi.RecordSetDeletes) != 1 {
+				t.Errorf("expected the recordset to be deleted, got %d deletes", len(mockApi.RecordSetDeletes))
+			}
+		})
+	}
+}
+
+func TestDesignateDnsResolver_Present_DottedRecordSetName(t *testing.T) {
+	mockApi := mockresolver.CreateMockOpenstackApi(t)
+	mockApi.Zones = []mockresolver.MockZone{
+		{
+			ID:   "12345",
+			Name: "example.com.",
+		},
+	}
+	// designate returns the stored, fully qualified name for the undotted filter
+	mockApi.RecordSets = []mockresolver.MockRecordSet{
+		{
+			ID:      "rs-1",
+			ZoneID:  "12345",
+			Name:    "Cool.example.com.",
+			Type:    "TXT",
+			Records: []string{"\"other\""},
+		},
+	}
+	resolver := newTestResolver(t, mockApi)
+
+	err := resolver.Present(newChallengeRequest("challenge", "cool.example.com", "example.com", `{
+		"secretName": "foo",
+		"secretNamespace": "bar",
+		"recordTrailingDot": false,
+		"strategy": {
+			"kind": "BestEffort"
+		}
+	}`))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(mockApi.Updates) != 0 {
+		t.Errorf("expected no duplicate create, got %v", mockApi.Updates)
+	}
+	if len(mockApi.RecordSetPuts) != 1 || mockApi.RecordSetPuts[0].RecordSetID != "rs-1" {
+		t.Errorf("expected the existing recordset rs-1 to be updated, got %v", mockApi.RecordSetPuts)
+	}
+}
+
+func TestCanonicalName(t *testing.T) {
+	tcs := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{name: "relative", input: "cool.example.com", expected: "cool.example.com."},
+		{name: "already dotted", input: "cool.example.com.", expected: "cool.example.com."},
+		{name: "doubly dotted", input: "cool.example.com..", expected: "cool.example.com."},
+		{name: "mixed case", input: "Cool.Example.COM.", expected: "cool.example.com."},
+		{name: "whitespace padded", input: " cool.example.com \t", expected: "cool.example.com."},
+		{name: "whitespace padded and dotted", input: "\ncool.example.com. ", expected: "cool.example.com."},
+		{name: "root", input: ".", expected: "."},
+		{name: "padded root", input: " . ", expected: "."},
+		{name: "empty", input: "", expected: ""},
+		{name: "whitespace only", input: "  ", expected: ""},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := canonicalName(tc.input); actual != tc.expected {
+				t.Errorf("expected %q to be canonicalized to %q, got %q", tc.input, tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestEnforceTrailingDot(t *testing.T) {
+	tcs := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{name: "relative", input: "Cool.example.com", expected: "Cool.example.com."},
+		{name: "already dotted", input: "Cool.example.com.", expected: "Cool.example.com."},
+		{name: "doubly dotted", input: "Cool.example.com..", expected: "Cool.example.com."},
+		{name: "whitespace padded", input: " Cool.example.com. ", expected: "Cool.example.com."},
+		{name: "root", input: ".", expected: "."},
+		{name: "empty", input: "", expected: ""},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := enforceTrailingDot(tc.input); actual != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestDesignateDnsResolver_Present_EmptyKey(t *testing.T) {
+	for _, key := range []string{"", "  ", `""`} {
+		t.Run(fmt.Sprintf("%q", key), func(t *testing.T) {
+			mockApi := mockresolver.CreateMockOpenstackApi(t)
+			mockApi.Zones = []mockresolver.MockZone{
+				{
+					ID:   "12345",
+					Name: "example.com.",
+				},
+			}
+			resolver := newTestResolver(t, mockApi)
+
+			err := resolver.Present(newChallengeRequest(key, "cool.example.com", "example.com", `{
+				"secretName": "foo",
+				"secretNamespace": "bar",
+				"strategy": {
+					"kind": "SOA"
+				}
+			}`))
+			if !errors.Is(err, ErrEmptyChallengeKey) {
+				t.Errorf("expected error %v, got %v", ErrEmptyChallengeKey, err)
+			}
+
+			if len(mockApi.Updates) != 0 || mockApi.TokenRequests != 0 {
+				t.Errorf("expected no API calls, got %d authentications and %d creates", mockApi.TokenRequests, len(mockApi.Updates))
+			}
+		})
+	}
+}
+
+func TestDesignateDnsResolver_BaseRecords(t *testing.T) {
+	mockApi := mockresolver.CreateMockOpenstackApi(t)
+	mockApi.Zones = []mockresolver.MockZone{
+		{
+			ID:   "12345",
+			Name: "example.com.",
+		},
+	}
+	resolver := newTestResolver(t, mockApi)
+
+	config := `{
+		"secretName": "foo",
+		"secretNamespace": "bar",
+		"baseRecords": ["\"site-verification=abc123\""],
+		"strategy": {
+			"kind": "SOA"
+		}
+	}`
+	first := newChallengeRequest("first", "cool.example.com", "example.com", config)
+	second := newChallengeRequest("second", "cool.example.com", "example.com", config)
+
+	for _, ch := range []*v1alpha1.ChallengeRequest{first, second} {
+		if err := resolver.Present(ch); err != nil {
+			t.Fatalf("unexpected error on present: %v", err)
+		}
+	}
+
+	if len(mockApi.RecordSets) != 1 {
+		t.Fatalf("expected 1 recordset, got %d", len(mockApi.RecordSets))
+	}
+	expected := []string{"first", "\"site-verification=abc123\"", "second"}
+	if !slices.Equal(mockApi.RecordSets[0].Records, expected) {
+		t.Errorf("expected records %v after present, got %v", expected, mockApi.RecordSets[0].Records)
+	}
+
+	for _, ch := range []*v1alpha1.ChallengeRequest{first, second} {
+		if err := resolver.CleanUp(ch); err != nil {
+			t.Fatalf("unexpected error on cleanup: %v", err)
+		}
+	}
+
+	if len(mockApi.RecordSetDeletes) != 0 {
+		t.Errorf("expected the recordset to be kept, got %d deletes", len(mockApi.RecordSetDeletes))
+	}
+	if len(mockApi.RecordSets) != 1 || !slices.Equal(mockApi.RecordSets[0].Records, []string{"\"site-verification=abc123\""}) {
+		t.Errorf("expected only the base record to remain, got %v", mockApi.RecordSets)
+	}
+}
+
+func TestDesignateDnsResolver_Present_WriteMode(t *testing.T) {
+	tcs := []struct {
+		name            string
+		writeMode       string
+		existing        bool
+		expectedCreates int
+		expectedPuts    int
+		expectedError   error
+	}{
+		{name: "auto creates an absent recordset", writeMode: WriteModeAuto, expectedCreates: 1},
+		{name: "auto updates an existing recordset", writeMode: WriteModeAuto, existing: true, expectedPuts: 1},
+		{name: "create-only creates an absent recordset", writeMode: WriteModeCreateOnly, expectedCreates: 1},
+		{name: "create-only rejects an existing recordset", writeMode: WriteModeCreateOnly, existing: true, expectedError: ErrWriteModeInapplicable},
+		{name: "update-only rejects an absent recordset", writeMode: WriteModeUpdateOnly, expectedError: ErrWriteModeInapplicable},
+		{name: "update-only updates an existing recordset", writeMode: WriteModeUpdateOnly, existing: true, expectedPuts: 1},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			mockApi := mockresolver.CreateMockOpenstackApi(t)
+			mockApi.Zones = []mockresolver.MockZone{
+				{
+					ID:   "12345",
+					Name: "example.com.",
+				},
+			}
+			if tc.existing {
+				mockApi.RecordSets = []mockresolver.MockRecordSet{
+					{
+						ID:      "rs-1",
+						ZoneID:  "12345",
+						Name:    "cool.example.com.",
+						Type:    "TXT",
+						Records: []string{"\"another-record\""},
+					},
+				}
+			}
+			resolver := newTestResolver(t, mockApi)
+
+			err := resolver.Present(newChallengeRequest("challenge", "cool.example.com", "example.com", fmt.Sprintf(`{
+				"secretName": "foo",
+				"secretNamespace": "bar",
+				"writeMode": %q,
+				"strategy": {
+					"kind": "SOA"
+				}
+			}`, tc.writeMode)))
+			if !errors.Is(err, tc.expectedError) {
+				t.Fatalf("expected error %v, got %v", tc.expectedError, err)
+			}
+
+			if len(mockApi.Updates) != tc.expectedCreates {
+				t.Errorf("expected %d creates, got %d", tc.expectedCreates, len(mockApi.Updates))
+			}
+
+			if len(mockApi.RecordSetPuts) != tc.expectedPuts {
+				t.Errorf("expected %d updates, got %d", tc.expectedPuts, len(mockApi.RecordSetPuts))
+			}
+		})
+	}
+}
+
+func TestDesignateDnsResolver_Present_MergeDuplicateRecordSets(t *testing.T) {
+	mockApi := mockresolver.CreateMockOpenstackApi(t)
+	mockApi.Zones = []mockresolver.MockZone{
+		{
+			ID:   "12345",
+			Name: "example.com.",
+		},
+	}
+	createdAt := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	mockApi.RecordSets = []mockresolver.MockRecordSet{
+		{
+			ID:        "rs-newer",
+			ZoneID:    "12345",
+			Name:      "cool.example.com.",
+			Type:      "TXT",
+			Records:   []string{"\"first\"", "\"second\""},
+			CreatedAt: createdAt.Add(time.Second),
+		},
+		{
+			ID:        "rs-oldest",
+			ZoneID:    "12345",
+			Name:      "cool.example.com.",
+			Type:      "TXT",
+			Records:   []string{"\"first\""},
+			CreatedAt: createdAt,
+		},
+	}
+	resolver := newTestResolver(t, mockApi)
+
+	err := resolver.Present(newChallengeRequest("third", "cool.example.com", "example.com", `{
+		"secretName": "foo",
+		"secretNamespace": "bar",
+		"mergeDuplicateRecordSets": true,
+		"strategy": {
+			"kind": "SOA"
+		}
+	}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(mockApi.RecordSetDeletes) != 1 || mockApi.RecordSetDeletes[0].RecordSetID != "rs-newer" {
+		t.Errorf("expected only rs-newer to be deleted, got %v", mockApi.RecordSetDeletes)
+	}
+
+	if len(mockApi.RecordSets) != 1 || mockApi.RecordSets[0].ID != "rs-oldest" {
+		t.Fatalf("expected only rs-oldest to remain, got %v", mockApi.RecordSets)
+	}
+
+	expected := []string{"\"first\"", "\"second\"", "third"}
+	if !slices.Equal(mockApi.RecordSets[0].Records, expected) {
+		t.Errorf("expected records %v, got %v", expected, mockApi.RecordSets[0].Records)
+	}
+
+	if len(mockApi.Updates) != 0 {
+		t.Errorf("expected no new recordset, got %d", len(mockApi.Updates))
+	}
+}
+
+func TestIsWithinZone(t *testing.T) {
+	tcs := []struct {
+		name     string
+		fqdn     string
+		zone     string
+		expected bool
+	}{
+		{name: "subdomain", fqdn: "cool.example.com", zone: "example.com.", expected: true},
+		{name: "zone apex", fqdn: "example.com.", zone: "example.com", expected: true},
+		{name: "suffix without label boundary", fqdn: "coolexample.com", zone: "example.com.", expected: false},
+		{name: "other zone", fqdn: "cool.example.com", zone: "example.org.", expected: false},
+		{name: "mixed case zone", fqdn: "cool.example.com", zone: "Example.COM.", expected: true},
+		{name: "mixed case name", fqdn: "Cool.EXAMPLE.com", zone: "example.com", expected: true},
+		{name: "doubly dotted name", fqdn: "cool.example.com..", zone: "example.com.", expected: true},
+		{name: "whitespace padded zone", fqdn: "cool.example.com", zone: " example.com. ", expected: true},
+		{name: "root zone", fqdn: "cool.example.com", zone: ".", expected: true},
+		{name: "empty zone", fqdn: "cool.example.com", zone: "", expected: false},
+		{name: "empty name", fqdn: "", zone: "example.com.", expected: false},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := isWithinZone(tc.fqdn, tc.zone); actual != tc.expected {
+				t.Errorf("expected %v, got %v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestSameRecordValue(t *testing.T) {
+	tcs := []struct {
+		name     string
+		record   string
+		expected bool
+	}{
+		{name: "unquoted", record: `challenge`, expected: true},
+		{name: "fully quoted", record: `"challenge"`, expected: true},
+		{name: "leading quote only", record: `"challenge`, expected: true},
+		{name: "trailing quote only", record: `challenge"`, expected: true},
+		{name: "other value", record: `"other"`, expected: false},
+		{name: "chunked", record: `"chall" "enge"`, expected: true},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			for _, key := range []string{`challenge`, `"challenge"`} {
+				if actual := sameRecordValue(tc.record, key); actual != tc.expected {
+					t.Errorf("sameRecordValue(%s, %s): expected %v, got %v", tc.record, key, tc.expected, actual)
+				}
+			}
+		})
+	}
+}
+
+func TestChunkTXTValue(t *testing.T) {
+	long := strings.Repeat("a", 255) + strings.Repeat("b", 255) + "c"
+
+	tcs := []struct {
+		name     string
+		value    string
+		expected string
+	}{
+		{name: "short value", value: "challenge", expected: "challenge"},
+		{name: "short quoted value", value: `"challenge"`, expected: `"challenge"`},
+		{name: "exactly the limit", value: strings.Repeat("a", 255), expected: strings.Repeat("a", 255)},
+		{name: "long value", value: long, expected: `"` + strings.Repeat("a", 255) + `" "` + strings.Repeat("b", 255) + `" "c"`},
+		{name: "long quoted value", value: `"` + long + `"`, expected: `"` + strings.Repeat("a", 255) + `" "` + strings.Repeat("b", 255) + `" "c"`},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := chunkTXTValue(tc.value)
+			if actual != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, actual)
+			}
+			if !sameRecordValue(actual, tc.value) {
+				t.Errorf("expected %q to reassemble to %q", actual, tc.value)
+			}
+		})
+	}
+}
+
+func TestDesignateDnsResolver_LongKey(t *testing.T) {
+	mockApi := mockresolver.CreateMockOpenstackApi(t)
+	mockApi.Zones = []mockresolver.MockZone{
+		{
+			ID:   "12345",
+			Name: "example.com.",
+		},
+	}
+	resolver := newTestResolver(t, mockApi)
+
+	key := strings.Repeat("k", 300)
+	ch := newChallengeRequest(key, "cool.example.com", "example.com", `{
+		"secretName": "foo",
+		"secretNamespace": "bar",
+		"strategy": {"kind": "SOA"}
+	}`)
+
+	for range 2 {
+		if err := resolver.Present(ch); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+
+	expected := []string{`"` + strings.Repeat("k", 255) + `" "` + strings.Repeat("k", 45) + `"`}
+	if len(mockApi.Updates) != 1 || !slices.Equal(mockApi.Updates[0].Opts.Records, expected) {
+		t.Fatalf("expected one create with the chunked key %v, got %v", expected, mockApi.Updates)
+	}
+	if len(mockApi.RecordSetPuts) != 0 {
+		t.Errorf("expected the second present to find the chunked key, got %d updates", len(mockApi.RecordSetPuts))
+	}
+
+	if err := resolver.CleanUp(ch); err != nil {
+		t.Fatalf("expected no error cleaning up, got %v", err)
+	}
+	if len(mockApi.RecordSetDeletes) != 1 || len(mockApi.RecordSets) != 0 {
+		t.Errorf("expected the chunked key to be cleaned up, got %d deletes and %v left", len(mockApi.RecordSetDeletes), mockApi.RecordSets)
+	}
+}
+
+func TestDesignateDnsResolver_PreservesTTLAndDescription(t *testing.T) {
+	mockApi := mockresolver.CreateMockOpenstackApi(t)
+	mockApi.Zones = []mockresolver.MockZone{
+		{
+			ID:   "12345",
+			Name: "example.com.",
+		},
+	}
+	mockApi.RecordSets = []mockresolver.MockRecordSet{
+		{
+			ID:          "rs-1",
+			ZoneID:      "12345",
+			Name:        "cool.example.com.",
+			Type:        "TXT",
+			Records:     []string{`"other"`},
+			TTL:         3600,
+			Description: "managed by ops",
+		},
+	}
+	resolver := newTestResolver(t, mockApi)
+
+	ch := newChallengeRequest("challenge", "cool.example.com", "example.com", `{
+		"secretName": "foo",
+		"secretNamespace": "bar",
+		"strategy": {
+			"kind": "SOA"
+		}
+	}`)
+	if err := resolver.Present(ch); err != nil {
+		t.Fatalf("expected no error presenting, got %v", err)
+	}
+	if err := resolver.CleanUp(ch); err != nil {
+		t.Fatalf("expected no error cleaning up, got %v", err)
+	}
+
+	if len(mockApi.RecordSetPuts) != 2 {
+		t.Fatalf("expected 2 puts, got %d", len(mockApi.RecordSetPuts))
+	}
+	for i, put := range mockApi.RecordSetPuts {
+		if put.Opts.TTL == nil || *put.Opts.TTL != 3600 {
+			t.Errorf("expected put %d to keep the TTL 3600, got %v", i, put.Opts.TTL)
+		}
+		if put.Opts.Description == nil || *put.Opts.Description != "managed by ops" {
+			t.Errorf("expected put %d to keep the description, got %v", i, put.Opts.Description)
+		}
+	}
+}
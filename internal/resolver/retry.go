@@ -0,0 +1,96 @@
+package resolver
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/cert-manager/cert-manager/pkg/acme/webhook/apis/acme/v1alpha1"
+	"github.com/gophercloud/gophercloud/v2"
+	"k8s.io/klog/v2"
+)
+
+var (
+	clientRetryBaseDelay = 500 * time.Millisecond
+	clientRetryMaxDelay  = 8 * time.Second
+)
+
+var (
+	// conflictRetries bounds the retries of recordset writes rejected with a conflict.
+	conflictRetries        = 4
+	conflictRetryBaseDelay = 250 * time.Millisecond
+)
+
+// WithClientCreationRetries retries creating the designate client up to the given number of times
+// with exponential backoff when it fails on a transient network error, e.g. a refused connection to
+// keystone. Authentication and configuration errors are never retried. Zero or less disables retries.
+func WithClientCreationRetries(retries int) Option {
+	return func(d *designateDnsResolver) {
+		d.clientCreationRetries = retries
+	}
+}
+
+// createDesignateClientWithRetries wraps createDesignateClient with the configured retries.
+func (d *designateDnsResolver) createDesignateClientWithRetries(ctx context.Context, ch *v1alpha1.ChallengeRequest) (_ *gophercloud.ServiceClient, _ *ChallengeConfig, _ string, err error) {
+	ctx, span := startSpan(ctx, spanAuthenticate)
+	defer func() { endSpan(span, err) }()
+
+	delay := clientRetryBaseDelay
+	for attempt := 0; ; attempt++ {
+		designateClient, cfg, cloud, err := d.createDesignateClient(ctx, ch)
+		if err == nil || attempt >= d.clientCreationRetries || !isTransientNetworkError(err) {
+			return designateClient, cfg, cloud, err
+		}
+
+		klog.V(2).InfoS("creating the designate client failed, retrying", "fqdn", ch.ResolvedFQDN, "attempt", attempt+1, "delay", delay, "err", err)
+		select {
+		case <-ctx.Done():
+			return nil, cfg, "", errors.Join(err, ctx.Err())
+		case <-time.After(delay):
+		}
+		delay = min(2*delay, clientRetryMaxDelay)
+	}
+}
+
+// isTransientNetworkError reports whether the request never got an HTTP response, e.g. because the
+// connection was refused or reset. Errors carrying a response, such as a 401, and untrusted
+// certificates are not transient.
+func isTransientNetworkError(err error) bool {
+	var unexpected gophercloud.ErrUnexpectedResponseCode
+	if errors.As(err, &unexpected) {
+		return false
+	}
+
+	var certErr *tls.CertificateVerificationError
+	if errors.As(err, &certErr) {
+		return false
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// retryOnConflict runs the write again, with exponential backoff, when designate rejects it with a
+// 409 Conflict because a concurrent challenge, e.g. for another SAN of the same certificate, changed
+// the same recordset in the meantime. The write has to re-read the recordset on every attempt. Once
+// the retries are exhausted the last error is returned.
+func retryOnConflict(ctx context.Context, fqdn string, write func() error) error {
+	delay := conflictRetryBaseDelay
+	for attempt := 0; ; attempt++ {
+		err := write()
+		if err == nil || attempt >= conflictRetries || !gophercloud.ResponseCodeIs(err, http.StatusConflict) {
+			return err
+		}
+
+		klog.V(2).InfoS("recordset write conflicted with a concurrent change, retrying", "fqdn", fqdn, "attempt", attempt+1, "delay", delay)
+		select {
+		case <-ctx.Done():
+			return errors.Join(err, ctx.Err())
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
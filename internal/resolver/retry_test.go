@@ -0,0 +1,205 @@
+package resolver
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gophercloud/gophercloud/v2"
+	mockresolver "github.com/rikotsev/cert-manager-webhook-designate/internal/resolver/mock"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// flakyListener drops the first connections right after accepting them.
+type flakyListener struct {
+	net.Listener
+	drop     int32
+	accepted atomic.Int32
+}
+
+func (l *flakyListener) Accept() (net.Conn, error) {
+	for {
+		conn, err := l.Listener.Accept()
+		if err != nil {
+			return nil, err
+		}
+
+		if l.accepted.Add(1) > l.drop {
+			return conn, nil
+		}
+		_ = conn.Close()
+	}
+}
+
+func TestDesignateDnsResolver_ClientCreationRetries(t *testing.T) {
+	previousDelay := clientRetryBaseDelay
+	clientRetryBaseDelay = time.Millisecond
+	t.Cleanup(func() { clientRetryBaseDelay = previousDelay })
+
+	tcs := []struct {
+		name                  string
+		dropConnections       int32
+		retries               int
+		errorAuthenticating   bool
+		expectedError         error
+		expectedTokenRequests int
+	}{
+		{
+			name:                  "recovers after refused connections",
+			dropConnections:       2,
+			retries:               3,
+			expectedTokenRequests: 1,
+		},
+		{
+			name:            "gives up after the retries",
+			dropConnections: 3,
+			retries:         2,
+			expectedError:   ErrFailedDesignateClientInitialization,
+		},
+		{
+			name:            "no retries by default",
+			dropConnections: 1,
+			expectedError:   ErrFailedDesignateClientInitialization,
+		},
+		{
+			name:                  "authentication errors are not retried",
+			retries:               3,
+			errorAuthenticating:   true,
+			expectedError:         ErrFailedDesignateClientInitialization,
+			expectedTokenRequests: 1,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			mockApi := mockresolver.CreateMockOpenstackApi(t)
+			mockApi.Zones = []mockresolver.MockZone{
+				{
+					ID:   "12345",
+					Name: "example.com.",
+				},
+			}
+			mockApi.ErrorAuthenticating = tc.errorAuthenticating
+
+			server := httptest.NewUnstartedServer(mockApi)
+			server.Listener = &flakyListener{Listener: server.Listener, drop: tc.dropConnections}
+			server.Start()
+			t.Cleanup(server.Close)
+
+			resolver := new(designateDnsResolver)
+			resolver.configProvider = &authConfigProvider{
+				client: fake.NewClientset(dummySecret("foo", "bar", map[string]string{
+					"tenantName":        "testTenant",
+					"tenantId":          "testTenantId",
+					"domainId":          "testDomainId",
+					"username":          "john-doe",
+					"password":          "secretpass",
+					"region":            "RegionOne",
+					"identityEndpoint":  server.URL,
+					"insecureAllowHTTP": "true",
+				})),
+			}
+			WithClientCreationRetries(tc.retries)(resolver)
+
+			err := resolver.Present(newChallengeRequest("challenge", "cool.example.com", "example.com", `{
+				"secretName": "foo",
+				"secretNamespace": "bar",
+				"strategy": {
+					"kind": "SOA"
+				}
+			}`))
+			if !errors.Is(err, tc.expectedError) {
+				t.Fatalf("expected error %v, got %v", tc.expectedError, err)
+			}
+
+			if mockApi.TokenRequests != tc.expectedTokenRequests {
+				t.Errorf("expected %d authentication requests, got %d", tc.expectedTokenRequests, mockApi.TokenRequests)
+			}
+		})
+	}
+}
+
+func TestDesignateDnsResolver_Present_RetriesConflicts(t *testing.T) {
+	previousDelay := conflictRetryBaseDelay
+	conflictRetryBaseDelay = time.Millisecond
+	t.Cleanup(func() { conflictRetryBaseDelay = previousDelay })
+
+	tcs := []struct {
+		name               string
+		existingRecords    []string
+		conflictingRecords []string
+		expectedRecords    []string
+		expectConflict     bool
+	}{
+		{
+			name:               "update conflicting twice",
+			existingRecords:    []string{"another-record"},
+			conflictingRecords: []string{"racing-1", "racing-2"},
+			expectedRecords:    []string{"another-record", "racing-1", "racing-2", "challenge"},
+		},
+		{
+			name:               "create conflicting with a racing create",
+			conflictingRecords: []string{"racing-1", "racing-2"},
+			expectedRecords:    []string{"racing-1", "racing-2", "challenge"},
+		},
+		{
+			name:               "retries exhausted",
+			existingRecords:    []string{"another-record"},
+			conflictingRecords: []string{"racing-1", "racing-2", "racing-3", "racing-4", "racing-5"},
+			expectedRecords:    []string{"another-record", "racing-1", "racing-2", "racing-3", "racing-4", "racing-5"},
+			expectConflict:     true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			mockApi := mockresolver.CreateMockOpenstackApi(t)
+			mockApi.Zones = []mockresolver.MockZone{
+				{
+					ID:   "12345",
+					Name: "example.com.",
+				},
+			}
+			if tc.existingRecords != nil {
+				mockApi.RecordSets = []mockresolver.MockRecordSet{
+					{
+						ID:      "12345-1",
+						ZoneID:  "12345",
+						Name:    "cool.example.com.",
+						Type:    "TXT",
+						Records: tc.existingRecords,
+					},
+				}
+			}
+			mockApi.ConflictingRecords = tc.conflictingRecords
+			resolver := newTestResolver(t, mockApi)
+
+			err := resolver.Present(newChallengeRequest("challenge", "cool.example.com", "example.com", `{
+				"secretName": "foo",
+				"secretNamespace": "bar",
+				"strategy": {
+					"kind": "SOA"
+				}
+			}`))
+			if tc.expectConflict {
+				if !gophercloud.ResponseCodeIs(err, http.StatusConflict) {
+					t.Fatalf("expected the last conflict to be returned, got %v", err)
+				}
+			} else if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if len(mockApi.RecordSets) != 1 {
+				t.Fatalf("expected a single recordset, got %v", mockApi.RecordSets)
+			}
+			if records := mockApi.RecordSets[0].Records; !slices.Equal(records, tc.expectedRecords) {
+				t.Errorf("expected records %v, got %v", tc.expectedRecords, records)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package resolver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+var ErrSelfCheckFailed = errors.New("the self-check nameservers did not serve the challenge in time")
+
+// selfCheckPollInterval is the pause between the self-check queries of a nameserver.
+var selfCheckPollInterval = 2 * time.Second
+
+// defaultDNSPort is the port of self-check nameservers given without one.
+const defaultDNSPort = "53"
+
+// normalizeNameserver returns the nameserver as host:port, defaulting the port to defaultDNSPort.
+func normalizeNameserver(nameserver string) (string, error) {
+	if host, port, err := net.SplitHostPort(nameserver); err == nil {
+		if host == "" || port == "" {
+			return "", fmt.Errorf("%q needs a host and a port", nameserver)
+		}
+		return nameserver, nil
+	}
+
+	host := strings.TrimSuffix(strings.TrimPrefix(nameserver, "["), "]")
+	if strings.TrimSpace(host) == "" {
+		return "", fmt.Errorf("%q is not a nameserver", nameserver)
+	}
+
+	return net.JoinHostPort(host, defaultDNSPort), nil
+}
+
+// lookupTXT queries the TXT records of name at nameserver directly, bypassing the resolvers of the
+// host and their caches. The strings of a record are joined into one value.
+func lookupTXT(ctx context.Context, nameserver, name string) ([]string, error) {
+	resolver := &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, network, nameserver)
+		},
+	}
+
+	return resolver.LookupTXT(ctx, name)
+}
+
+// selfCheck queries the self-check nameservers of the config until each of them serves key as a TXT
+// value of name, so that cert-manager's own propagation check passes on its first attempts. It gives
+// up after the propagation timeout. Nameservers seen serving the zone after the write, e.g. by the
+// challenge of another SAN in the zone, are not queried again, see propagationKey.
+func (d *designateDnsResolver) selfCheck(ctx context.Context, cfg *ChallengeConfig, cloud, zoneId, name, key string) error {
+	lookup := d.lookupTXT
+	if lookup == nil {
+		lookup = lookupTXT
+	}
+	name = enforceTrailingDot(name)
+	timeout := cfg.waitTimeout()
+	writtenAt := d.propagationCache.clock()
+
+	// Like the propagation wait, the self-check has its own timeout.
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), timeout)
+	defer cancel()
+
+	pending := slices.Clone(cfg.selfCheckNameservers)
+	for {
+		pending = slices.DeleteFunc(pending, func(nameserver string) bool {
+			cacheKey := propagationKey(cloud, zoneId, nameserver)
+			if d.propagationCache.settledSince(cacheKey, writtenAt) {
+				return true
+			}
+
+			observedAt := d.propagationCache.clock()
+			values, err := lookup(ctx, nameserver, name)
+			if err != nil {
+				klog.V(4).InfoS("self-check query failed", "nameserver", nameserver, "name", name, "err", err)
+				return false
+			}
+			if !slices.Contains(values, key) {
+				return false
+			}
+
+			d.propagationCache.put(cacheKey, observedAt)
+			return true
+		})
+		if len(pending) == 0 {
+			return nil
+		}
+
+		klog.V(4).InfoS("waiting for nameservers to serve the challenge", "name", name, "nameservers", pending)
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w: %s is not served by %s after %s", ErrSelfCheckFailed, name, strings.Join(pending, ", "), timeout)
+		case <-time.After(selfCheckPollInterval):
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package resolver
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	mockresolver "github.com/rikotsev/cert-manager-webhook-designate/internal/resolver/mock"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+func TestParseConfig_SelfCheckNameservers(t *testing.T) {
+	cfg, err := ParseConfig(&apiextensionsv1.JSON{Raw: []byte(`{
+		"selfCheckNameservers": ["ns1.example.com", "192.0.2.1:5353", "2001:db8::1", "[2001:db8::2]:53"]
+	}`)})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	expected := []string{"ns1.example.com:53", "192.0.2.1:5353", "[2001:db8::1]:53", "[2001:db8::2]:53"}
+	if !slices.Equal(cfg.selfCheckNameservers, expected) {
+		t.Errorf("expected nameservers %v, got %v", expected, cfg.selfCheckNameservers)
+	}
+
+	if _, err := ParseConfig(&apiextensionsv1.JSON{Raw: []byte(`{"selfCheckNameservers": [":53"]}`)}); !errors.Is(err, ErrCannotParse) {
+		t.Errorf("expected error %v for a nameserver without host, got %v", ErrCannotParse, err)
+	}
+}
+
+func TestDesignateDnsResolver_Present_SelfCheck(t *testing.T) {
+	previousInterval := selfCheckPollInterval
+	selfCheckPollInterval = 10 * time.Millisecond
+	t.Cleanup(func() { selfCheckPollInterval = previousInterval })
+
+	tcs := []struct {
+		name string
+		// servedAfter is the delay after which the stub nameservers serve the challenge, never if negative.
+		servedAfter   time.Duration
+		expectedError error
+	}{
+		{
+			name:        "served after a delay",
+			servedAfter: 50 * time.Millisecond,
+		},
+		{
+			name:          "never served",
+			servedAfter:   -1,
+			expectedError: ErrSelfCheckFailed,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			mockApi := mockresolver.CreateMockOpenstackApi(t)
+			mockApi.Zones = []mockresolver.MockZone{
+				{
+					ID:   "12345",
+					Name: "example.com.",
+				},
+			}
+			resolver := newTestResolver(t, mockApi)
+
+			start := time.Now()
+			var mu sync.Mutex
+			queried := map[string]int{}
+			resolver.lookupTXT = func(_ context.Context, nameserver, name string) ([]string, error) {
+				mu.Lock()
+				defer mu.Unlock()
+
+				queried[nameserver+" "+name]++
+				if tc.servedAfter < 0 || time.Since(start) < tc.servedAfter {
+					return nil, errors.New("no such host")
+				}
+				return []string{"other", "challenge"}, nil
+			}
+
+			err := resolver.Present(newChallengeRequest("challenge", "cool.example.com", "example.com", `{
+				"secretName": "foo",
+				"secretNamespace": "bar",
+				"propagationTimeout": "200ms",
+				"selfCheckNameservers": ["ns1.example.com", "ns2.example.com:5353"],
+				"strategy": {
+					"kind": "SOA"
+				}
+			}`))
+			if !errors.Is(err, tc.expectedError) {
+				t.Fatalf("expected error %v, got %v", tc.expectedError, err)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			for _, key := range []string{"ns1.example.com:53 cool.example.com.", "ns2.example.com:5353 cool.example.com."} {
+				if queried[key] < 2 {
+					t.Errorf("expected %s to be queried repeatedly, got %d queries", key, queried[key])
+				}
+			}
+		})
+	}
+}
+
+func TestDesignateDnsResolver_SelfCheck_PropagationCache(t *testing.T) {
+	resolver := New(WithPropagationCacheTTL(10 * time.Second)).(*designateDnsResolver)
+
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	resolver.propagationCache.now = func() time.Time { return now }
+
+	queried := map[string]int{}
+	resolver.lookupTXT = func(_ context.Context, nameserver, name string) ([]string, error) {
+		queried[nameserver]++
+		return []string{"challenge"}, nil
+	}
+
+	cfg, err := ParseConfig(&apiextensionsv1.JSON{Raw: []byte(`{
+		"selfCheckNameservers": ["ns1.example.com", "ns2.example.com"]
+	}`)})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	steps := []struct {
+		name            string
+		recordName      string
+		zoneId          string
+		advance         time.Duration
+		expectedQueries int
+	}{
+		{name: "first check queries", recordName: "cool.example.com", zoneId: "12345", expectedQueries: 1},
+		{name: "other name of the zone shares the observation", recordName: "other.example.com", zoneId: "12345", expectedQueries: 1},
+		{name: "other zone queries", recordName: "cool.example.org", zoneId: "67890", expectedQueries: 2},
+		{name: "write after the observation queries again", recordName: "other.example.com", zoneId: "12345", advance: time.Second, expectedQueries: 3},
+	}
+
+	for _, step := range steps {
+		now = now.Add(step.advance)
+
+		if err := resolver.selfCheck(context.Background(), cfg, "cloud", step.zoneId, step.recordName, "challenge"); err != nil {
+			t.Fatalf("%s: unexpected error: %v", step.name, err)
+		}
+
+		for _, nameserver := range []string{"ns1.example.com:53", "ns2.example.com:53"} {
+			if queried[nameserver] != step.expectedQueries {
+				t.Errorf("%s: expected %d queries of %s, got %d", step.name, step.expectedQueries, nameserver, queried[nameserver])
+			}
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package resolver
+
+import (
+	"context"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// doShared runs fn once for the concurrent callers with the same key. The call is detached from the
+// caller starting it, so that its cancellation does not fail the others, and bounded by timeout, or
+// the operation timeout if zero or less. Every caller waits for the result only until its own ctx
+// is done.
+func (d *designateDnsResolver) doShared(ctx context.Context, group *singleflight.Group, key string, timeout time.Duration, fn func(context.Context) (any, error)) (any, error) {
+	if timeout <= 0 {
+		timeout = d.operationTimeout
+	}
+
+	results := group.DoChan(key, func() (any, error) {
+		sharedCtx, cancel := phaseContext(context.WithoutCancel(ctx), timeout)
+		defer cancel()
+
+		return fn(sharedCtx)
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case result := <-results:
+		return result.Val, result.Err
+	}
+}
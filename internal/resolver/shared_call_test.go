@@ -0,0 +1,66 @@
+package resolver
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gophercloud/gophercloud/v2/openstack/dns/v2/zones"
+	mockresolver "github.com/rikotsev/cert-manager-webhook-designate/internal/resolver/mock"
+)
+
+func TestDesignateDnsResolver_ListZones_SharedListingOutlivesItsCaller(t *testing.T) {
+	mockApi := mockresolver.CreateMockOpenstackApi(t)
+	mockApi.Zones = []mockresolver.MockZone{
+		{
+			ID:   "12345",
+			Name: "example.com.",
+		},
+	}
+	mockApi.ZoneListDelay = 200 * time.Millisecond
+	resolver := newTestResolver(t, mockApi)
+
+	designateClient, _, cloud, err := resolver.createDesignateClient(context.Background(), newChallengeRequest("challenge", "cool.example.com", "example.com", `{
+		"secretName": "foo",
+		"secretNamespace": "bar",
+		"strategy": {
+			"kind": "BestEffort"
+		}
+	}`))
+	if err != nil {
+		t.Fatalf("failed to create the designate client: %v", err)
+	}
+
+	// The first caller starts the listing and gives up on it while the second one waits for it.
+	firstCtx, cancelFirst := context.WithCancel(context.Background())
+	firstErr := make(chan error, 1)
+	go func() {
+		_, err := resolver.listZones(firstCtx, cloud, designateClient, zones.ListOpts{})
+		firstErr <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	secondResult := make(chan []zones.Zone, 1)
+	secondErr := make(chan error, 1)
+	go func() {
+		allZones, err := resolver.listZones(context.Background(), cloud, designateClient, zones.ListOpts{})
+		secondResult <- allZones
+		secondErr <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+	cancelFirst()
+
+	if err := <-firstErr; !errors.Is(err, context.Canceled) {
+		t.Errorf("expected the first caller to stop waiting with %v, got %v", context.Canceled, err)
+	}
+	if err := <-secondErr; err != nil {
+		t.Fatalf("expected the second caller to get the listing, got %v", err)
+	}
+	if allZones := <-secondResult; len(allZones) != 1 || allZones[0].ID != "12345" {
+		t.Errorf("expected the listed zone, got %+v", allZones)
+	}
+	if mockApi.ZoneListCalls != 1 {
+		t.Errorf("expected a single shared listing, got %d", mockApi.ZoneListCalls)
+	}
+}
@@ -0,0 +1,113 @@
+package resolver
+
+import (
+	"errors"
+	"sync"
+
+	"k8s.io/klog/v2"
+)
+
+var ErrShuttingDown = errors.New("the resolver is shutting down")
+
+// ShutdownWaiter is implemented by solvers that finish their work in flight once the stop channel
+// handed to Initialize is closed.
+type ShutdownWaiter interface {
+	// Stopped returns a channel closed once the solver has stopped, or nil before Initialize.
+	Stopped() <-chan struct{}
+}
+
+var _ ShutdownWaiter = (*designateDnsResolver)(nil)
+
+// Stopped returns a channel closed once the stop channel handed to Initialize is closed, every
+// Present and CleanUp in flight as well as the startup reconciliation have returned and the caches
+// are cleared.
+func (d *designateDnsResolver) Stopped() <-chan struct{} {
+	return d.stopped
+}
+
+// watchShutdown stops the resolver once stopCh is closed: it drains the challenges in flight,
+// refusing new ones with ErrShuttingDown from then on, drops the credentials provider and clears
+// every cache, so that no credentials or tokens outlive the resolver. It then closes d.stopped.
+func (d *designateDnsResolver) watchShutdown(stopCh <-chan struct{}) {
+	d.stopped = make(chan struct{})
+	go func() {
+		<-stopCh
+		klog.InfoS("draining challenges in flight", "count", d.challenges.count())
+		<-d.challenges.stop()
+		d.reconciliation.Wait()
+
+		d.configProvider = nil
+		d.tokenCache.clear()
+		d.zoneCache.clear()
+		d.propagationCache.clear()
+		d.validatedRegions.Clear()
+		d.probedCapabilities.Clear()
+		d.descriptionSupport.Clear()
+		d.events.shutdown()
+
+		klog.InfoS("stopped the resolver")
+		close(d.stopped)
+	}()
+}
+
+// challengeTracker counts the Present and CleanUp calls in flight. Unlike a sync.WaitGroup it
+// tolerates challenges starting while the shutdown waits for the others, and refuses them once
+// none is left.
+type challengeTracker struct {
+	mu       sync.Mutex
+	inFlight int
+	stopping bool
+	// idle is closed once stopping with no challenge in flight, after which none may start.
+	idle chan struct{}
+}
+
+// start records a challenge in flight and returns the function recording its end, or ErrShuttingDown
+// once the tracker is idle.
+func (t *challengeTracker) start() (func(), error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.stopping && t.inFlight == 0 {
+		return nil, ErrShuttingDown
+	}
+
+	t.inFlight++
+	return func() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+
+		t.inFlight--
+		if t.stopping && t.inFlight == 0 {
+			close(t.idle)
+		}
+	}, nil
+}
+
+// stop marks the tracker stopping and returns a channel closed once no challenge is in flight.
+func (t *challengeTracker) stop() <-chan struct{} {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.stopping {
+		t.stopping = true
+		t.idle = make(chan struct{})
+		if t.inFlight == 0 {
+			close(t.idle)
+		}
+	}
+	return t.idle
+}
+
+func (t *challengeTracker) isStopping() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.stopping
+}
+
+func (t *challengeTracker) count() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.inFlight
+}
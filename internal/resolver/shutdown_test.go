@@ -0,0 +1,121 @@
+package resolver
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	mockresolver "github.com/rikotsev/cert-manager-webhook-designate/internal/resolver/mock"
+)
+
+func TestDesignateDnsResolver_Shutdown(t *testing.T) {
+	mockApi := mockresolver.CreateMockOpenstackApi(t)
+	mockApi.Zones = []mockresolver.MockZone{
+		{
+			ID:   "12345",
+			Name: "example.com.",
+		},
+	}
+	resolver := newTestResolver(t, mockApi)
+	WithMaxConcurrentChallenges(1)(resolver)
+
+	stopCh := make(chan struct{})
+	resolver.watchShutdown(stopCh)
+
+	// Hold the only challenge slot, so that the Present below stays in flight until released.
+	release := resolver.acquireChallengeSlot()
+	presented := make(chan error, 1)
+	go func() {
+		presented <- resolver.Present(newChallengeRequest("challenge", "cool.example.com", "example.com", `{
+			"secretName": "foo",
+			"secretNamespace": "bar",
+			"strategy": {
+				"kind": "SOA"
+			}
+		}`))
+	}()
+	waitFor(t, func() bool { return resolver.challenges.count() == 1 })
+
+	close(stopCh)
+	waitFor(t, func() bool { return errors.Is(resolver.Ready(), ErrShuttingDown) })
+
+	select {
+	case <-resolver.Stopped():
+		t.Fatal("expected the resolver to wait for the challenge in flight before stopping")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	release()
+	if err := <-presented; err != nil {
+		t.Fatalf("expected the challenge in flight to complete, got %v", err)
+	}
+	if len(mockApi.RecordSets) != 1 {
+		t.Errorf("expected the challenge recordset to be created, got %d recordsets", len(mockApi.RecordSets))
+	}
+
+	select {
+	case <-resolver.Stopped():
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the resolver to stop once the challenge in flight completed")
+	}
+}
+
+// waitFor polls condition until it holds, failing the test after a few seconds.
+func waitFor(t *testing.T, condition func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the condition")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestDesignateDnsResolver_Shutdown_ClearsCaches(t *testing.T) {
+	mockApi := mockresolver.CreateMockOpenstackApi(t)
+	mockApi.Zones = []mockresolver.MockZone{
+		{
+			ID:   "12345",
+			Name: "example.com.",
+		},
+	}
+	resolver := newTestResolver(t, mockApi)
+	WithTokenCache(true)(resolver)
+	WithZoneCacheTTL(time.Minute)(resolver)
+
+	stopCh := make(chan struct{})
+	resolver.watchShutdown(stopCh)
+
+	ch := newChallengeRequest("challenge", "cool.example.com", "example.com", `{
+		"secretName": "foo",
+		"secretNamespace": "bar",
+		"strategy": {
+			"kind": "BestEffort"
+		}
+	}`)
+	if err := resolver.Present(ch); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if dump := resolver.DumpCaches(); len(dump.Clients) == 0 || len(dump.Zones) == 0 {
+		t.Fatalf("expected the client and zone caches to be filled, got %+v", dump)
+	}
+
+	close(stopCh)
+	select {
+	case <-resolver.Stopped():
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the resolver to stop")
+	}
+
+	if resolver.configProvider != nil {
+		t.Error("expected the credentials provider to be dropped")
+	}
+	if dump := resolver.DumpCaches(); len(dump.Clients) != 0 || len(dump.Zones) != 0 || len(dump.Propagation) != 0 {
+		t.Errorf("expected every cache to be cleared, got %+v", dump)
+	}
+	if err := resolver.CleanUp(ch); !errors.Is(err, ErrShuttingDown) {
+		t.Errorf("expected error %v after stopping, got %v", ErrShuttingDown, err)
+	}
+}
@@ -0,0 +1,54 @@
+package resolver
+
+import (
+	"context"
+	"time"
+)
+
+const defaultOperationTimeout = 30 * time.Second
+
+// WithOperationTimeout bounds the OpenStack calls of a single Present or CleanUp, so that a hung
+// keystone or designate API fails the challenge instead of blocking it forever. Waiting for
+// propagation has its own timeout. A timeout of zero or less never times out.
+func WithOperationTimeout(timeout time.Duration) Option {
+	return func(d *designateDnsResolver) {
+		d.operationTimeout = timeout
+	}
+}
+
+// operationContext returns the context for the OpenStack calls of a single Present or CleanUp.
+func (d *designateDnsResolver) operationContext() (context.Context, context.CancelFunc) {
+	if d.operationTimeout <= 0 {
+		return context.WithCancel(context.Background())
+	}
+
+	return context.WithTimeout(context.Background(), d.operationTimeout)
+}
+
+// WithListTimeout bounds matching the zone of a challenge, which may page through every zone of the
+// cloud, separately from writing the recordset. It applies within the operation timeout. A timeout of
+// zero or less leaves the phase bounded by the operation timeout only.
+func WithListTimeout(timeout time.Duration) Option {
+	return func(d *designateDnsResolver) {
+		d.listTimeout = timeout
+	}
+}
+
+// WithMutateTimeout bounds reading and writing the challenge recordset, once its zone is matched. It
+// applies within the operation timeout. A timeout of zero or less leaves the phase bounded by the
+// operation timeout only.
+func WithMutateTimeout(timeout time.Duration) Option {
+	return func(d *designateDnsResolver) {
+		d.mutateTimeout = timeout
+	}
+}
+
+// phaseContext returns the context for a phase of a Present or CleanUp, bounded by the phase timeout
+// in addition to the deadline of the operation.
+func phaseContext(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+
+	return context.WithTimeout(ctx, timeout)
+}
@@ -0,0 +1,170 @@
+package resolver
+
+import (
+	"context"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mockresolver "github.com/rikotsev/cert-manager-webhook-designate/internal/resolver/mock"
+)
+
+func TestDesignateDnsResolver_OperationTimeout(t *testing.T) {
+	mockApi := mockresolver.CreateMockOpenstackApi(t)
+	mockApi.Zones = []mockresolver.MockZone{
+		{
+			ID:   "12345",
+			Name: "example.com.",
+		},
+	}
+	mockApi.ZoneListDelay = 500 * time.Millisecond
+	resolver := newTestResolver(t, mockApi)
+	WithOperationTimeout(100 * time.Millisecond)(resolver)
+
+	ch := newChallengeRequest("challenge", "cool.example.com", "example.com", `{
+		"secretName": "foo",
+		"secretNamespace": "bar",
+		"strategy": {
+			"kind": "BestEffort"
+		}
+	}`)
+
+	start := time.Now()
+	err := resolver.Present(ch)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected present to fail with %v, got %v", context.DeadlineExceeded, err)
+	}
+	if elapsed := time.Since(start); elapsed >= mockApi.ZoneListDelay {
+		t.Errorf("expected present to give up before the zone listing returned, took %s", elapsed)
+	}
+
+	err = resolver.CleanUp(ch)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected cleanup to fail with %v, got %v", context.DeadlineExceeded, err)
+	}
+
+	if len(mockApi.Updates) != 0 {
+		t.Errorf("expected no recordset to be created, got %d", len(mockApi.Updates))
+	}
+}
+
+func TestDesignateDnsResolver_PhaseTimeouts(t *testing.T) {
+	tcs := []struct {
+		name          string
+		zoneListDelay time.Duration
+		writeDelay    time.Duration
+		listTimeout   time.Duration
+		mutateTimeout time.Duration
+		expectedError error
+		expectedPuts  int
+	}{
+		{
+			name:          "slow list times out",
+			zoneListDelay: 500 * time.Millisecond,
+			listTimeout:   100 * time.Millisecond,
+			mutateTimeout: time.Second,
+			expectedError: context.DeadlineExceeded,
+		},
+		{
+			name:          "slow list does not eat into the mutate timeout",
+			zoneListDelay: 300 * time.Millisecond,
+			listTimeout:   time.Second,
+			mutateTimeout: 200 * time.Millisecond,
+			expectedPuts:  1,
+		},
+		{
+			name:          "slow mutate times out",
+			writeDelay:    500 * time.Millisecond,
+			listTimeout:   time.Second,
+			mutateTimeout: 100 * time.Millisecond,
+			expectedError: context.DeadlineExceeded,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			mockApi := mockresolver.CreateMockOpenstackApi(t)
+			mockApi.Zones = []mockresolver.MockZone{
+				{
+					ID:   "12345",
+					Name: "example.com.",
+				},
+			}
+			mockApi.RecordSets = []mockresolver.MockRecordSet{
+				{
+					ID:      "12345-1",
+					ZoneID:  "12345",
+					Name:    "cool.example.com.",
+					Type:    "TXT",
+					Records: []string{"another-record"},
+				},
+			}
+			mockApi.ZoneListDelay = tc.zoneListDelay
+			mockApi.WriteDelay = tc.writeDelay
+			resolver := newTestResolver(t, mockApi)
+			WithListTimeout(tc.listTimeout)(resolver)
+			WithMutateTimeout(tc.mutateTimeout)(resolver)
+
+			err := resolver.Present(newChallengeRequest("challenge", "cool.example.com", "example.com", `{
+				"secretName": "foo",
+				"secretNamespace": "bar",
+				"strategy": {
+					"kind": "BestEffort"
+				}
+			}`))
+			if tc.expectedError != nil {
+				if !errors.Is(err, tc.expectedError) {
+					t.Errorf("expected error %v, got %v", tc.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if len(mockApi.RecordSetPuts) != tc.expectedPuts {
+				t.Errorf("expected %d recordset updates, got %d", tc.expectedPuts, len(mockApi.RecordSetPuts))
+			}
+		})
+	}
+}
+
+func TestDesignateDnsResolver_HTTPTimeout(t *testing.T) {
+	// The server accepts connections but never answers, like a stuck keystone.
+	hanging := make(chan struct{})
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-hanging:
+		case <-r.Context().Done():
+		}
+	}))
+	t.Cleanup(server.Close)
+	t.Cleanup(func() { close(hanging) })
+
+	resolver := newTLSTestResolver(server.URL, string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})))
+
+	start := time.Now()
+	err := resolver.Present(newChallengeRequest("challenge", "cool.example.com", "example.com", `{
+		"secretName": "foo",
+		"secretNamespace": "bar",
+		"httpTimeout": "200ms",
+		"strategy": {
+			"kind": "BestEffort"
+		}
+	}`))
+	elapsed := time.Since(start)
+
+	if !errors.Is(err, ErrFailedDesignateClientInitialization) {
+		t.Fatalf("expected error %v, got %v", ErrFailedDesignateClientInitialization, err)
+	}
+	var timeoutErr interface{ Timeout() bool }
+	if !errors.As(err, &timeoutErr) || !timeoutErr.Timeout() {
+		t.Errorf("expected a timeout error, got %v", err)
+	}
+	if elapsed > 5*time.Second {
+		t.Errorf("expected the request to time out after 200ms, took %v", elapsed)
+	}
+}
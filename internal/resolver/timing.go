@@ -0,0 +1,59 @@
+package resolver
+
+import (
+	"time"
+
+	"github.com/cert-manager/cert-manager/pkg/acme/webhook/apis/acme/v1alpha1"
+	"k8s.io/klog/v2"
+)
+
+// WithTimingLogs emits a structured log line after every Present and CleanUp with the total
+// duration and the time spent authenticating, matching the zone and mutating the recordset.
+func WithTimingLogs(enabled bool) Option {
+	return func(d *designateDnsResolver) {
+		d.timingLogs = enabled
+	}
+}
+
+// phaseTimer measures the consecutive phases of processing a challenge. A nil timer records nothing,
+// so that the phases can be marked unconditionally.
+type phaseTimer struct {
+	start time.Time
+	last  time.Time
+	// phases holds alternating phase names and durations, ready to be logged.
+	phases []any
+}
+
+func (d *designateDnsResolver) newPhaseTimer() *phaseTimer {
+	if !d.timingLogs {
+		return nil
+	}
+
+	now := time.Now()
+	return &phaseTimer{start: now, last: now}
+}
+
+// phase marks the end of the named phase, which started when the previous one ended.
+func (t *phaseTimer) phase(name string) {
+	if t == nil {
+		return
+	}
+
+	now := time.Now()
+	t.phases = append(t.phases, name, now.Sub(t.last))
+	t.last = now
+}
+
+func (t *phaseTimer) log(operation string, ch *v1alpha1.ChallengeRequest, err error) {
+	if t == nil {
+		return
+	}
+
+	keysAndValues := append([]any{
+		"operation", operation,
+		"fqdn", ch.ResolvedFQDN,
+		"success", err == nil,
+		"duration", time.Since(t.start),
+	}, t.phases...)
+	klog.InfoS("challenge processed", keysAndValues...)
+}
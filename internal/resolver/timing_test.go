@@ -0,0 +1,117 @@
+package resolver
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/cert-manager/cert-manager/pkg/acme/webhook/apis/acme/v1alpha1"
+	mockresolver "github.com/rikotsev/cert-manager-webhook-designate/internal/resolver/mock"
+	"k8s.io/klog/v2"
+)
+
+func TestDesignateDnsResolver_TimingLogs(t *testing.T) {
+	tcs := []struct {
+		name                string
+		enabled             bool
+		errorAuthenticating bool
+		call                func(*designateDnsResolver) error
+		expectedFields      []string
+		unexpectedFields    []string
+	}{
+		{
+			name:           "present",
+			enabled:        true,
+			call:           presentTimingChallenge,
+			expectedFields: []string{`operation="present"`, "success=true", "duration=", "auth=", "match=", "mutate="},
+		},
+		{
+			name:    "cleanup",
+			enabled: true,
+			call: func(d *designateDnsResolver) error {
+				if err := presentTimingChallenge(d); err != nil {
+					return err
+				}
+				return d.CleanUp(timingChallenge())
+			},
+			expectedFields: []string{`operation="cleanup"`, "success=true", "duration=", "auth=", "match=", "mutate="},
+		},
+		{
+			name:                "failed authentication",
+			enabled:             true,
+			errorAuthenticating: true,
+			call:                presentTimingChallenge,
+			expectedFields:      []string{`operation="present"`, "success=false", "duration=", "auth="},
+			unexpectedFields:    []string{"match=", "mutate="},
+		},
+		{
+			name: "disabled",
+			call: presentTimingChallenge,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			klog.LogToStderr(false)
+			klog.SetOutput(&logs)
+			t.Cleanup(func() {
+				klog.SetOutput(os.Stderr)
+				klog.LogToStderr(true)
+			})
+
+			mockApi := mockresolver.CreateMockOpenstackApi(t)
+			mockApi.Zones = []mockresolver.MockZone{
+				{
+					ID:   "12345",
+					Name: "example.com.",
+				},
+			}
+			mockApi.ErrorAuthenticating = tc.errorAuthenticating
+			resolver := newTestResolver(t, mockApi)
+			WithTimingLogs(tc.enabled)(resolver)
+
+			err := tc.call(resolver)
+			if (err != nil) != tc.errorAuthenticating {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			klog.Flush()
+
+			var line string
+			for _, l := range strings.Split(logs.String(), "\n") {
+				if strings.Contains(l, "challenge processed") {
+					line = l
+				}
+			}
+
+			for _, field := range tc.expectedFields {
+				if !strings.Contains(line, field) {
+					t.Errorf("expected the timing log to contain %s, got %q", field, line)
+				}
+			}
+			if !tc.enabled && line != "" {
+				t.Errorf("expected no timing log, got %q", line)
+			}
+			for _, field := range tc.unexpectedFields {
+				if strings.Contains(line, field) {
+					t.Errorf("expected the timing log not to contain %s, got %q", field, line)
+				}
+			}
+		})
+	}
+}
+
+func timingChallenge() *v1alpha1.ChallengeRequest {
+	return newChallengeRequest("challenge", "cool.example.com", "example.com", `{
+		"secretName": "foo",
+		"secretNamespace": "bar",
+		"strategy": {
+			"kind": "SOA"
+		}
+	}`)
+}
+
+func presentTimingChallenge(d *designateDnsResolver) error {
+	return d.Present(timingChallenge())
+}
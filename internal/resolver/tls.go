@@ -0,0 +1,69 @@
+package resolver
+
+import (
+	"cmp"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/gophercloud/gophercloud/v2/openstack"
+)
+
+// defaultHTTPTimeout bounds every HTTP request to keystone and designate unless the config sets
+// httpTimeout.
+const defaultHTTPTimeout = 30 * time.Second
+
+var ErrInvalidCACert = errors.New("invalid caCert, expected PEM encoded certificates")
+
+// authenticatedClient authenticates against keystone. The TLS settings and the HTTP timeout apply to
+// keystone as well as to every service client derived from the provider client.
+func (c *AuthConfig) authenticatedClient(ctx context.Context, insecureSkipVerify bool) (*gophercloud.ProviderClient, error) {
+	client, err := openstack.NewClient(c.authOpts.IdentityEndpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(c.caCert) > 0 || insecureSkipVerify {
+		httpClient, err := tlsHTTPClient(c.caCert, insecureSkipVerify)
+		if err != nil {
+			return nil, err
+		}
+		client.HTTPClient = *httpClient
+	}
+	client.HTTPClient.Timeout = cmp.Or(c.httpTimeout, defaultHTTPTimeout)
+
+	if err := c.authenticate(ctx, client); err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+// tlsHTTPClient returns an HTTP client trusting the given certificates on top of the system pool, or
+// not verifying certificates at all.
+func tlsHTTPClient(caCert []byte, insecureSkipVerify bool) (*http.Client, error) {
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: insecureSkipVerify,
+	}
+
+	if len(caCert) > 0 {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+
+		if !pool.AppendCertsFromPEM(caCert) {
+			return nil, ErrInvalidCACert
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+
+	return &http.Client{Transport: transport}, nil
+}
@@ -0,0 +1,156 @@
+package resolver
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	mockresolver "github.com/rikotsev/cert-manager-webhook-designate/internal/resolver/mock"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/klog/v2"
+)
+
+func TestDesignateDnsResolver_Present_CACert(t *testing.T) {
+	mockApi := mockresolver.CreateMockOpenstackApi(t)
+	mockApi.Zones = []mockresolver.MockZone{
+		{
+			ID:   "12345",
+			Name: "example.com.",
+		},
+	}
+	openstackMock := httptest.NewTLSServer(mockApi)
+	t.Cleanup(openstackMock.Close)
+
+	serverCert := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: openstackMock.Certificate().Raw}))
+
+	tcs := []struct {
+		name          string
+		caCert        string
+		expectedError error
+	}{
+		{
+			name:   "trusted server certificate",
+			caCert: serverCert,
+		},
+		{
+			name:          "invalid caCert",
+			caCert:        "not a certificate",
+			expectedError: ErrInvalidCACert,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			resolver := newTLSTestResolver(openstackMock.URL, tc.caCert)
+
+			err := resolver.Present(newChallengeRequest("challenge", "cool.example.com", "example.com", `{
+				"secretName": "foo",
+				"secretNamespace": "bar",
+				"strategy": {
+					"kind": "SOA"
+				}
+			}`))
+			if !errors.Is(err, tc.expectedError) {
+				t.Errorf("expected error %v, got %v", tc.expectedError, err)
+			}
+		})
+	}
+
+	t.Run("untrusted server certificate", func(t *testing.T) {
+		resolver := newTLSTestResolver(openstackMock.URL, "")
+
+		err := resolver.Present(newChallengeRequest("challenge", "cool.example.com", "example.com", `{
+			"secretName": "foo",
+			"secretNamespace": "bar",
+			"strategy": {
+				"kind": "SOA"
+			}
+		}`))
+		var certErr *tls.CertificateVerificationError
+		if !errors.As(err, &certErr) {
+			t.Fatalf("expected a certificate verification error, got %v", err)
+		}
+
+		if isTransientNetworkError(err) {
+			t.Errorf("expected certificate verification errors not to be retried")
+		}
+	})
+}
+
+func TestDesignateDnsResolver_Present_InsecureSkipVerify(t *testing.T) {
+	mockApi := mockresolver.CreateMockOpenstackApi(t)
+	mockApi.Zones = []mockresolver.MockZone{
+		{
+			ID:   "12345",
+			Name: "example.com.",
+		},
+	}
+	openstackMock := httptest.NewTLSServer(mockApi)
+	t.Cleanup(openstackMock.Close)
+
+	for _, insecureSkipVerify := range []bool{false, true} {
+		t.Run(fmt.Sprintf("insecureSkipVerify=%t", insecureSkipVerify), func(t *testing.T) {
+			var logs bytes.Buffer
+			klog.LogToStderr(false)
+			klog.SetOutput(&logs)
+			t.Cleanup(func() {
+				klog.SetOutput(os.Stderr)
+				klog.LogToStderr(true)
+			})
+
+			resolver := newTLSTestResolver(openstackMock.URL, "")
+
+			err := resolver.Present(newChallengeRequest("challenge", "cool.example.com", "example.com", fmt.Sprintf(`{
+				"secretName": "foo",
+				"secretNamespace": "bar",
+				"insecureSkipVerify": %t,
+				"strategy": {
+					"kind": "SOA"
+				}
+			}`, insecureSkipVerify)))
+			klog.Flush()
+
+			var certErr *tls.CertificateVerificationError
+			if insecureSkipVerify {
+				if err != nil {
+					t.Errorf("expected no error, got %v", err)
+				}
+			} else if !errors.As(err, &certErr) {
+				t.Errorf("expected a certificate verification error, got %v", err)
+			}
+
+			warned := strings.Contains(logs.String(), "TLS certificate verification is disabled")
+			if warned != insecureSkipVerify {
+				t.Errorf("expected warning to be logged: %t, got logs %q", insecureSkipVerify, logs.String())
+			}
+		})
+	}
+}
+
+// newTLSTestResolver returns a resolver whose credentials secret "bar/foo" points at the given
+// identity endpoint and trusts caCert, if any.
+func newTLSTestResolver(identityEndpoint, caCert string) *designateDnsResolver {
+	data := map[string]string{
+		"tenantName":       "testTenant",
+		"domainId":         "testDomainId",
+		"username":         "john-doe",
+		"password":         "secretpass",
+		"region":           "RegionOne",
+		"identityEndpoint": identityEndpoint,
+	}
+	if caCert != "" {
+		data["caCert"] = caCert
+	}
+
+	resolver := new(designateDnsResolver)
+	resolver.configProvider = &authConfigProvider{
+		client: fake.NewClientset(dummySecret("foo", "bar", data)),
+	}
+	return resolver
+}
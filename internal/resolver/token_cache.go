@@ -0,0 +1,200 @@
+package resolver
+
+import (
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/gophercloud/gophercloud/v2"
+	tokens2 "github.com/gophercloud/gophercloud/v2/openstack/identity/v2/tokens"
+	tokens3 "github.com/gophercloud/gophercloud/v2/openstack/identity/v3/tokens"
+	"github.com/rikotsev/cert-manager-webhook-designate/internal/metrics"
+	"k8s.io/klog/v2"
+)
+
+// defaultTokenExpirySkew covers the clock skew between the webhook and keystone seen in practice,
+// with room for the challenge to finish before the token expires.
+const defaultTokenExpirySkew = 5 * time.Minute
+
+// WithTokenCache reuses authenticated clients, and with them their keystone token, for challenges
+// with the same credentials until the token is about to expire, see WithTokenExpirySkew. Enabled by
+// default. Tokens expiring while a client is in use are renewed by the client itself.
+func WithTokenCache(enabled bool) Option {
+	return func(d *designateDnsResolver) {
+		d.tokenCache.enabled = enabled
+	}
+}
+
+// WithTokenExpirySkew sets how long before its expiry a cached token is no longer used. The expiry
+// is reported by keystone, so the skew has to cover the clock of the webhook running behind that of
+// keystone.
+func WithTokenExpirySkew(skew time.Duration) Option {
+	return func(d *designateDnsResolver) {
+		d.tokenCache.skew = skew
+	}
+}
+
+type tokenCacheEntry struct {
+	client *gophercloud.ProviderClient
+	// cloud and user describe the entry in cache dumps, as the key is opaque.
+	cloud   string
+	user    string
+	expires time.Time
+}
+
+// tokenCache holds authenticated provider clients by a hash of their auth options and TLS settings.
+// Lookups are counted as hits or misses in metrics.CacheRequests.
+type tokenCache struct {
+	mu      sync.Mutex
+	enabled bool
+	skew    time.Duration
+	entries map[string]tokenCacheEntry
+	// now is replaceable in tests.
+	now func() time.Time
+}
+
+// authenticatedClient returns a cached client for the auth config, or authenticates a new one.
+func (c *tokenCache) authenticatedClient(ctx context.Context, authCfg *AuthConfig, cloud string, insecureSkipVerify bool) (*gophercloud.ProviderClient, error) {
+	if !c.enabled {
+		return authCfg.authenticatedClient(ctx, insecureSkipVerify)
+	}
+
+	key := tokenCacheKey(authCfg, insecureSkipVerify)
+	if client, ok := c.get(key); ok {
+		return client, nil
+	}
+
+	client, err := authCfg.authenticatedClient(ctx, insecureSkipVerify)
+	if err != nil {
+		return nil, err
+	}
+
+	expires, err := tokenExpiry(client.GetAuthResult())
+	if err != nil {
+		klog.V(4).InfoS("not caching client, the token expiry is unknown", "identityEndpoint", authCfg.authOpts.IdentityEndpoint, "err", err)
+		return client, nil
+	}
+	c.put(key, tokenCacheEntry{
+		client:  client,
+		cloud:   cloud,
+		user:    cmp.Or(authCfg.authOpts.Username, authCfg.authOpts.UserID, authCfg.authOpts.ApplicationCredentialName, authCfg.authOpts.ApplicationCredentialID),
+		expires: expires,
+	})
+
+	return client, nil
+}
+
+func (c *tokenCache) get(key string) (*gophercloud.ProviderClient, bool) {
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	if ok && !c.usable(entry.expires) {
+		delete(c.entries, key)
+		ok = false
+	}
+	c.mu.Unlock()
+
+	if !ok {
+		metrics.CacheRequests.WithLabelValues(metrics.CacheOperationClient, metrics.CacheResultMiss).Inc()
+		klog.V(4).InfoS("token cache miss")
+		return nil, false
+	}
+
+	metrics.CacheRequests.WithLabelValues(metrics.CacheOperationClient, metrics.CacheResultHit).Inc()
+	klog.V(4).InfoS("token cache hit", "expiresIn", entry.expires.Sub(c.clock()))
+	return entry.client, true
+}
+
+func (c *tokenCache) put(key string, entry tokenCacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.usable(entry.expires) {
+		klog.V(4).InfoS("not caching client, the token expires within the skew", "expires", entry.expires, "skew", c.skew)
+		return
+	}
+
+	if c.entries == nil {
+		c.entries = make(map[string]tokenCacheEntry)
+	}
+	c.entries[key] = entry
+}
+
+// clear drops every entry.
+func (c *tokenCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries = nil
+}
+
+// usable reports whether a token expiring at the given time may still be used, allowing for the skew.
+func (c *tokenCache) usable(expires time.Time) bool {
+	return c.clock().Before(expires.Add(-c.skew))
+}
+
+func (c *tokenCache) clock() time.Time {
+	if c.now != nil {
+		return c.now()
+	}
+
+	return time.Now()
+}
+
+// tokenCacheKey hashes everything that goes into authenticating, so that clients are only shared by
+// challenges with the same credentials. Unlike the cloud identity it includes the secrets.
+func tokenCacheKey(authCfg *AuthConfig, insecureSkipVerify bool) string {
+	opts := authCfg.authOpts
+	hash := sha256.New()
+	for _, field := range []string{
+		opts.IdentityEndpoint,
+		opts.Username,
+		opts.UserID,
+		opts.Password,
+		opts.Passcode,
+		opts.DomainID,
+		opts.DomainName,
+		opts.TenantID,
+		opts.TenantName,
+		opts.ApplicationCredentialID,
+		opts.ApplicationCredentialName,
+		opts.ApplicationCredentialSecret,
+		string(authCfg.caCert),
+		fmt.Sprint(insecureSkipVerify),
+		authCfg.httpTimeout.String(),
+		string(authCfg.identityAPIVersion),
+		fmt.Sprint(opts.AllowReauth),
+	} {
+		_, _ = io.WriteString(hash, field)
+		_, _ = hash.Write([]byte{0})
+	}
+	if opts.Scope != nil {
+		_, _ = fmt.Fprintf(hash, "%+v", *opts.Scope)
+	}
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// tokenExpiry reads the expiry of the token from the authentication result.
+func tokenExpiry(authResult gophercloud.AuthResult) (time.Time, error) {
+	switch result := authResult.(type) {
+	case tokens2.CreateResult:
+		token, err := result.ExtractToken()
+		if err != nil {
+			return time.Time{}, err
+		}
+		return token.ExpiresAt, nil
+	case tokens3.CreateResult:
+		token, err := result.ExtractToken()
+		if err != nil {
+			return time.Time{}, err
+		}
+		return token.ExpiresAt, nil
+	default:
+		return time.Time{}, fmt.Errorf("cannot read the token from a %T authentication result", authResult)
+	}
+}
@@ -0,0 +1,158 @@
+package resolver
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	mockresolver "github.com/rikotsev/cert-manager-webhook-designate/internal/resolver/mock"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestDesignateDnsResolver_TokenCacheSkew(t *testing.T) {
+	tcs := []struct {
+		name string
+		skew time.Duration
+		// clockOffset is how far the clock of the webhook is ahead of that of keystone.
+		clockOffset           time.Duration
+		elapsed               time.Duration
+		expectedTokenRequests int
+	}{
+		{
+			name:                  "token well within its lifetime",
+			skew:                  5 * time.Minute,
+			elapsed:               30 * time.Minute,
+			expectedTokenRequests: 1,
+		},
+		{
+			name:                  "token within the skew of its expiry",
+			skew:                  5 * time.Minute,
+			elapsed:               56 * time.Minute,
+			expectedTokenRequests: 2,
+		},
+		{
+			name:                  "clock behind keystone is covered by the skew",
+			skew:                  5 * time.Minute,
+			clockOffset:           -3 * time.Minute,
+			elapsed:               58 * time.Minute,
+			expectedTokenRequests: 2,
+		},
+		{
+			name:                  "clock behind keystone without skew reuses an expired token",
+			clockOffset:           -3 * time.Minute,
+			elapsed:               58 * time.Minute,
+			expectedTokenRequests: 1,
+		},
+		{
+			name:                  "clock ahead of keystone reauthenticates early",
+			skew:                  5 * time.Minute,
+			clockOffset:           10 * time.Minute,
+			elapsed:               46 * time.Minute,
+			expectedTokenRequests: 2,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			keystoneNow := time.Now()
+			mockApi := mockresolver.CreateMockOpenstackApi(t)
+			mockApi.Zones = []mockresolver.MockZone{
+				{
+					ID:   "12345",
+					Name: "example.com.",
+				},
+			}
+			mockApi.TokenExpiresAt = keystoneNow.Add(time.Hour)
+			resolver := newTestResolver(t, mockApi)
+			WithTokenCache(true)(resolver)
+			WithTokenExpirySkew(tc.skew)(resolver)
+			now := keystoneNow.Add(tc.clockOffset)
+			resolver.tokenCache.now = func() time.Time { return now }
+
+			ch := newChallengeRequest("challenge", "cool.example.com", "example.com", `{
+				"secretName": "foo",
+				"secretNamespace": "bar",
+				"strategy": {
+					"kind": "BestEffort"
+				}
+			}`)
+
+			if err := resolver.Present(ch); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			now = now.Add(tc.elapsed)
+			if err := resolver.CleanUp(ch); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if mockApi.TokenRequests != tc.expectedTokenRequests {
+				t.Errorf("expected %d authentication requests, got %d", tc.expectedTokenRequests, mockApi.TokenRequests)
+			}
+		})
+	}
+}
+
+func TestDesignateDnsResolver_TokenCacheCredentials(t *testing.T) {
+	tcs := []struct {
+		name                  string
+		secondSecret          string
+		expectedTokenRequests int
+	}{
+		{
+			name:                  "identical credentials",
+			secondSecret:          "foo",
+			expectedTokenRequests: 1,
+		},
+		{
+			name:                  "differing credentials",
+			secondSecret:          "other",
+			expectedTokenRequests: 2,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			mockApi := mockresolver.CreateMockOpenstackApi(t)
+			mockApi.Zones = []mockresolver.MockZone{
+				{
+					ID:   "12345",
+					Name: "example.com.",
+				},
+			}
+			resolver := newTestResolver(t, mockApi)
+			WithTokenCache(true)(resolver)
+
+			foo, err := resolver.configProvider.client.CoreV1().Secrets("bar").Get(context.Background(), "foo", metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("failed to get the secret: %v", err)
+			}
+			other := foo.DeepCopy()
+			other.Name = "other"
+			other.ResourceVersion = ""
+			other.Data["username"] = []byte("jane-doe")
+			other.Data["password"] = []byte("otherpass")
+			if _, err := resolver.configProvider.client.CoreV1().Secrets("bar").Create(context.Background(), other, metav1.CreateOptions{}); err != nil {
+				t.Fatalf("failed to create the secret: %v", err)
+			}
+
+			for i, secretName := range []string{"foo", tc.secondSecret} {
+				ch := newChallengeRequest(fmt.Sprintf("challenge-%d", i), "cool.example.com", "example.com", fmt.Sprintf(`{
+					"secretName": %q,
+					"secretNamespace": "bar",
+					"strategy": {
+						"kind": "BestEffort"
+					}
+				}`, secretName))
+				if err := resolver.Present(ch); err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+			}
+
+			if mockApi.TokenRequests != tc.expectedTokenRequests {
+				t.Errorf("expected %d authentication requests, got %d", tc.expectedTokenRequests, mockApi.TokenRequests)
+			}
+		})
+	}
+}
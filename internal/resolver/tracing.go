@@ -0,0 +1,67 @@
+package resolver
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// tracerName is the instrumentation scope of the spans of the resolver.
+const tracerName = "github.com/rikotsev/cert-manager-webhook-designate/internal/resolver"
+
+// Names of the spans of a challenge. The operations are the roots, the others their children.
+const (
+	spanPresent         = "Present"
+	spanCleanUp         = "CleanUp"
+	spanAuthenticate    = "Authenticate"
+	spanListZones       = "ListZones"
+	spanListRecordSets  = "ListRecordSets"
+	spanCreateRecordSet = "CreateRecordSet"
+	spanUpdateRecordSet = "UpdateRecordSet"
+	spanDeleteRecordSet = "DeleteRecordSet"
+)
+
+// Attributes of the spans of a challenge.
+const (
+	attrFQDN        = attribute.Key("acme.fqdn")
+	attrZoneID      = attribute.Key("designate.zone_id")
+	attrRecordSetID = attribute.Key("designate.recordset_id")
+	attrStrategy    = attribute.Key("designate.strategy")
+)
+
+// WithTracerProvider records a span for every Present and CleanUp, with child spans for
+// authenticating, listing zones and listing and writing recordsets. Without it no spans are recorded.
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(d *designateDnsResolver) {
+		d.tracer = provider.Tracer(tracerName)
+	}
+}
+
+// startOperationSpan starts the root span of a challenge operation.
+func (d *designateDnsResolver) startOperationSpan(ctx context.Context, name, fqdn string) (context.Context, trace.Span) {
+	tracer := d.tracer
+	if tracer == nil {
+		tracer = noop.NewTracerProvider().Tracer(tracerName)
+	}
+
+	return tracer.Start(ctx, name, trace.WithAttributes(attrFQDN.String(fqdn)))
+}
+
+// startSpan starts a child of the span in ctx with the tracer that started it, so that calls
+// outside of a traced operation record nothing.
+func startSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	tracer := trace.SpanFromContext(ctx).TracerProvider().Tracer(tracerName)
+	return tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// endSpan ends the span, marking it failed with err if there is one.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,77 @@
+package resolver
+
+import (
+	"testing"
+
+	mockresolver "github.com/rikotsev/cert-manager-webhook-designate/internal/resolver/mock"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestDesignateDnsResolver_Present_Spans(t *testing.T) {
+	mockApi := mockresolver.CreateMockOpenstackApi(t)
+	mockApi.Zones = []mockresolver.MockZone{
+		{
+			ID:   "12345",
+			Name: "example.com.",
+		},
+	}
+	resolver := newTestResolver(t, mockApi)
+	exporter := tracetest.NewInMemoryExporter()
+	WithTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))(resolver)
+
+	ch := newChallengeRequest("challenge", "cool.example.com", "example.com", `{
+		"secretName": "foo",
+		"secretNamespace": "bar",
+		"strategy": {
+			"kind": "SOA"
+		}
+	}`)
+	if err := resolver.Present(ch); err != nil {
+		t.Fatalf("expected no error presenting, got %v", err)
+	}
+
+	spans := make(map[string]tracetest.SpanStub)
+	for _, span := range exporter.GetSpans() {
+		spans[span.Name] = span
+	}
+
+	root, ok := spans[spanPresent]
+	if !ok {
+		t.Fatalf("expected a %s span, got %v", spanPresent, exporter.GetSpans())
+	}
+	expectedAttrs := []attribute.KeyValue{
+		attrFQDN.String("cool.example.com"),
+		attrZoneID.String("12345"),
+		attrStrategy.String("SOA"),
+	}
+	for _, attr := range expectedAttrs {
+		if !hasAttribute(root, attr) {
+			t.Errorf("expected the %s span to have %s=%s, got %v", spanPresent, attr.Key, attr.Value.Emit(), root.Attributes)
+		}
+	}
+
+	for _, name := range []string{spanAuthenticate, spanListZones, spanListRecordSets, spanCreateRecordSet} {
+		span, ok := spans[name]
+		if !ok {
+			t.Errorf("expected a %s span", name)
+			continue
+		}
+		if span.Parent.SpanID() != root.SpanContext.SpanID() {
+			t.Errorf("expected the %s span to be a child of the %s span", name, spanPresent)
+		}
+	}
+	if create := spans[spanCreateRecordSet]; !hasAttribute(create, attrZoneID.String("12345")) {
+		t.Errorf("expected the %s span to carry the zone ID, got %v", spanCreateRecordSet, create.Attributes)
+	}
+}
+
+func hasAttribute(span tracetest.SpanStub, attr attribute.KeyValue) bool {
+	for _, got := range span.Attributes {
+		if got == attr {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,77 @@
+package resolver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/gophercloud/gophercloud/v2/openstack/dns/v2/recordsets"
+	"k8s.io/klog/v2"
+)
+
+var ErrRecordSetNotSettled = errors.New("the recordset did not become active in time")
+var ErrRecordSetErrored = errors.New("designate failed to apply the recordset")
+
+var (
+	recordSetPollInterval = 2 * time.Second
+	// recordSetWaitTimeout is the default of ChallengeConfig.PropagationTimeout.
+	recordSetWaitTimeout = 2 * time.Minute
+)
+
+// recordSetSettled reports whether designate finished applying a recordset. Designate may already
+// report ACTIVE while an UPDATE or DELETE is still queued, so the pending action has to be NONE too.
+func recordSetSettled(rs *recordsets.RecordSet) (bool, error) {
+	if rs.Status == "ERROR" {
+		return false, fmt.Errorf("%w: %s", ErrRecordSetErrored, rs.ID)
+	}
+
+	return rs.Status == "ACTIVE" && (rs.Action == "NONE" || rs.Action == ""), nil
+}
+
+// waitForRecordSet polls the recordset, which has just been written, until it is settled or the
+// timeout elapses. A settled state of the zone observed after the write, e.g. by the challenge of
+// another SAN in the zone, is reused from the propagation cache.
+func (d *designateDnsResolver) waitForRecordSet(ctx context.Context, designateClient *gophercloud.ServiceClient, cloud, zoneId, recordSetId string, timeout time.Duration) error {
+	writtenAt := d.propagationCache.clock()
+	key := propagationKey(cloud, zoneId, designatePool)
+	if d.propagationCache.settledSince(key, writtenAt) {
+		return nil
+	}
+
+	// The wait has its own timeout, which usually exceeds the operation timeout.
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), timeout)
+	defer cancel()
+
+	var last *recordsets.RecordSet
+	for {
+		observedAt := d.propagationCache.clock()
+		rs, err := recordsets.Get(ctx, designateClient, zoneId, recordSetId).Extract()
+		if err != nil {
+			// The timeout may elapse while polling, which still means the recordset did not settle.
+			if last != nil && ctx.Err() != nil {
+				return fmt.Errorf("%w: %s is still %s with pending action %s after %s", ErrRecordSetNotSettled, recordSetId, last.Status, last.Action, timeout)
+			}
+			return err
+		}
+		last = rs
+
+		settled, err := recordSetSettled(rs)
+		if err != nil {
+			return err
+		}
+		if settled {
+			d.propagationCache.put(key, observedAt)
+			return nil
+		}
+
+		klog.V(4).InfoS("waiting for recordset to settle", "zoneId", zoneId, "recordsetId", recordSetId, "status", rs.Status, "action", rs.Action)
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w: %s is still %s with pending action %s after %s", ErrRecordSetNotSettled, recordSetId, rs.Status, rs.Action, timeout)
+		case <-time.After(recordSetPollInterval):
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package resolver
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gophercloud/gophercloud/v2/openstack/dns/v2/recordsets"
+	mockresolver "github.com/rikotsev/cert-manager-webhook-designate/internal/resolver/mock"
+)
+
+func TestRecordSetSettled(t *testing.T) {
+	tcs := []struct {
+		name            string
+		status          string
+		action          string
+		expectedSettled bool
+		expectedError   error
+	}{
+		{
+			name:            "active without pending action",
+			status:          "ACTIVE",
+			action:          "NONE",
+			expectedSettled: true,
+		},
+		{
+			name:            "active with pending update",
+			status:          "ACTIVE",
+			action:          "UPDATE",
+			expectedSettled: false,
+		},
+		{
+			name:            "pending create",
+			status:          "PENDING",
+			action:          "CREATE",
+			expectedSettled: false,
+		},
+		{
+			name:          "errored",
+			status:        "ERROR",
+			action:        "CREATE",
+			expectedError: ErrRecordSetErrored,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			settled, err := recordSetSettled(&recordsets.RecordSet{ID: "12345-1", Status: tc.status, Action: tc.action})
+			if !errors.Is(err, tc.expectedError) {
+				t.Errorf("expected error %v, got %v", tc.expectedError, err)
+			}
+
+			if settled != tc.expectedSettled {
+				t.Errorf("expected settled %v, got %v", tc.expectedSettled, settled)
+			}
+		})
+	}
+}
+
+func TestDesignateDnsResolver_Present_WaitsForPendingAction(t *testing.T) {
+	previousInterval := recordSetPollInterval
+	recordSetPollInterval = 10 * time.Millisecond
+	t.Cleanup(func() { recordSetPollInterval = previousInterval })
+
+	mockApi := mockresolver.CreateMockOpenstackApi(t)
+	mockApi.Zones = []mockresolver.MockZone{
+		{
+			ID:   "12345",
+			Name: "example.com.",
+		},
+	}
+	mockApi.RecordSets = []mockresolver.MockRecordSet{
+		{
+			ID:      "12345-1",
+			ZoneID:  "12345",
+			Name:    "cool.example.com.",
+			Type:    "TXT",
+			Records: []string{"another-record"},
+		},
+	}
+	mockApi.WriteStates = []mockresolver.MockRecordSetState{
+		{Status: "ACTIVE", Action: "UPDATE"},
+		{Status: "ACTIVE", Action: "UPDATE"},
+	}
+	resolver := newTestResolver(t, mockApi)
+
+	err := resolver.Present(newChallengeRequest("challenge", "cool.example.com", "example.com", `{
+		"secretName": "foo",
+		"secretNamespace": "bar",
+		"waitForPropagation": true,
+		"strategy": {
+			"kind": "SOA"
+		}
+	}`))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if mockApi.RecordSetGets != 3 {
+		t.Errorf("expected 3 polls until the action settled, got %d", mockApi.RecordSetGets)
+	}
+}
+
+func TestDesignateDnsResolver_Present_WaitsForPendingStatus(t *testing.T) {
+	previousInterval := recordSetPollInterval
+	recordSetPollInterval = 10 * time.Millisecond
+	t.Cleanup(func() { recordSetPollInterval = previousInterval })
+
+	tcs := []struct {
+		name               string
+		pendingPolls       int
+		propagationTimeout string
+		expectedError      error
+		expectedMessage    string
+	}{
+		{
+			name:         "active after three pending polls",
+			pendingPolls: 3,
+		},
+		{
+			name:               "still pending when the timeout elapses",
+			pendingPolls:       1000,
+			propagationTimeout: "100ms",
+			expectedError:      ErrRecordSetNotSettled,
+			expectedMessage:    "is still PENDING with pending action CREATE after 100ms",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			mockApi := mockresolver.CreateMockOpenstackApi(t)
+			mockApi.Zones = []mockresolver.MockZone{
+				{
+					ID:   "12345",
+					Name: "example.com.",
+				},
+			}
+			for range tc.pendingPolls {
+				mockApi.WriteStates = append(mockApi.WriteStates, mockresolver.MockRecordSetState{Status: "PENDING", Action: "CREATE"})
+			}
+			resolver := newTestResolver(t, mockApi)
+
+			err := resolver.Present(newChallengeRequest("challenge", "cool.example.com", "example.com", fmt.Sprintf(`{
+				"secretName": "foo",
+				"secretNamespace": "bar",
+				"waitForPropagation": true,
+				"propagationTimeout": %q,
+				"strategy": {
+					"kind": "SOA"
+				}
+			}`, cmp.Or(tc.propagationTimeout, "1m"))))
+			if tc.expectedError != nil {
+				if !errors.Is(err, tc.expectedError) || !strings.Contains(err.Error(), tc.expectedMessage) {
+					t.Fatalf("expected error %v containing %q, got %v", tc.expectedError, tc.expectedMessage, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if mockApi.RecordSetGets != tc.pendingPolls+1 {
+				t.Errorf("expected %d polls until the recordset became active, got %d", tc.pendingPolls+1, mockApi.RecordSetGets)
+			}
+		})
+	}
+}
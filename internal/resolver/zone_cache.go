@@ -0,0 +1,91 @@
+package resolver
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gophercloud/gophercloud/v2/openstack/dns/v2/zones"
+	"github.com/rikotsev/cert-manager-webhook-designate/internal/metrics"
+	"k8s.io/klog/v2"
+)
+
+// WithZoneCacheTTL keeps zone listings for the given time, so that challenges issued shortly after
+// each other in the same cloud do not list the zones again. A TTL of zero or less disables the cache.
+func WithZoneCacheTTL(ttl time.Duration) Option {
+	return func(d *designateDnsResolver) {
+		d.zoneCache.ttl = ttl
+	}
+}
+
+type zoneCacheEntry struct {
+	zones   []zones.Zone
+	expires time.Time
+}
+
+// zoneCache holds zone listings by cloud identity and listing options. Lookups are counted as hits or
+// misses in metrics.CacheRequests.
+type zoneCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]zoneCacheEntry
+	// now is replaceable in tests.
+	now func() time.Time
+}
+
+func (c *zoneCache) enabled() bool {
+	return c.ttl > 0
+}
+
+func (c *zoneCache) get(key string) ([]zones.Zone, bool) {
+	if !c.enabled() {
+		return nil, false
+	}
+
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	if ok && !c.clock().Before(entry.expires) {
+		delete(c.entries, key)
+		ok = false
+	}
+	c.mu.Unlock()
+
+	if !ok {
+		metrics.CacheRequests.WithLabelValues(metrics.CacheOperationZoneList, metrics.CacheResultMiss).Inc()
+		klog.V(4).InfoS("zone cache miss", "key", key)
+		return nil, false
+	}
+
+	metrics.CacheRequests.WithLabelValues(metrics.CacheOperationZoneList, metrics.CacheResultHit).Inc()
+	klog.V(4).InfoS("zone cache hit", "key", key, "expiresIn", entry.expires.Sub(c.clock()))
+	return entry.zones, true
+}
+
+func (c *zoneCache) put(key string, allZones []zones.Zone) {
+	if !c.enabled() {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.entries == nil {
+		c.entries = make(map[string]zoneCacheEntry)
+	}
+	c.entries[key] = zoneCacheEntry{zones: allZones, expires: c.clock().Add(c.ttl)}
+}
+
+// clear drops every entry.
+func (c *zoneCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries = nil
+}
+
+func (c *zoneCache) clock() time.Time {
+	if c.now != nil {
+		return c.now()
+	}
+
+	return time.Now()
+}
@@ -0,0 +1,82 @@
+package resolver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/rikotsev/cert-manager-webhook-designate/internal/metrics"
+	mockresolver "github.com/rikotsev/cert-manager-webhook-designate/internal/resolver/mock"
+)
+
+func TestDesignateDnsResolver_ZoneCache(t *testing.T) {
+	mockApi := mockresolver.CreateMockOpenstackApi(t)
+	mockApi.Zones = []mockresolver.MockZone{
+		{
+			ID:   "12345",
+			Name: "example.com.",
+		},
+	}
+	resolver := newTestResolver(t, mockApi)
+	WithZoneCacheTTL(time.Minute)(resolver)
+	now := time.Now()
+	resolver.zoneCache.now = func() time.Time { return now }
+
+	hits := metrics.CacheRequests.WithLabelValues(metrics.CacheOperationZoneList, metrics.CacheResultHit)
+	misses := metrics.CacheRequests.WithLabelValues(metrics.CacheOperationZoneList, metrics.CacheResultMiss)
+	initialHits, initialMisses := testutil.ToFloat64(hits), testutil.ToFloat64(misses)
+
+	ch := newChallengeRequest("challenge", "cool.example.com", "example.com", `{
+		"secretName": "foo",
+		"secretNamespace": "bar",
+		"strategy": {
+			"kind": "BestEffort"
+		}
+	}`)
+
+	steps := []struct {
+		name           string
+		advance        time.Duration
+		expectedHits   float64
+		expectedMisses float64
+		expectedLists  int
+	}{
+		{
+			name:           "cold cache",
+			expectedMisses: 1,
+			expectedLists:  1,
+		},
+		{
+			name:           "warm cache",
+			advance:        30 * time.Second,
+			expectedHits:   1,
+			expectedMisses: 1,
+			expectedLists:  1,
+		},
+		{
+			name:           "expired cache",
+			advance:        time.Minute,
+			expectedHits:   1,
+			expectedMisses: 2,
+			expectedLists:  2,
+		},
+	}
+
+	for _, step := range steps {
+		now = now.Add(step.advance)
+
+		if err := resolver.Present(ch); err != nil {
+			t.Fatalf("%s: expected no error, got %v", step.name, err)
+		}
+
+		if actual := testutil.ToFloat64(hits) - initialHits; actual != step.expectedHits {
+			t.Errorf("%s: expected %v hits, got %v", step.name, step.expectedHits, actual)
+		}
+		if actual := testutil.ToFloat64(misses) - initialMisses; actual != step.expectedMisses {
+			t.Errorf("%s: expected %v misses, got %v", step.name, step.expectedMisses, actual)
+		}
+		if mockApi.ZoneListCalls != step.expectedLists {
+			t.Errorf("%s: expected %d zone listings, got %d", step.name, step.expectedLists, mockApi.ZoneListCalls)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package resolver
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/gophercloud/gophercloud/v2/openstack/dns/v2/zones"
+	"github.com/gophercloud/gophercloud/v2/pagination"
+	"k8s.io/klog/v2"
+)
+
+// WithZoneListPageSize asks designate for at most size zones per page when listing zones. Zero or
+// less keeps the default page size of designate. Without the zone cache, it also bounds the zones
+// held at a time, see listZonesContaining.
+func WithZoneListPageSize(size int) Option {
+	return func(d *designateDnsResolver) {
+		d.zoneListPageSize = max(size, 0)
+	}
+}
+
+// eachZonePage lists the zones page by page, handing the zones of every page to fn, so that only a
+// single page of the raw response is held in memory at a time. Listing stops once fn returns false
+// or at the first error, and tells whether pages were left unread.
+func (d *designateDnsResolver) eachZonePage(ctx context.Context, designateClient *gophercloud.ServiceClient, opts zones.ListOpts, fn func([]zones.Zone) (bool, error)) (truncated bool, err error) {
+	opts.Limit = d.zoneListPageSize
+	err = zones.List(designateClient, opts).EachPage(ctx, func(_ context.Context, page pagination.Page) (bool, error) {
+		pageZones, err := zones.ExtractZones(page)
+		if err != nil {
+			return false, err
+		}
+
+		more, err := fn(pageZones)
+		if !more && err == nil {
+			next, _ := page.(zones.ZonePage).NextPageURL()
+			truncated = next != ""
+		}
+		return more, err
+	})
+	return truncated, err
+}
+
+// listZonesContaining lists the zones in scope that name is within, for picking the longest one.
+// With the zone cache, the whole listing is cached and shared, see listZones. Without it, only the
+// zones of each page containing name are kept, and paging stops after the first page with a zone named
+// name or its direct parent that final accepts. Zones of those two names are then listed by name, so
+// that zones of the same name on later pages, and a zone of name itself, are still considered.
+func (d *designateDnsResolver) listZonesContaining(ctx context.Context, cfg *ChallengeConfig, cloud, name string, designateClient *gophercloud.ServiceClient, final func(zones.Zone) bool) (_ []zones.Zone, err error) {
+	if d.zoneCache.enabled() {
+		return d.listScopedZones(ctx, cfg, cloud, designateClient, zones.ListOpts{})
+	}
+
+	inScope, err := zoneScopeFilter(cfg, designateClient)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, span := startSpan(ctx, spanListZones)
+	defer func() { endSpan(span, err) }()
+	ctx, cancel := phaseContext(ctx, d.listTimeout)
+	defer cancel()
+
+	name = canonicalName(name)
+	_, parent, _ := strings.Cut(name, ".")
+	var containing []zones.Zone
+	keep := func(zone zones.Zone) bool {
+		if !isWithinZone(name, zone.Name) || !inScope(zone) || slices.ContainsFunc(containing, func(z zones.Zone) bool { return z.ID == zone.ID }) {
+			return false
+		}
+		containing = append(containing, zone)
+		return true
+	}
+
+	truncated, err := d.eachZonePage(ctx, designateClient, zones.ListOpts{}, func(pageZones []zones.Zone) (bool, error) {
+		closest := false
+		for _, zone := range pageZones {
+			if !keep(zone) {
+				continue
+			}
+
+			if zoneName := canonicalName(zone.Name); (zoneName == name || zoneName == parent) && final(zone) {
+				klog.V(4).InfoS("stopping the zone listing after the page of the closest zone", "name", name, "zone", zone.Name, "id", zone.ID)
+				closest = true
+			}
+		}
+		return !closest, nil
+	})
+	if err != nil || !truncated {
+		return containing, err
+	}
+
+	for _, zoneName := range []string{name, parent} {
+		if zoneName == "" || zoneName == "." {
+			continue
+		}
+		_, err := d.eachZonePage(ctx, designateClient, zones.ListOpts{Name: zoneName}, func(pageZones []zones.Zone) (bool, error) {
+			for _, zone := range pageZones {
+				keep(zone)
+			}
+			return true, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return containing, nil
+}
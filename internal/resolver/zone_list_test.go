@@ -0,0 +1,197 @@
+package resolver
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/gophercloud/gophercloud/v2/openstack/dns/v2/zones"
+	mockresolver "github.com/rikotsev/cert-manager-webhook-designate/internal/resolver/mock"
+)
+
+func TestDesignateDnsResolver_ZoneListPageSize(t *testing.T) {
+	mockApi := mockresolver.CreateMockOpenstackApi(t)
+	// The most specific zone is listed last, so that it is only matched if every page is consumed.
+	mockApi.Zones = []mockresolver.MockZone{
+		{ID: "1", Name: "example.com."},
+		{ID: "2", Name: "example.org."},
+		{ID: "3", Name: "example.net."},
+		{ID: "4", Name: "other.example.com."},
+		{ID: "5", Name: "sub.example.com."},
+	}
+	resolver := newTestResolver(t, mockApi)
+	WithZoneListPageSize(2)(resolver)
+
+	ch := newChallengeRequest("challenge", "cool.sub.example.com", "example.com", `{
+		"secretName": "foo",
+		"secretNamespace": "bar",
+		"strategy": {
+			"kind": "BestEffort"
+		}
+	}`)
+	if err := resolver.Present(ch); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if mockApi.ZoneListCalls != 3 {
+		t.Errorf("expected 3 pages of zones, got %d", mockApi.ZoneListCalls)
+	}
+	if !slices.Equal(mockApi.ZoneListLimits, []string{"2", "2", "2"}) {
+		t.Errorf("expected every page to be limited to 2 zones, got %v", mockApi.ZoneListLimits)
+	}
+	if len(mockApi.Updates) != 1 || mockApi.Updates[0].ZoneID != "5" {
+		t.Errorf("expected the challenge in zone 5, got %+v", mockApi.Updates)
+	}
+}
+
+func TestDesignateDnsResolver_ZoneListPageSize_Default(t *testing.T) {
+	mockApi := mockresolver.CreateMockOpenstackApi(t)
+	mockApi.Zones = []mockresolver.MockZone{
+		{ID: "1", Name: "example.com."},
+		{ID: "2", Name: "example.org."},
+	}
+	resolver := newTestResolver(t, mockApi)
+
+	ch := newChallengeRequest("challenge", "cool.example.com", "example.com", `{
+		"secretName": "foo",
+		"secretNamespace": "bar",
+		"strategy": {
+			"kind": "BestEffort"
+		}
+	}`)
+	if err := resolver.Present(ch); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if !slices.Equal(mockApi.ZoneListLimits, []string{""}) {
+		t.Errorf("expected a single listing with the default page size, got %v", mockApi.ZoneListLimits)
+	}
+}
+
+func TestDesignateDnsResolver_ZoneListPageSize_LargeListing(t *testing.T) {
+	const zoneCount, pageSize = 2500, 100
+
+	mockApi := mockresolver.CreateMockOpenstackApi(t)
+	for i := range zoneCount - 1 {
+		mockApi.Zones = append(mockApi.Zones, mockresolver.MockZone{ID: fmt.Sprintf("zone-%d", i), Name: fmt.Sprintf("example-%d.com.", i)})
+	}
+	// The parent zone is listed first and the most specific zone last, so that matching the
+	// challenge takes every page.
+	mockApi.Zones[0] = mockresolver.MockZone{ID: "parent", Name: "example.com."}
+	mockApi.Zones = append(mockApi.Zones, mockresolver.MockZone{ID: "last", Name: "sub.example.com."})
+	resolver := newTestResolver(t, mockApi)
+	WithZoneListPageSize(pageSize)(resolver)
+	WithZoneCacheTTL(time.Minute)(resolver)
+
+	ch := newChallengeRequest("challenge", "cool.sub.example.com", "example.com", `{
+		"secretName": "foo",
+		"secretNamespace": "bar",
+		"strategy": {
+			"kind": "BestEffort"
+		}
+	}`)
+	if err := resolver.Present(ch); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if mockApi.ZoneListCalls != zoneCount/pageSize {
+		t.Errorf("expected %d pages of zones, got %d", zoneCount/pageSize, mockApi.ZoneListCalls)
+	}
+	for _, limit := range mockApi.ZoneListLimits {
+		if limit != fmt.Sprint(pageSize) {
+			t.Fatalf("expected every page to be limited to %d zones, got %v", pageSize, mockApi.ZoneListLimits)
+		}
+	}
+	if len(mockApi.Updates) != 1 || mockApi.Updates[0].ZoneID != "last" {
+		t.Errorf("expected the challenge in the zone listed last, got %+v", mockApi.Updates)
+	}
+
+	// The page size only bounds the responses, the listing itself keeps every zone.
+	for key, entry := range resolver.zoneCache.entries {
+		if len(entry.zones) != zoneCount {
+			t.Errorf("expected the listing %s to hold all %d zones, got %d", key, zoneCount, len(entry.zones))
+		}
+	}
+	if len(resolver.zoneCache.entries) != 1 {
+		t.Errorf("expected a single cached listing, got %d", len(resolver.zoneCache.entries))
+	}
+}
+
+func TestDesignateDnsResolver_ZoneListPageSize_StopsAtClosestZone(t *testing.T) {
+	mockApi := mockresolver.CreateMockOpenstackApi(t)
+	// The direct parent of the record name is on the first page, a zone of the same name with a lower
+	// ID on a later one.
+	mockApi.Zones = []mockresolver.MockZone{
+		{ID: "b", Name: "sub.example.com."},
+		{ID: "1", Name: "example.org."},
+		{ID: "2", Name: "example.net."},
+		{ID: "3", Name: "other.example.com."},
+		{ID: "a", Name: "sub.example.com."},
+		{ID: "4", Name: "example.edu."},
+	}
+	resolver := newTestResolver(t, mockApi)
+	WithZoneListPageSize(2)(resolver)
+
+	ch := newChallengeRequest("challenge", "cool.sub.example.com", "example.com", `{
+		"secretName": "foo",
+		"secretNamespace": "bar",
+		"strategy": {
+			"kind": "BestEffort"
+		}
+	}`)
+	if err := resolver.Present(ch); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	// A single page of the whole listing, then the record name and its parent by name.
+	expectedQueries := []string{"", "cool.sub.example.com.", "sub.example.com."}
+	if !slices.Equal(mockApi.ZoneListQueries, expectedQueries) {
+		t.Errorf("expected the zone listings %q, got %q", expectedQueries, mockApi.ZoneListQueries)
+	}
+	if len(mockApi.Updates) != 1 || mockApi.Updates[0].ZoneID != "a" {
+		t.Errorf("expected the challenge in zone a, got %+v", mockApi.Updates)
+	}
+}
+
+func TestDesignateDnsResolver_ListZonesContaining_KeepsOnlyContainingZones(t *testing.T) {
+	const zoneCount, pageSize = 2500, 100
+
+	mockApi := mockresolver.CreateMockOpenstackApi(t)
+	for i := range zoneCount - 1 {
+		mockApi.Zones = append(mockApi.Zones, mockresolver.MockZone{ID: fmt.Sprintf("zone-%d", i), Name: fmt.Sprintf("example-%d.com.", i)})
+	}
+	mockApi.Zones[0] = mockresolver.MockZone{ID: "parent", Name: "example.com."}
+	mockApi.Zones = append(mockApi.Zones, mockresolver.MockZone{ID: "last", Name: "sub.example.com."})
+	resolver := newTestResolver(t, mockApi)
+	WithZoneListPageSize(pageSize)(resolver)
+
+	ch := newChallengeRequest("challenge", "cool.sub.example.com", "example.com", `{
+		"secretName": "foo",
+		"secretNamespace": "bar",
+		"strategy": {
+			"kind": "BestEffort"
+		}
+	}`)
+	designateClient, cfg, cloud, err := resolver.createDesignateClient(context.Background(), ch)
+	if err != nil {
+		t.Fatalf("failed to create the designate client: %v", err)
+	}
+
+	containing, err := resolver.listZonesContaining(context.Background(), cfg, cloud, "cool.sub.example.com.", designateClient, func(zones.Zone) bool { return true })
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if mockApi.ZoneListCalls != zoneCount/pageSize {
+		t.Errorf("expected %d pages of zones, got %d", zoneCount/pageSize, mockApi.ZoneListCalls)
+	}
+	ids := make([]string, 0, len(containing))
+	for _, zone := range containing {
+		ids = append(ids, zone.ID)
+	}
+	if !slices.Equal(ids, []string{"parent", "last"}) {
+		t.Errorf("expected only the zones containing the name to be kept, got %v", ids)
+	}
+}
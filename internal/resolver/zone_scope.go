@@ -0,0 +1,96 @@
+package resolver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/gophercloud/gophercloud/v2/openstack/dns/v2/zones"
+	tokens2 "github.com/gophercloud/gophercloud/v2/openstack/identity/v2/tokens"
+	tokens3 "github.com/gophercloud/gophercloud/v2/openstack/identity/v3/tokens"
+)
+
+const (
+	// ZoneScopeAll matches against every zone the credentials can list, including zones shared by
+	// other projects.
+	ZoneScopeAll = "all"
+	// ZoneScopeProject only matches against the zones owned by the project of the credentials.
+	ZoneScopeProject = "project"
+)
+
+var ErrUnknownProject = errors.New("cannot tell the project the credentials are scoped to")
+
+// listScopedZones lists the zones like listZones and, with the project zone scope, drops the zones
+// owned by other projects. The listing itself, and with it the zone cache, stays unscoped.
+func (d *designateDnsResolver) listScopedZones(ctx context.Context, cfg *ChallengeConfig, cloud string, designateClient *gophercloud.ServiceClient, opts zones.ListOpts) ([]zones.Zone, error) {
+	allZones, err := d.listZones(ctx, cloud, designateClient, opts)
+	if err != nil || cfg.ZoneScope != ZoneScopeProject {
+		return allZones, err
+	}
+
+	inScope, err := zoneScopeFilter(cfg, designateClient)
+	if err != nil {
+		return nil, err
+	}
+
+	scoped := make([]zones.Zone, 0, len(allZones))
+	for _, zone := range allZones {
+		if inScope(zone) {
+			scoped = append(scoped, zone)
+		}
+	}
+
+	return scoped, nil
+}
+
+// zoneScopeFilter returns whether a zone is in the zone scope of the config, which with the project
+// scope are the zones owned by the project of the credentials.
+func zoneScopeFilter(cfg *ChallengeConfig, designateClient *gophercloud.ServiceClient) (func(zones.Zone) bool, error) {
+	if cfg.ZoneScope != ZoneScopeProject {
+		return func(zones.Zone) bool { return true }, nil
+	}
+
+	projectID, err := authenticatedProjectID(designateClient.ProviderClient)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(zone zones.Zone) bool { return zone.ProjectID == projectID }, nil
+}
+
+// authenticatedProjectID returns the ID of the project the token of the provider client is scoped
+// to, as reported by keystone when authenticating.
+func authenticatedProjectID(providerClient *gophercloud.ProviderClient) (string, error) {
+	var projectID string
+	switch result := providerClient.GetAuthResult().(type) {
+	case tokens2.CreateResult:
+		token, err := result.ExtractToken()
+		if err != nil {
+			return "", fmt.Errorf("%w: %w", ErrUnknownProject, err)
+		}
+		projectID = token.Tenant.ID
+	case tokens3.CreateResult:
+		project, err := result.ExtractProject()
+		if err != nil {
+			return "", fmt.Errorf("%w: %w", ErrUnknownProject, err)
+		}
+		if project != nil {
+			projectID = project.ID
+		}
+	case tokens3.GetResult:
+		project, err := result.ExtractProject()
+		if err != nil {
+			return "", fmt.Errorf("%w: %w", ErrUnknownProject, err)
+		}
+		if project != nil {
+			projectID = project.ID
+		}
+	}
+
+	if projectID == "" {
+		return "", fmt.Errorf("%w: the token carries no project", ErrUnknownProject)
+	}
+
+	return projectID, nil
+}
@@ -0,0 +1,98 @@
+package resolver
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	mockresolver "github.com/rikotsev/cert-manager-webhook-designate/internal/resolver/mock"
+)
+
+func TestDesignateDnsResolver_ZoneScope(t *testing.T) {
+	shared := []mockresolver.MockZone{
+		{
+			ID:        "11111",
+			Name:      "example.com.",
+			ProjectID: "otherProject",
+		},
+		{
+			ID:   "12345",
+			Name: "example.com.",
+		},
+		{
+			ID:        "22222",
+			Name:      "sub.example.com.",
+			ProjectID: "otherProject",
+		},
+	}
+
+	tcs := []struct {
+		name           string
+		zones          []mockresolver.MockZone
+		scope          string
+		strategy       string
+		fqdn           string
+		expectedError  error
+		expectedZoneID string
+	}{
+		{
+			name:           "unscoped picks the first zone of the name",
+			zones:          shared,
+			scope:          ZoneScopeAll,
+			strategy:       "SOA",
+			fqdn:           "cool.example.com",
+			expectedZoneID: "11111",
+		},
+		{
+			name:           "project scope picks the own zone of the name",
+			zones:          shared,
+			scope:          ZoneScopeProject,
+			strategy:       "SOA",
+			fqdn:           "cool.example.com",
+			expectedZoneID: "12345",
+		},
+		{
+			name:           "project scope ignores more specific zones of other projects",
+			zones:          shared,
+			scope:          ZoneScopeProject,
+			strategy:       "BestEffort",
+			fqdn:           "cool.sub.example.com",
+			expectedZoneID: "12345",
+		},
+		{
+			name:          "project scope without an own zone",
+			zones:         []mockresolver.MockZone{shared[0]},
+			scope:         ZoneScopeProject,
+			strategy:      "SOA",
+			fqdn:          "cool.example.com",
+			expectedError: ErrNoZones,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			mockApi := mockresolver.CreateMockOpenstackApi(t)
+			mockApi.Zones = tc.zones
+			resolver := newTestResolver(t, mockApi)
+
+			err := resolver.Present(newChallengeRequest("challenge", tc.fqdn, "example.com", fmt.Sprintf(`{
+				"secretName": "foo",
+				"secretNamespace": "bar",
+				"zoneScope": %q,
+				"strategy": {
+					"kind": %q
+				}
+			}`, tc.scope, tc.strategy)))
+			if !errors.Is(err, tc.expectedError) {
+				t.Fatalf("expected error %v, got %v", tc.expectedError, err)
+			}
+			if tc.expectedError != nil {
+				return
+			}
+
+			if len(mockApi.Updates) != 1 || mockApi.Updates[0].ZoneID != tc.expectedZoneID {
+				t.Errorf("expected 1 create in zone %s, got %v", tc.expectedZoneID, mockApi.Updates)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package resolver
+
+import (
+	"context"
+	"strings"
+
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/gophercloud/gophercloud/v2/openstack/dns/v2/zones"
+)
+
+// parentZoneNames returns the name and every parent of it up to the top level domain, most specific
+// first, e.g. a.b.c. yields a.b.c., b.c. and c.
+func parentZoneNames(name string) []string {
+	name = canonicalName(name)
+	if name == "." {
+		return nil
+	}
+
+	var names []string
+	for {
+		names = append(names, name)
+		_, parent, ok := strings.Cut(name, ".")
+		if !ok || parent == "" {
+			return names
+		}
+		name = parent
+	}
+}
+
+// walkLabelsMatchZone selects the zone bestEffortMatchZone would, without listing every zone: it looks
+// up the name and then each of its parents by name and stops at the first active zone. Zones of the
+// same name are settled by the lowest ID.
+func (d *designateDnsResolver) walkLabelsMatchZone(ctx context.Context, cfg *ChallengeConfig, cloud, fqdn string, designateClient *gophercloud.ServiceClient) (*zones.Zone, error) {
+	for _, name := range parentZoneNames(fqdn) {
+		namedZones, err := d.listScopedZones(ctx, cfg, cloud, designateClient, zones.ListOpts{
+			Name: name,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		var matchedZone *zones.Zone
+		for i, z := range namedZones {
+			if !strings.EqualFold(z.Status, zoneStatusActive) || canonicalName(z.Name) != name {
+				continue
+			}
+			if matchedZone == nil || z.ID < matchedZone.ID {
+				matchedZone = &namedZones[i]
+			}
+		}
+		if matchedZone != nil {
+			return matchedZone, nil
+		}
+	}
+
+	return nil, ErrNoZones
+}
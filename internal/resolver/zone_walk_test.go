@@ -0,0 +1,144 @@
+package resolver
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+
+	mockresolver "github.com/rikotsev/cert-manager-webhook-designate/internal/resolver/mock"
+)
+
+func TestParentZoneNames(t *testing.T) {
+	tcs := []struct {
+		name     string
+		expected []string
+	}{
+		{name: "a.b.c", expected: []string{"a.b.c.", "b.c.", "c."}},
+		{name: "A.B.C.", expected: []string{"a.b.c.", "b.c.", "c."}},
+		{name: "com.", expected: []string{"com."}},
+		{name: ".", expected: nil},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := parentZoneNames(tc.name); !slices.Equal(actual, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestDesignateDnsResolver_Present_WalkLabels(t *testing.T) {
+	tcs := []struct {
+		name         string
+		zones        []mockresolver.MockZone
+		fqdn         string
+		expectedZone string
+		// expectedQueries are the names looked up while walking the labels.
+		expectedQueries []string
+	}{
+		{
+			name: "closest parent",
+			zones: []mockresolver.MockZone{
+				{ID: "1", Name: "example.com."},
+				{ID: "2", Name: "sub.example.com."},
+				{ID: "3", Name: "example.org."},
+			},
+			fqdn:            "_acme-challenge.cool.sub.example.com",
+			expectedZone:    "2",
+			expectedQueries: []string{"_acme-challenge.cool.sub.example.com.", "cool.sub.example.com.", "sub.example.com."},
+		},
+		{
+			name: "inactive closest parent",
+			zones: []mockresolver.MockZone{
+				{ID: "1", Name: "example.com."},
+				{ID: "2", Name: "sub.example.com.", Status: "PENDING"},
+			},
+			fqdn:            "cool.sub.example.com",
+			expectedZone:    "1",
+			expectedQueries: []string{"cool.sub.example.com.", "sub.example.com.", "example.com."},
+		},
+		{
+			name: "zone of the record name itself",
+			zones: []mockresolver.MockZone{
+				{ID: "1", Name: "example.com."},
+				{ID: "2", Name: "cool.example.com."},
+			},
+			fqdn:            "cool.example.com",
+			expectedZone:    "2",
+			expectedQueries: []string{"cool.example.com."},
+		},
+		{
+			name: "zones of the same name",
+			zones: []mockresolver.MockZone{
+				{ID: "b", Name: "example.com."},
+				{ID: "a", Name: "example.com."},
+			},
+			fqdn:            "cool.example.com",
+			expectedZone:    "a",
+			expectedQueries: []string{"cool.example.com.", "example.com."},
+		},
+	}
+
+	present := func(t *testing.T, zones []mockresolver.MockZone, fqdn string, walkLabels bool) *mockresolver.OpenstackApiMock {
+		t.Helper()
+
+		mockApi := mockresolver.CreateMockOpenstackApi(t)
+		mockApi.Zones = zones
+		resolver := newTestResolver(t, mockApi)
+
+		ch := newChallengeRequest("challenge", fqdn, "example.com", fmt.Sprintf(`{
+			"secretName": "foo",
+			"secretNamespace": "bar",
+			"strategy": {
+				"kind": "BestEffort",
+				"walkLabels": %t
+			}
+		}`, walkLabels))
+		if err := resolver.Present(ch); err != nil {
+			t.Fatalf("expected no error with walkLabels %t, got %v", walkLabels, err)
+		}
+		if len(mockApi.Updates) != 1 {
+			t.Fatalf("expected a single recordset create with walkLabels %t, got %+v", walkLabels, mockApi.Updates)
+		}
+		return mockApi
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			listed := present(t, tc.zones, tc.fqdn, false)
+			walked := present(t, tc.zones, tc.fqdn, true)
+
+			if listed.Updates[0].ZoneID != walked.Updates[0].ZoneID {
+				t.Errorf("expected walking the labels to choose zone %s like BestEffort, got %s", listed.Updates[0].ZoneID, walked.Updates[0].ZoneID)
+			}
+			if walked.Updates[0].ZoneID != tc.expectedZone {
+				t.Errorf("expected zone %s, got %s", tc.expectedZone, walked.Updates[0].ZoneID)
+			}
+			if !slices.Equal(walked.ZoneListQueries, tc.expectedQueries) {
+				t.Errorf("expected the lookups %v, got %v", tc.expectedQueries, walked.ZoneListQueries)
+			}
+		})
+	}
+}
+
+func TestDesignateDnsResolver_Present_WalkLabels_NoZone(t *testing.T) {
+	mockApi := mockresolver.CreateMockOpenstackApi(t)
+	mockApi.Zones = []mockresolver.MockZone{
+		{ID: "1", Name: "example.org."},
+	}
+	resolver := newTestResolver(t, mockApi)
+
+	ch := newChallengeRequest("challenge", "cool.example.com", "example.com", `{
+		"secretName": "foo",
+		"secretNamespace": "bar",
+		"strategy": {
+			"kind": "BestEffort",
+			"walkLabels": true
+		}
+	}`)
+	if err := resolver.Present(ch); !errors.Is(err, ErrNoZones) {
+		t.Errorf("expected %v, got %v", ErrNoZones, err)
+	}
+}